@@ -59,6 +59,9 @@ func (c *Config) runAddCmd(cmd *cobra.Command, args []string) (err error) {
 	if err := c.ensureSourceDirectory(); err != nil {
 		return err
 	}
+	if err := vfs.MkdirAll(c.mutator, ts.SourceDir, 0o777&^os.FileMode(c.Umask)); err != nil {
+		return err
+	}
 	destDirPrefix := filepath.FromSlash(ts.DestDir + "/")
 	var quit int // quit is an int with a unique address
 	defer func() {
@@ -93,6 +96,18 @@ func (c *Config) runAddCmd(cmd *cobra.Command, args []string) (err error) {
 						cmd.Printf("warning: %s: skipping file generated by template, use --force to force\n", path)
 						return nil
 					}
+					if file, ok := entry.(*chezmoi.File); ok && file.Modify {
+						cmd.Printf("warning: %s: skipping file generated by modify script, use --force to force\n", path)
+						return nil
+					}
+					if file, ok := entry.(*chezmoi.File); ok && file.Block {
+						cmd.Printf("warning: %s: skipping file containing managed block, use --force to force\n", path)
+						return nil
+					}
+					if file, ok := entry.(*chezmoi.File); ok && file.Merge {
+						cmd.Printf("warning: %s: skipping file generated by merging a partial document, use --force to force\n", path)
+						return nil
+					}
 				}
 				if c.add.prompt {
 					choice, err := c.prompt(fmt.Sprintf("Add %s", path), "ynqa")
@@ -127,6 +142,18 @@ func (c *Config) runAddCmd(cmd *cobra.Command, args []string) (err error) {
 					cmd.Printf("warning: %s: skipping file generated by template, use --force to force\n", path)
 					continue
 				}
+				if file, ok := entry.(*chezmoi.File); ok && file.Modify {
+					cmd.Printf("warning: %s: skipping file generated by modify script, use --force to force\n", path)
+					continue
+				}
+				if file, ok := entry.(*chezmoi.File); ok && file.Block {
+					cmd.Printf("warning: %s: skipping file containing managed block, use --force to force\n", path)
+					continue
+				}
+				if file, ok := entry.(*chezmoi.File); ok && file.Merge {
+					cmd.Printf("warning: %s: skipping file generated by merging a partial document, use --force to force\n", path)
+					continue
+				}
 			}
 			if c.add.prompt {
 				choice, err := c.prompt(fmt.Sprintf("Add %s", path), "ynqa")
@@ -1,7 +1,17 @@
 package cmd
 
 import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5/plumbing/format/diff"
 	"github.com/spf13/cobra"
+	vfs "github.com/twpayne/go-vfs"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
 var applyCmd = &cobra.Command{
@@ -13,9 +23,19 @@ var applyCmd = &cobra.Command{
 	RunE:    config.runApplyCmd,
 }
 
+type applyCmdConfig struct {
+	interactive bool
+}
+
 func init() {
 	rootCmd.AddCommand(applyCmd)
 
+	persistentFlags := applyCmd.PersistentFlags()
+	persistentFlags.StringSliceVarP(&config.exclude, "exclude", "x", config.exclude, "exclude entry types")
+	persistentFlags.StringSliceVarP(&config.include, "include", "i", config.include, "include entry types")
+	persistentFlags.BoolVar(&config.apply.interactive, "interactive", false, "prompt before each change")
+	persistentFlags.StringVar(&config.outputFormat, "output-format", config.outputFormat, "output format, \"text\" or \"json\"")
+
 	markRemainingZshCompPositionalArgumentsAsFiles(applyCmd, 1)
 }
 
@@ -26,5 +46,89 @@ func (c *Config) runApplyCmd(cmd *cobra.Command, args []string) error {
 	}
 	defer persistentState.Close()
 
-	return c.applyArgs(args, persistentState)
+	if err := c.applyArgs(args, persistentState); err != nil {
+		return err
+	}
+	return persistentState.Close()
+}
+
+// getInteractiveMutator returns mutator wrapped so that each change is shown
+// as a diff in c.Diff.Format and confirmed before it is made.
+func (c *Config) getInteractiveMutator(mutator chezmoi.Mutator) (chezmoi.Mutator, error) {
+	preview, err := c.getPreviewMutator(c.Stdout)
+	if err != nil {
+		return nil, err
+	}
+	return chezmoi.NewInteractiveMutator(mutator, preview, c.promptInteractive, c.mergeInteractive), nil
+}
+
+// getPreviewMutator returns a Mutator that writes the changes it is asked to
+// make to w in c.Diff.Format, without making them.
+func (c *Config) getPreviewMutator(w io.Writer) (chezmoi.Mutator, error) {
+	switch c.Diff.Format {
+	case "chezmoi":
+		return chezmoi.NewVerboseMutator(w, chezmoi.NullMutator{}, c.colored, c.maxDiffDataSize), nil
+	case "git":
+		unifiedEncoder := diff.NewUnifiedEncoder(w, diff.DefaultContextLines)
+		if c.colored {
+			unifiedEncoder.SetColor(diff.NewColorConfig())
+		}
+		return chezmoi.NewGitDiffMutator(unifiedEncoder, chezmoi.NewFSMutator(vfs.NewReadOnlyFS(c.fs)), c.DestDir+string(filepath.Separator)), nil
+	default:
+		return nil, fmt.Errorf("unknown diff format: %q", c.Diff.Format)
+	}
+}
+
+// mergeInteractive runs the merge command on a copy of name, which contains
+// currData, and data, and returns the merged copy.
+func (c *Config) mergeInteractive(name string, data, currData []byte) ([]byte, error) {
+	tempDir, err := ioutil.TempDir("", "chezmoi")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	destPath := filepath.Join(tempDir, filepath.Base(name)+".destination")
+	if err := ioutil.WriteFile(destPath, currData, 0o600); err != nil {
+		return nil, err
+	}
+	targetStatePath := filepath.Join(tempDir, filepath.Base(name))
+	if err := ioutil.WriteFile(targetStatePath, data, 0o600); err != nil {
+		return nil, err
+	}
+
+	args := append(append([]string{}, c.Merge.Args...), destPath, targetStatePath)
+	if err := c.run("", c.Merge.Command, args...); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return ioutil.ReadFile(destPath)
+}
+
+// promptInteractive asks what to do with a change to name.
+func (c *Config) promptInteractive(name string, canMerge bool) (chezmoi.InteractiveChoice, error) {
+	s := fmt.Sprintf("%s: overwrite, skip, quit, all, or none", name)
+	choices := "osqan"
+	if canMerge {
+		s = fmt.Sprintf("%s: overwrite, skip, merge, quit, all, or none", name)
+		choices = "osmqan"
+	}
+	choice, err := c.prompt(s, choices)
+	if err != nil {
+		return chezmoi.InteractiveSkip, err
+	}
+	switch choice {
+	case 'o':
+		return chezmoi.InteractiveOverwrite, nil
+	case 'm':
+		return chezmoi.InteractiveMerge, nil
+	case 'q':
+		return chezmoi.InteractiveSkip, &chezmoi.AbortError{Err: errExitFailure}
+	case 'a':
+		return chezmoi.InteractiveAll, nil
+	case 'n':
+		return chezmoi.InteractiveNone, nil
+	default:
+		return chezmoi.InteractiveSkip, nil
+	}
 }
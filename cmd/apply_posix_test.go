@@ -74,6 +74,36 @@ func getApplyScriptTestCases(tempDir string) []scriptTestCase {
 				),
 			},
 		},
+		{
+			name: "before_and_after",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"run_after_a":  "#!/bin/sh\necho a >>" + filepath.Join(tempDir, "evidence") + "\n",
+					"run_b":        "#!/bin/sh\necho b >>" + filepath.Join(tempDir, "evidence") + "\n",
+					"run_before_c": "#!/bin/sh\necho c >>" + filepath.Join(tempDir, "evidence") + "\n",
+					"dir": map[string]interface{}{
+						"run_once_before_d": "#!/bin/sh\necho d >>" + filepath.Join(tempDir, "evidence") + "\n",
+					},
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath(filepath.Join(tempDir, "evidence"),
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString(strings.Join([]string{
+						"c\n",
+						"d\n",
+						"b\n",
+						"a\n",
+						"c\n",
+						"b\n",
+						"a\n",
+						"c\n",
+						"b\n",
+						"a\n",
+					}, "")),
+				),
+			},
+		},
 	}
 }
 
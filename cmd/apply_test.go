@@ -151,9 +151,19 @@ func TestApplyCommand(t *testing.T) {
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			tc.root["/home/user/.local/share/chezmoi/dir/file"] = "contents"
-			tc.root["/home/user/.local/share/chezmoi/dir/other"] = "other stuff"
-			tc.root["/home/user/.local/share/chezmoi/symlink_symlink"] = "target"
+			// Templates in the test case's source directory replace the
+			// default source files, which would otherwise be duplicates.
+			sourceDir, _ := tc.root["/home/user/.local/share/chezmoi"].(map[string]interface{})
+			for name, contents := range map[string]string{
+				"dir/file":        "contents",
+				"dir/other":       "other stuff",
+				"symlink_symlink": "target",
+			} {
+				if _, ok := sourceDir[name+".tmpl"]; ok {
+					continue
+				}
+				tc.root["/home/user/.local/share/chezmoi/"+name] = contents
+			}
 			fs, cleanup, err := vfst.NewTestFS(tc.root)
 			require.NoError(t, err)
 			defer cleanup()
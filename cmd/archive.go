@@ -26,6 +26,8 @@ func init() {
 	rootCmd.AddCommand(archiveCmd)
 
 	persistentFlags := archiveCmd.PersistentFlags()
+	persistentFlags.StringSliceVarP(&config.exclude, "exclude", "x", config.exclude, "exclude entry types")
+	persistentFlags.StringSliceVarP(&config.include, "include", "i", config.include, "include entry types")
 	persistentFlags.StringVarP(&config.archive.output, "output", "o", "", "output filename")
 	panicOnError(archiveCmd.MarkPersistentFlagFilename("output"))
 }
@@ -36,9 +38,14 @@ func (c *Config) runArchiveCmd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	filter, err := c.getEntryTypeFilter()
+	if err != nil {
+		return err
+	}
+
 	output := &strings.Builder{}
 	w := tar.NewWriter(output)
-	if err := ts.Archive(w, os.FileMode(c.Umask)); err != nil {
+	if err := ts.Archive(w, filter, os.FileMode(c.Umask)); err != nil {
 		return err
 	}
 	if err := w.Close(); err != nil {
@@ -1,10 +1,13 @@
 package cmd
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -12,6 +15,10 @@ import (
 	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
+type chattrCmdConfig struct {
+	metadata bool
+}
+
 var chattrCmd = &cobra.Command{
 	Use:      "chattr attributes targets...",
 	Args:     cobra.MinimumNArgs(2),
@@ -26,29 +33,39 @@ var chattrCmd = &cobra.Command{
 type boolModifier int
 
 type attributeModifiers struct {
+	create     boolModifier
 	empty      boolModifier
 	encrypted  boolModifier
 	exact      boolModifier
 	executable boolModifier
+	mode       boolModifier
+	perm       os.FileMode
 	private    boolModifier
+	readOnly   boolModifier
 	template   boolModifier
 }
 
 func init() {
 	rootCmd.AddCommand(chattrCmd)
 
+	persistentFlags := chattrCmd.PersistentFlags()
+	persistentFlags.BoolVar(&config.chattr.metadata, "metadata", false, "edit .chezmoiattributes files instead of renaming")
+
 	attributes := []string{
+		"create",
 		"empty", "e",
 		"encrypted",
 		"exact",
 		"executable", "x",
 		"private", "p",
+		"readonly", "r",
 		"template", "t",
 	}
-	words := make([]string, 0, 4*len(attributes))
+	words := make([]string, 0, 4*len(attributes)+3)
 	for _, attribute := range attributes {
 		words = append(words, attribute, "-"+attribute, "+"+attribute, "no"+attribute)
 	}
+	words = append(words, "mode=", "-mode", "nomode")
 	panicOnError(chattrCmd.MarkZshCompPositionalArgumentWords(1, words...))
 	markRemainingZshCompPositionalArgumentsAsFiles(chattrCmd, 2)
 }
@@ -69,51 +86,66 @@ func (c *Config) runChattrCmd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if c.chattr.metadata {
+		return c.chattrMetadata(ts, ams, entries)
+	}
+
 	updates := make(map[string]func() error)
 	for _, entry := range entries {
+		if chezmoi.IsExternal(entry) {
+			return fmt.Errorf("%s: external", filepath.Join(c.DestDir, entry.TargetName()))
+		}
+		sourceDir := ts.EntrySourceDir(entry)
 		dir, oldBase := filepath.Split(entry.SourceName())
-		oldpath := filepath.Join(ts.SourceDir, dir, oldBase)
-		switch entry := entry.(type) {
+		oldpath := filepath.Join(sourceDir, dir, oldBase)
+		// Attributes declared in .chezmoiattributes files can only be
+		// changed by editing them.
+		if name := ams.declared(ts.DeclaredAttributes(entry)); name != "" {
+			return fmt.Errorf("%s: %s: declared in %s, use --metadata", oldpath, name, chezmoi.AttributesName)
+		}
+		// Only the attributes in the source name are modified, so they are
+		// parsed from it rather than taken from entry.
+		switch entry.(type) {
 		case *chezmoi.Dir:
 			da := chezmoi.ParseDirAttributes(oldBase)
-			da.Exact = ams.exact.modify(entry.Exact)
+			oldDA := da
+			da.Exact = ams.exact.modify(oldDA.Exact)
 			perm := os.FileMode(0o777)
-			if private := ams.private.modify(entry.Private()); private {
+			if private := ams.private.modify(oldDA.Perm&0o77 == 0); private {
 				perm &= 0o700
 			}
-			da.Perm = perm
+			if readOnly := ams.readOnly.modify(oldDA.Perm&0o222 == 0); readOnly {
+				perm &^= 0o222
+			}
+			da.Perm, da.ExplicitPerm = ams.modifyPerm(perm, oldDA.Perm, oldDA.ExplicitPerm)
 			newBase := da.SourceName()
 			if newBase != oldBase {
-				newpath := filepath.Join(ts.SourceDir, dir, newBase)
+				newpath := filepath.Join(sourceDir, dir, newBase)
 				updates[oldpath] = func() error {
 					return c.mutator.Rename(oldpath, newpath)
 				}
 			}
 		case *chezmoi.File:
 			fa := chezmoi.ParseFileAttributes(oldBase)
+			oldFA := fa
 			mode := os.FileMode(0o666)
-			if executable := ams.executable.modify(entry.Executable()); executable {
+			if executable := ams.executable.modify(oldFA.Mode&0o111 != 0); executable {
 				mode |= 0o111
 			}
-			if private := ams.private.modify(entry.Private()); private {
+			if private := ams.private.modify(oldFA.Mode&0o77 == 0); private {
 				mode &= 0o700
 			}
-			fa.Mode = mode
-			fa.Encrypted = ams.encrypted.modify(entry.Encrypted)
-			fa.Empty = ams.empty.modify(entry.Empty)
-			fa.Template = ams.template.modify(entry.Template)
-			newpath := filepath.Join(ts.SourceDir, dir, fa.SourceName())
-			if fa.Encrypted != entry.Encrypted {
-				oldContents, err := c.fs.ReadFile(filepath.Join(c.SourceDir, entry.SourceName()))
-				if err != nil {
-					return err
-				}
-				var newContents []byte
-				if fa.Encrypted {
-					newContents, err = ts.GPG.Encrypt(entry.TargetName(), oldContents)
-				} else {
-					newContents, err = ts.GPG.Decrypt(entry.TargetName(), oldContents)
-				}
+			if readOnly := ams.readOnly.modify(oldFA.Mode&0o222 == 0); readOnly {
+				mode &^= 0o222
+			}
+			fa.Mode, fa.ExplicitPerm = ams.modifyPerm(mode, oldFA.Mode.Perm(), oldFA.ExplicitPerm)
+			fa.Create = ams.create.modify(oldFA.Create)
+			fa.Encrypted = ams.encrypted.modify(oldFA.Encrypted)
+			fa.Empty = ams.empty.modify(oldFA.Empty)
+			fa.Template = ams.template.modify(oldFA.Template)
+			newpath := filepath.Join(sourceDir, dir, fa.SourceName())
+			if fa.Encrypted != oldFA.Encrypted {
+				newContents, oldContents, err := c.chattrEncryption(ts, entry, fa.Encrypted)
 				if err != nil {
 					return err
 				}
@@ -132,10 +164,10 @@ func (c *Config) runChattrCmd(cmd *cobra.Command, args []string) error {
 			}
 		case *chezmoi.Symlink:
 			fa := chezmoi.ParseFileAttributes(oldBase)
-			fa.Template = ams.template.modify(entry.Template)
+			fa.Template = ams.template.modify(fa.Template)
 			newBase := fa.SourceName()
 			if newBase != oldBase {
-				newpath := filepath.Join(ts.SourceDir, dir, newBase)
+				newpath := filepath.Join(sourceDir, dir, newBase)
 				updates[oldpath] = func() error {
 					return c.mutator.Rename(oldpath, newpath)
 				}
@@ -160,6 +192,146 @@ func (c *Config) runChattrCmd(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// chattrMetadata modifies the attributes of entries by editing the
+// .chezmoiattributes files in their source directories.
+func (c *Config) chattrMetadata(ts *chezmoi.TargetState, ams *attributeModifiers, entries []chezmoi.Entry) error {
+	type attributesFile struct {
+		oldData []byte
+		newData []byte
+	}
+	attributesFiles := make(map[string]*attributesFile)
+	var attributesFilePaths []string
+	type contentsUpdate struct {
+		path        string
+		oldContents []byte
+		newContents []byte
+	}
+	var contentsUpdates []contentsUpdate
+
+	for _, entry := range entries {
+		if chezmoi.IsExternal(entry) {
+			return fmt.Errorf("%s: external", filepath.Join(c.DestDir, entry.TargetName()))
+		}
+		sourceDir := ts.EntrySourceDir(entry)
+		dir, base := filepath.Split(entry.SourceName())
+		sourcePath := filepath.Join(sourceDir, dir, base)
+		declared := &chezmoi.DeclaredAttributes{}
+		if oldDeclared := ts.DeclaredAttributes(entry); oldDeclared != nil {
+			*declared = *oldDeclared
+		}
+		var errs []error
+		switch entry := entry.(type) {
+		case *chezmoi.Dir:
+			da := chezmoi.ParseDirAttributes(base)
+			errs = []error{
+				ams.exact.modifyDeclared(&declared.Exact, da.Exact, "exact"),
+				ams.private.modifyDeclared(&declared.Private, !da.ExplicitPerm && da.Perm&0o77 == 0, "private"),
+				ams.readOnly.modifyDeclared(&declared.ReadOnly, !da.ExplicitPerm && da.Perm&0o222 == 0, "readonly"),
+				ams.modifyDeclaredPerm(declared, da.ExplicitPerm),
+			}
+			_, err := declared.ParseDirAttributes(base)
+			errs = append(errs, err)
+		case *chezmoi.File:
+			fa := chezmoi.ParseFileAttributes(base)
+			errs = []error{
+				ams.create.modifyDeclared(&declared.Create, fa.Create, "create"),
+				ams.empty.modifyDeclared(&declared.Empty, fa.Empty, "empty"),
+				ams.encrypted.modifyDeclared(&declared.Encrypted, fa.Encrypted, "encrypted"),
+				ams.executable.modifyDeclared(&declared.Executable, !fa.ExplicitPerm && fa.Mode&0o111 != 0, "executable"),
+				ams.private.modifyDeclared(&declared.Private, !fa.ExplicitPerm && fa.Mode&0o77 == 0, "private"),
+				ams.readOnly.modifyDeclared(&declared.ReadOnly, !fa.ExplicitPerm && fa.Mode&0o222 == 0, "readonly"),
+				ams.template.modifyDeclared(&declared.Template, fa.Template, "template"),
+				ams.modifyDeclaredPerm(declared, fa.ExplicitPerm),
+			}
+			newFA, err := declared.ParseFileAttributes(base)
+			errs = append(errs, err)
+			if err == nil && newFA.Encrypted != entry.Encrypted {
+				newContents, oldContents, err := c.chattrEncryption(ts, entry, newFA.Encrypted)
+				if err != nil {
+					return err
+				}
+				contentsUpdates = append(contentsUpdates, contentsUpdate{
+					path:        sourcePath,
+					oldContents: oldContents,
+					newContents: newContents,
+				})
+			}
+		case *chezmoi.Symlink:
+			fa := chezmoi.ParseFileAttributes(base)
+			errs = []error{
+				ams.template.modifyDeclared(&declared.Template, fa.Template, "template"),
+			}
+		default:
+			continue
+		}
+		for _, err := range errs {
+			if err != nil {
+				return fmt.Errorf("%s: %w", sourcePath, err)
+			}
+		}
+
+		attributesFilePath := filepath.Join(sourceDir, dir, chezmoi.AttributesName)
+		af, ok := attributesFiles[attributesFilePath]
+		if !ok {
+			data, err := c.fs.ReadFile(attributesFilePath)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			af = &attributesFile{
+				oldData: data,
+				newData: data,
+			}
+			attributesFiles[attributesFilePath] = af
+			attributesFilePaths = append(attributesFilePaths, attributesFilePath)
+		}
+		newData, err := chezmoi.SetDeclaredAttributes(af.newData, base, declared)
+		if err != nil {
+			return fmt.Errorf("%s: %w", attributesFilePath, err)
+		}
+		af.newData = newData
+	}
+
+	for _, cu := range contentsUpdates {
+		if err := c.mutator.WriteFile(cu.path, cu.newContents, 0o644, cu.oldContents); err != nil {
+			return err
+		}
+	}
+	for _, attributesFilePath := range attributesFilePaths {
+		af := attributesFiles[attributesFilePath]
+		switch {
+		case bytes.Equal(af.oldData, af.newData):
+		case len(af.newData) == 0:
+			if err := c.mutator.RemoveAll(attributesFilePath); err != nil {
+				return err
+			}
+		default:
+			if err := c.mutator.WriteFile(attributesFilePath, af.newData, 0o644, af.oldData); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// chattrEncryption returns the new and old contents of entry's source file
+// when it is encrypted or decrypted.
+func (c *Config) chattrEncryption(ts *chezmoi.TargetState, entry chezmoi.Entry, encrypt bool) ([]byte, []byte, error) {
+	oldContents, err := c.fs.ReadFile(filepath.Join(ts.EntrySourceDir(entry), entry.SourceName()))
+	if err != nil {
+		return nil, nil, err
+	}
+	var newContents []byte
+	if encrypt {
+		newContents, err = ts.GPG.Encrypt(entry.TargetName(), oldContents)
+	} else {
+		newContents, err = ts.GPG.Decrypt(entry.TargetName(), oldContents)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return newContents, oldContents, nil
+}
+
 func parseAttributeModifiers(s string) (*attributeModifiers, error) {
 	ams := &attributeModifiers{}
 	for _, attributeModifier := range strings.Split(s, ",") {
@@ -167,6 +339,15 @@ func parseAttributeModifiers(s string) (*attributeModifiers, error) {
 		if attributeModifier == "" {
 			continue
 		}
+		if strings.HasPrefix(attributeModifier, "mode=") {
+			perm, err := strconv.ParseUint(strings.TrimPrefix(attributeModifier, "mode="), 8, 32)
+			if err != nil || os.FileMode(perm) != os.FileMode(perm).Perm() {
+				return nil, fmt.Errorf("%s: invalid mode", attributeModifier)
+			}
+			ams.mode = boolModifier(1)
+			ams.perm = os.FileMode(perm)
+			continue
+		}
 		var modifier boolModifier
 		var attribute string
 		switch {
@@ -184,16 +365,25 @@ func parseAttributeModifiers(s string) (*attributeModifiers, error) {
 			attribute = attributeModifier
 		}
 		switch attribute {
+		case "create":
+			ams.create = modifier
 		case "empty", "e":
 			ams.empty = modifier
 		case "encrypted":
 			ams.encrypted = modifier
 		case "exact":
 			ams.exact = modifier
+		case "mode":
+			if modifier > 0 {
+				return nil, fmt.Errorf("%s: missing mode", attributeModifier)
+			}
+			ams.mode = modifier
 		case "executable", "x":
 			ams.executable = modifier
 		case "private", "p":
 			ams.private = modifier
+		case "readonly", "r":
+			ams.readOnly = modifier
 		case "template", "t":
 			ams.template = modifier
 		default:
@@ -203,6 +393,88 @@ func parseAttributeModifiers(s string) (*attributeModifiers, error) {
 	return ams, nil
 }
 
+// declared returns the name of the first attribute that ams modifies that is
+// declared by d, or the empty string if there is none.
+func (ams *attributeModifiers) declared(d *chezmoi.DeclaredAttributes) string {
+	if d == nil {
+		return ""
+	}
+	for _, a := range []struct {
+		name     string
+		modifier boolModifier
+		declared bool
+	}{
+		{"create", ams.create, d.Create},
+		{"empty", ams.empty, d.Empty},
+		{"encrypted", ams.encrypted, d.Encrypted},
+		{"exact", ams.exact, d.Exact},
+		{"executable", ams.executable, d.Executable || d.ExplicitPerm},
+		{"mode", ams.mode, d.ExplicitPerm},
+		{"private", ams.private, d.Private || d.ExplicitPerm},
+		{"readonly", ams.readOnly, d.ReadOnly || d.ExplicitPerm},
+		{"template", ams.template, d.Template},
+	} {
+		if a.modifier != 0 && a.declared {
+			return a.name
+		}
+	}
+	return ""
+}
+
+// modifyDeclaredPerm modifies the explicit permissions declared by d. named is
+// true if the source name has explicit permissions. As when renaming,
+// modifying the executable, private, or readonly attributes removes any
+// explicit permissions.
+func (ams *attributeModifiers) modifyDeclaredPerm(d *chezmoi.DeclaredAttributes, named bool) error {
+	switch {
+	case ams.mode > 0:
+		d.ExplicitPerm = true
+		d.Perm = ams.perm
+		d.Executable = false
+		d.Private = false
+		d.ReadOnly = false
+	case ams.mode < 0 && named:
+		return errors.New("mode: set by source name")
+	case ams.mode < 0 || ams.executable != 0 || ams.private != 0 || ams.readOnly != 0:
+		d.ExplicitPerm = false
+		d.Perm = 0
+	}
+	return nil
+}
+
+// modifyPerm returns the new permissions of an entry, and whether they are
+// explicit, given perm, the permissions computed from the executable, private,
+// and readonly attributes, and the entry's current permissions. An existing
+// explicit mode is kept unless it is removed or any of these attributes are
+// modified.
+func (ams *attributeModifiers) modifyPerm(perm, currentPerm os.FileMode, currentExplicitPerm bool) (os.FileMode, bool) {
+	switch {
+	case ams.mode > 0:
+		return ams.perm, true
+	case ams.mode < 0:
+		return perm, false
+	case currentExplicitPerm && ams.executable == 0 && ams.private == 0 && ams.readOnly == 0:
+		return currentPerm, true
+	default:
+		return perm, false
+	}
+}
+
+// modifyDeclared applies bm to the declared attribute *declared. named is true
+// if the attribute is already set by the source name, in which case it cannot
+// be removed.
+func (bm boolModifier) modifyDeclared(declared *bool, named bool, name string) error {
+	switch {
+	case bm > 0 && !named:
+		*declared = true
+	case bm < 0 && named:
+		return fmt.Errorf("%s: set by source name", name)
+	case bm < 0:
+		*declared = false
+	}
+	return nil
+}
+
 func (bm boolModifier) modify(x bool) bool {
 	switch {
 	case bm < 0:
@@ -83,6 +83,59 @@ func TestChattrCommand(t *testing.T) {
 				),
 			},
 		},
+		{
+			name: "dir_add_mode",
+			args: []string{"mode=0750", "/home/user/dir"},
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"private_dir": &vfst.Dir{Perm: 0o755},
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/private_dir",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/mode_0750_dir",
+					vfst.TestIsDir,
+				),
+			},
+		},
+		{
+			name: "file_add_create",
+			args: []string{"+create", "/home/user/foo"},
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"foo": "# contents of ~/foo\n",
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/foo",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/create_foo",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("# contents of ~/foo\n"),
+				),
+			},
+		},
+		{
+			name: "file_remove_create",
+			args: []string{"-create", "/home/user/foo"},
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"create_foo": "# contents of ~/foo\n",
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/foo",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("# contents of ~/foo\n"),
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/create_foo",
+					vfst.TestDoesNotExist,
+				),
+			},
+		},
 		{
 			name: "file_add_empty",
 			args: []string{"+empty", "/home/user/foo"},
@@ -191,6 +244,75 @@ func TestChattrCommand(t *testing.T) {
 				),
 			},
 		},
+		{
+			name: "file_add_readonly",
+			args: []string{"+readonly", "/home/user/foo"},
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"private_foo": "# contents of ~/foo\n",
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/private_foo",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/private_readonly_foo",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("# contents of ~/foo\n"),
+				),
+			},
+		},
+		{
+			name: "file_add_mode",
+			args: []string{"mode=0640", "/home/user/foo"},
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"executable_foo": "# contents of ~/foo\n",
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/executable_foo",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/mode_0640_foo",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("# contents of ~/foo\n"),
+				),
+			},
+		},
+		{
+			name: "file_keep_mode",
+			args: []string{"+template", "/home/user/foo"},
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"mode_0640_foo": "# contents of ~/foo\n",
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/mode_0640_foo.tmpl",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("# contents of ~/foo\n"),
+				),
+			},
+		},
+		{
+			name: "file_remove_mode",
+			args: []string{"-mode", "/home/user/foo"},
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"mode_0640_foo": "# contents of ~/foo\n",
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/mode_0640_foo",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/foo",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("# contents of ~/foo\n"),
+				),
+			},
+		},
 		{
 			name: "file_add_template",
 			args: []string{"+template", "/home/user/foo"},
@@ -325,6 +447,14 @@ func TestParseAttributeModifiers(t *testing.T) {
 		{s: "+p", want: &attributeModifiers{private: 1}},
 		{s: "-p", want: &attributeModifiers{private: -1}},
 		{s: "nop", want: &attributeModifiers{private: -1}},
+		{s: "readonly", want: &attributeModifiers{readOnly: 1}},
+		{s: "-r", want: &attributeModifiers{readOnly: -1}},
+		{s: "mode=0640", want: &attributeModifiers{mode: 1, perm: 0o640}},
+		{s: "-mode", want: &attributeModifiers{mode: -1}},
+		{s: "nomode", want: &attributeModifiers{mode: -1}},
+		{s: "mode", wantErr: true},
+		{s: "mode=0999", wantErr: true},
+		{s: "mode=01777", wantErr: true},
 		{s: "template", want: &attributeModifiers{template: 1}},
 		{s: "+template", want: &attributeModifiers{template: 1}},
 		{s: "-template", want: &attributeModifiers{template: -1}},
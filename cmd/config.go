@@ -18,19 +18,20 @@ import (
 	"unicode"
 
 	"github.com/Masterminds/sprig/v3"
-	"github.com/pelletier/go-toml"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	vfs "github.com/twpayne/go-vfs"
 	xdg "github.com/twpayne/go-xdg/v3"
 	bolt "go.etcd.io/bbolt"
-	yaml "gopkg.in/yaml.v2"
 
 	"github.com/twpayne/chezmoi/internal/chezmoi"
 	"github.com/twpayne/chezmoi/internal/git"
 )
 
-const commitMessageTemplateAsset = "assets/templates/COMMIT_MESSAGE.tmpl"
+const (
+	commitMessageTemplateAsset = "assets/templates/COMMIT_MESSAGE.tmpl"
+	rootName                   = ".chezmoiroot"
+)
 
 var whitespaceRegexp = regexp.MustCompile(`\s+`)
 
@@ -47,17 +48,32 @@ type templateConfig struct {
 	Options []string
 }
 
+type persistentStateConfig struct {
+	Backend string
+}
+
 // A Config represents a configuration.
 type Config struct {
 	configFile        string
 	err               error
 	fs                vfs.FS
 	mutator           chezmoi.Mutator
+	reportDrift       func(targetPath string, drift chezmoi.Drift)
+	reportScript      func(targetPath string, contents []byte) error
+	outputFormat      string
+	include           []string
+	exclude           []string
+	CacheDir          string
+	Backup            bool
+	BackupDir         string
 	SourceDir         string
+	SourceDirs        []string
 	DestDir           string
 	Umask             permValue
+	Conflict          string
 	DryRun            bool
 	Follow            bool
+	KeepGoing         bool
 	Remove            bool
 	Verbose           bool
 	Color             string
@@ -66,6 +82,7 @@ type Config struct {
 	GPGRecipient      string
 	SourceVCS         sourceVCSConfig
 	Template          templateConfig
+	PersistentState   persistentStateConfig
 	Merge             mergeConfig
 	Bitwarden         bitwardenCmdConfig
 	CD                cdCmdConfig
@@ -82,7 +99,9 @@ type Config struct {
 	maxDiffDataSize   int
 	templateFuncs     template.FuncMap
 	add               addCmdConfig
+	apply             applyCmdConfig
 	archive           archiveCmdConfig
+	chattr            chattrCmdConfig
 	completion        completionCmdConfig
 	data              dataCmdConfig
 	dump              dumpCmdConfig
@@ -94,12 +113,15 @@ type Config struct {
 	managed           managedCmdConfig
 	purge             purgeCmdConfig
 	remove            removeCmdConfig
+	state             stateCmdConfig
 	update            updateCmdConfig
 	upgrade           upgradeCmdConfig
 	Stdin             io.Reader
+	stdinReader       *bufio.Reader
 	Stdout            io.Writer
 	Stderr            io.Writer
 	bds               *xdg.BaseDirectorySpecification
+	entryStateBucket  []byte
 	scriptStateBucket []byte
 
 	//nolint:structcheck,unused
@@ -111,17 +133,9 @@ type configOption func(*Config)
 
 var (
 	formatMap = map[string]func(io.Writer, interface{}) error{
-		"json": func(w io.Writer, value interface{}) error {
-			e := json.NewEncoder(w)
-			e.SetIndent("", "  ")
-			return e.Encode(value)
-		},
-		"toml": func(w io.Writer, value interface{}) error {
-			return toml.NewEncoder(w).Encode(value)
-		},
-		"yaml": func(w io.Writer, value interface{}) error {
-			return yaml.NewEncoder(w).Encode(value)
-		},
+		"json": chezmoi.EncodeJSON,
+		"toml": chezmoi.EncodeTOML,
+		"yaml": chezmoi.EncodeYAML,
 	}
 
 	wellKnownAbbreviations = map[string]struct{}{
@@ -139,14 +153,20 @@ var (
 // newConfig creates a new Config with the given options.
 func newConfig(options ...configOption) *Config {
 	c := &Config{
-		Umask: permValue(chezmoi.GetUmask()),
-		Color: "auto",
+		Umask:        permValue(chezmoi.GetUmask()),
+		Conflict:     "prompt",
+		Color:        "auto",
+		outputFormat: "text",
+		include:      []string{"all"},
 		SourceVCS: sourceVCSConfig{
 			Command: "git",
 		},
 		Template: templateConfig{
 			Options: chezmoi.DefaultTemplateOptions,
 		},
+		PersistentState: persistentStateConfig{
+			Backend: "bolt",
+		},
 		Diff: diffCmdConfig{
 			Format: "chezmoi",
 		},
@@ -158,6 +178,7 @@ func newConfig(options ...configOption) *Config {
 		},
 		maxDiffDataSize:   1 * 1024 * 1024, // 1MB
 		templateFuncs:     sprig.TxtFuncMap(),
+		entryStateBucket:  []byte("entryState"),
 		scriptStateBucket: []byte("script"),
 		Stdin:             os.Stdin,
 		Stdout:            os.Stdout,
@@ -185,30 +206,70 @@ func (c *Config) applyArgs(args []string, persistentState chezmoi.PersistentStat
 	if err != nil {
 		return err
 	}
+	conflict, err := c.getConflictFunc()
+	if err != nil {
+		return err
+	}
+	filter, err := c.getEntryTypeFilter()
+	if err != nil {
+		return err
+	}
 	applyOptions := &chezmoi.ApplyOptions{
+		Conflict:          conflict,
 		DestDir:           ts.DestDir,
 		DryRun:            c.DryRun,
+		EntryStateBucket:  c.entryStateBucket,
+		Filter:            filter,
 		Ignore:            ts.TargetIgnore.Match,
+		KeepGoing:         c.KeepGoing,
 		PersistentState:   persistentState,
 		Remove:            c.Remove,
+		ReportDrift:       c.reportDrift,
+		ReportScript:      c.reportScript,
 		ScriptStateBucket: c.scriptStateBucket,
 		Stdout:            c.Stdout,
 		Umask:             ts.Umask,
 		Verbose:           c.Verbose,
 	}
+	mutator := c.mutator
+	switch c.outputFormat {
+	case "text":
+	case "json":
+		encoder := json.NewEncoder(c.Stdout)
+		reportingMutator := chezmoi.NewReportingMutator(mutator, fs, func(report *chezmoi.Report) error {
+			return encoder.Encode(report)
+		})
+		if c.DryRun {
+			// Scripts are not run in dry runs, so report them when they would
+			// be run instead.
+			reportScript := applyOptions.ReportScript
+			applyOptions.ReportScript = func(targetPath string, contents []byte) error {
+				if reportScript != nil {
+					if err := reportScript(targetPath, contents); err != nil {
+						return err
+					}
+				}
+				return reportingMutator.ReportScript(targetPath, contents)
+			}
+		}
+		mutator = reportingMutator
+	default:
+		return fmt.Errorf("%s: unknown output format", c.outputFormat)
+	}
+	mutator = c.getBackupMutator(mutator, ts.DestDir)
+	if c.apply.interactive && !c.DryRun {
+		if mutator, err = c.getInteractiveMutator(mutator); err != nil {
+			return err
+		}
+	}
 	if len(args) == 0 {
-		return ts.Apply(fs, c.mutator, c.Follow, applyOptions)
+		return ts.Apply(fs, mutator, c.Follow, applyOptions)
 	}
 	entries, err := c.getEntries(ts, args)
 	if err != nil {
 		return err
 	}
-	for _, entry := range entries {
-		if err := entry.Apply(fs, c.mutator, c.Follow, applyOptions); err != nil {
-			return err
-		}
-	}
-	return nil
+	return ts.ApplyEntries(fs, mutator, c.Follow, applyOptions, entries)
 }
 
 func (c *Config) autoCommit(vcs VCS) error {
@@ -283,6 +344,63 @@ func (c *Config) ensureNoError(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// getSourceDirs returns the source directories, in order of increasing
+// precedence. c.SourceDir is always included, and is the last source directory
+// if it is not already in c.SourceDirs.
+func (c *Config) getSourceDirs() []string {
+	sourceDirs := make([]string, 0, len(c.SourceDirs)+1)
+	found := false
+	for _, sourceDir := range c.SourceDirs {
+		sourceDir = filepath.Clean(sourceDir)
+		if sourceDir == filepath.Clean(c.SourceDir) {
+			found = true
+		}
+		sourceDirs = append(sourceDirs, sourceDir)
+	}
+	if !found {
+		sourceDirs = append(sourceDirs, c.SourceDir)
+	}
+	return sourceDirs
+}
+
+// getSourceRootDir returns the root of the source state in c.SourceDir.
+func (c *Config) getSourceRootDir() (string, error) {
+	return c.getRootDir(c.SourceDir)
+}
+
+// getSourceRootDirs returns the roots of the source states in all source
+// directories, in order of increasing precedence.
+func (c *Config) getSourceRootDirs() ([]string, error) {
+	sourceDirs := c.getSourceDirs()
+	sourceRootDirs := make([]string, 0, len(sourceDirs))
+	for _, sourceDir := range sourceDirs {
+		sourceRootDir, err := c.getRootDir(sourceDir)
+		if err != nil {
+			return nil, err
+		}
+		sourceRootDirs = append(sourceRootDirs, sourceRootDir)
+	}
+	return sourceRootDirs, nil
+}
+
+// getRootDir returns the root of the source state in sourceDir, which is the
+// subdirectory of sourceDir named in sourceDir's .chezmoiroot file, if it
+// exists, or sourceDir otherwise.
+func (c *Config) getRootDir(sourceDir string) (string, error) {
+	data, err := c.fs.ReadFile(filepath.Join(sourceDir, rootName))
+	switch {
+	case os.IsNotExist(err):
+		return sourceDir, nil
+	case err != nil:
+		return "", err
+	}
+	root := filepath.Clean(filepath.FromSlash(strings.TrimSpace(string(data))))
+	if filepath.IsAbs(root) || root == ".." || strings.HasPrefix(root, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s: %s: outside source directory", filepath.Join(sourceDir, rootName), root)
+	}
+	return filepath.Join(sourceDir, root), nil
+}
+
 func (c *Config) ensureSourceDirectory() error {
 	info, err := c.fs.Stat(c.SourceDir)
 	switch {
@@ -324,10 +442,15 @@ func (c *Config) getData() (map[string]interface{}, error) {
 }
 
 func (c *Config) getDefaultData() (map[string]interface{}, error) {
+	sourceRootDir, err := c.getSourceRootDir()
+	if err != nil {
+		return nil, err
+	}
+
 	data := map[string]interface{}{
 		"arch":      runtime.GOARCH,
 		"os":        runtime.GOOS,
-		"sourceDir": c.SourceDir,
+		"sourceDir": sourceRootDir,
 	}
 
 	currentUser, err := user.Current()
@@ -396,6 +519,72 @@ func (c *Config) getEditor() (string, []string) {
 	return components[0], components[1:]
 }
 
+// getBackupMutator returns mutator wrapped so that targets in destDir are saved
+// in a new backup directory before they are replaced or removed, if backups are
+// enabled.
+func (c *Config) getBackupMutator(mutator chezmoi.Mutator, destDir string) chezmoi.Mutator {
+	if !c.Backup || c.DryRun {
+		return mutator
+	}
+	backupDir := filepath.Join(c.BackupDir, time.Now().UTC().Format(chezmoi.BackupTimeFormat))
+	return chezmoi.NewBackupMutator(mutator, c.fs, destDir, backupDir)
+}
+
+// getConflictFunc returns a function that resolves conflicts between local
+// changes and changes in the source state according to c.Conflict. Conflicts
+// are not resolved in dry runs, which never change the destination directory,
+// and are not prompted for separately in interactive mode, which prompts for
+// every change.
+func (c *Config) getConflictFunc() (func(string) (bool, error), error) {
+	if c.DryRun || c.apply.interactive && c.Conflict == "prompt" {
+		return nil, nil
+	}
+	switch c.Conflict {
+	case "fail":
+		return func(targetPath string) (bool, error) {
+			return false, fmt.Errorf("%s: changed locally and in source state", targetPath)
+		}, nil
+	case "overwrite":
+		return nil, nil
+	case "prompt":
+		overwriteAll := false
+		return func(targetPath string) (bool, error) {
+			if overwriteAll {
+				return true, nil
+			}
+			choice, err := c.prompt(fmt.Sprintf("%s has changed locally and in the source state, overwrite", targetPath), "ynqa")
+			switch {
+			case err == io.EOF:
+				// There is no input to answer the prompt, for example when
+				// chezmoi is run non-interactively, so fail like the fail
+				// policy.
+				return false, fmt.Errorf("%s: changed locally and in source state (set --conflict to resolve conflicts without prompting)", targetPath)
+			case err != nil:
+				return false, err
+			}
+			switch choice {
+			case 'y':
+				return true, nil
+			case 'n':
+				return false, nil
+			case 'q':
+				return false, &chezmoi.AbortError{Err: errExitFailure}
+			case 'a':
+				overwriteAll = true
+				return true, nil
+			}
+			return false, nil
+		}, nil
+	case "skip":
+		return func(targetPath string) (bool, error) {
+			fmt.Fprintf(c.Stderr, "warning: %s: changed locally and in source state, skipping\n", targetPath)
+			return false, nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("%s: unknown conflict policy", c.Conflict)
+	}
+}
+
 func (c *Config) getEntries(ts *chezmoi.TargetState, args []string) ([]chezmoi.Entry, error) {
 	entries := []chezmoi.Entry{}
 	for _, arg := range args {
@@ -415,35 +604,88 @@ func (c *Config) getEntries(ts *chezmoi.TargetState, args []string) ([]chezmoi.E
 	return entries, nil
 }
 
+// getEntryTypeFilter returns the filter for the entry types given by the
+// --include and --exclude flags.
+func (c *Config) getEntryTypeFilter() (*chezmoi.EntryTypeFilter, error) {
+	include, err := chezmoi.ParseEntryTypeSet(c.include)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := chezmoi.ParseEntryTypeSet(c.exclude)
+	if err != nil {
+		return nil, err
+	}
+	return &chezmoi.EntryTypeFilter{
+		Include: include,
+		Exclude: exclude,
+	}, nil
+}
+
+// getPersistentState returns the persistent state using the configured
+// backend. options are only used by the bolt backend. In dry runs, the
+// persistent state is copied into memory so that it is never changed.
 func (c *Config) getPersistentState(options *bolt.Options) (chezmoi.PersistentState, error) {
-	persistentStateFile := c.getPersistentStateFile()
 	if options == nil {
 		options = &bolt.Options{}
 	}
-	if options.Timeout == 0 {
-		options.Timeout = 2 * time.Second
-	}
 	if c.DryRun {
 		options.ReadOnly = true
 	}
-	state, err := chezmoi.NewBoltPersistentState(c.fs, persistentStateFile, options)
-	if errors.Is(err, bolt.ErrTimeout) {
-		return nil, fmt.Errorf("failed to lock database: %w", err)
+	var state chezmoi.PersistentState
+	switch c.PersistentState.Backend {
+	case "bolt":
+		if options.Timeout == 0 {
+			options.Timeout = 2 * time.Second
+		}
+		boltState, err := chezmoi.NewBoltPersistentState(c.fs, c.getPersistentStateFile(), options)
+		switch {
+		case errors.Is(err, bolt.ErrTimeout):
+			return nil, fmt.Errorf("failed to lock database: %w", err)
+		case err != nil:
+			return nil, err
+		}
+		state = boltState
+	case "json":
+		jsonFileState, err := chezmoi.NewJSONFilePersistentState(c.fs, c.getPersistentStateFile(), options.ReadOnly)
+		if err != nil {
+			return nil, err
+		}
+		state = jsonFileState
+	case "memory":
+		return chezmoi.NewMemoryPersistentState(), nil
+	default:
+		return nil, fmt.Errorf("%s: unknown persistent state backend", c.PersistentState.Backend)
+	}
+	if !c.DryRun {
+		return state, nil
 	}
-	return state, err
+	defer state.Close()
+	stateData, err := chezmoi.PersistentStateData(state)
+	if err != nil {
+		return nil, err
+	}
+	memoryState := chezmoi.NewMemoryPersistentState()
+	if err := chezmoi.SetPersistentStateData(memoryState, stateData); err != nil {
+		return nil, err
+	}
+	return memoryState, nil
 }
 
 func (c *Config) getPersistentStateFile() string {
+	name := "chezmoistate.boltdb"
+	if c.PersistentState.Backend == "json" {
+		name = "chezmoistate.json"
+	}
 	if c.configFile != "" {
-		return filepath.Join(filepath.Dir(c.configFile), "chezmoistate.boltdb")
+		return filepath.Join(filepath.Dir(c.configFile), name)
 	}
 	for _, configDir := range c.bds.ConfigDirs {
-		persistentStateFile := filepath.Join(configDir, "chezmoi", "chezmoistate.boltdb")
+		persistentStateFile := filepath.Join(configDir, "chezmoi", name)
 		if _, err := os.Stat(persistentStateFile); err == nil {
 			return persistentStateFile
 		}
 	}
-	return filepath.Join(filepath.Dir(getDefaultConfigFile(c.bds)), "chezmoistate.boltdb")
+	return filepath.Join(filepath.Dir(getDefaultConfigFile(c.bds)), name)
 }
 
 func (c *Config) getTargetState(populateOptions *chezmoi.PopulateOptions) (*chezmoi.TargetState, error) {
@@ -467,10 +709,22 @@ func (c *Config) getTargetState(populateOptions *chezmoi.PopulateOptions) (*chez
 		c.GPG.Recipient = c.GPGRecipient
 	}
 
+	sourceRootDir, err := c.getSourceRootDir()
+	if err != nil {
+		return nil, err
+	}
+	sourceRootDirs, err := c.getSourceRootDirs()
+	if err != nil {
+		return nil, err
+	}
+
 	ts := chezmoi.NewTargetState(
+		chezmoi.WithCache(c.fs, c.CacheDir),
 		chezmoi.WithDestDir(destDir),
+		chezmoi.WithGitCommand(c.getGitCommand()),
 		chezmoi.WithGPG(&c.GPG),
-		chezmoi.WithSourceDir(c.SourceDir),
+		chezmoi.WithSourceDir(sourceRootDir),
+		chezmoi.WithSourceDirs(sourceRootDirs),
 		chezmoi.WithTemplateData(data),
 		chezmoi.WithTemplateFuncs(c.templateFuncs),
 		chezmoi.WithTemplateOptions(c.Template.Options),
@@ -507,7 +761,12 @@ func (c *Config) output(dir, name string, argv ...string) ([]byte, error) {
 
 //nolint:unparam
 func (c *Config) prompt(s, choices string) (byte, error) {
-	r := bufio.NewReader(c.Stdin)
+	// Reuse the same reader for every prompt so that input buffered by one
+	// prompt is available to the next.
+	if c.stdinReader == nil {
+		c.stdinReader = bufio.NewReader(c.Stdin)
+	}
+	r := c.stdinReader
 	for {
 		_, err := fmt.Printf("%s [%s]? ", s, strings.Join(strings.Split(choices, ""), ","))
 		if err != nil {
@@ -557,6 +816,14 @@ func getAsset(name string) ([]byte, error) {
 	return asset, nil
 }
 
+func getDefaultBackupDir(bds *xdg.BaseDirectorySpecification) string {
+	return filepath.Join(bds.DataHome, "chezmoi-backups")
+}
+
+func getDefaultCacheDir(bds *xdg.BaseDirectorySpecification) string {
+	return filepath.Join(bds.CacheHome, "chezmoi")
+}
+
 func getDefaultConfigFile(bds *xdg.BaseDirectorySpecification) string {
 	// Search XDG Base Directory Specification config directories first.
 	for _, configDir := range bds.ConfigDirs {
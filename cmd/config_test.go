@@ -219,6 +219,7 @@ func withTestFS(fs vfs.FS) configOption {
 func withTestUser(username string) configOption {
 	return func(c *Config) {
 		homeDir := filepath.Join("/", "home", username)
+		c.CacheDir = filepath.Join(homeDir, ".cache", "chezmoi")
 		c.SourceDir = filepath.Join(homeDir, ".local", "share", "chezmoi")
 		c.DestDir = homeDir
 		c.Umask = 0o22
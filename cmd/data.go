@@ -5,6 +5,9 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	vfs "github.com/twpayne/go-vfs"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
 type dataCmdConfig struct {
@@ -37,5 +40,16 @@ func (c *Config) runDataCmd(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	return format(c.Stdout, data)
+	sourceRootDirs, err := c.getSourceRootDirs()
+	if err != nil {
+		return err
+	}
+	ts := chezmoi.NewTargetState(
+		chezmoi.WithSourceDirs(sourceRootDirs),
+		chezmoi.WithTemplateData(data),
+	)
+	if err := ts.PopulateTemplateData(vfs.NewReadOnlyFS(c.fs)); err != nil {
+		return err
+	}
+	return format(c.Stdout, ts.TemplateData)
 }
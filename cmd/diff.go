@@ -36,8 +36,11 @@ func init() {
 	rootCmd.AddCommand(diffCmd)
 
 	persistentFlags := diffCmd.PersistentFlags()
+	persistentFlags.StringSliceVarP(&config.exclude, "exclude", "x", config.exclude, "exclude entry types")
 	persistentFlags.StringVarP(&config.Diff.Format, "format", "f", config.Diff.Format, "format, \"chezmoi\" or \"git\"")
+	persistentFlags.StringSliceVarP(&config.include, "include", "i", config.include, "include entry types")
 	persistentFlags.BoolVar(&config.Diff.NoPager, "no-pager", false, "disable pager")
+	persistentFlags.StringVar(&config.outputFormat, "output-format", config.outputFormat, "output format, \"text\" or \"json\"")
 
 	markRemainingZshCompPositionalArgumentsAsFiles(diffCmd, 1)
 }
@@ -65,6 +68,15 @@ func (c *Config) runDiffCmd(cmd *cobra.Command, args []string) error {
 	}
 	defer persistentState.Close()
 
+	// In JSON output format, the changes are reported by applyArgs.
+	if c.outputFormat == "json" {
+		c.mutator = chezmoi.NullMutator{}
+		if c.Debug {
+			c.mutator = chezmoi.NewDebugMutator(c.mutator)
+		}
+		return c.applyArgs(args, persistentState)
+	}
+
 	if c.Diff.NoPager || c.Diff.Pager == "" {
 		switch c.Diff.Format {
 		case "chezmoi":
@@ -74,7 +86,7 @@ func (c *Config) runDiffCmd(cmd *cobra.Command, args []string) error {
 			if c.colored {
 				unifiedEncoder.SetColor(diff.NewColorConfig())
 			}
-			c.mutator = chezmoi.NewGitDiffMutator(unifiedEncoder, c.mutator, c.DestDir+string(filepath.Separator))
+			c.mutator = c.getGitDiffMutator(unifiedEncoder)
 		}
 		return c.applyArgs(args, persistentState)
 	}
@@ -110,7 +122,7 @@ func (c *Config) runDiffCmd(cmd *cobra.Command, args []string) error {
 		if c.colored {
 			unifiedEncoder.SetColor(diff.NewColorConfig())
 		}
-		c.mutator = chezmoi.NewGitDiffMutator(unifiedEncoder, c.mutator, c.DestDir+string(filepath.Separator))
+		c.mutator = c.getGitDiffMutator(unifiedEncoder)
 	}
 
 	if err := c.applyArgs(args, persistentState); err != nil {
@@ -123,3 +135,12 @@ func (c *Config) runDiffCmd(cmd *cobra.Command, args []string) error {
 
 	return pagerCmd.Wait()
 }
+
+// getGitDiffMutator returns c.mutator wrapped so that changes are written to
+// unifiedEncoder as a git diff. Scripts are not run by diff, so the scripts that
+// would be run are written to the diff too.
+func (c *Config) getGitDiffMutator(unifiedEncoder *diff.UnifiedEncoder) chezmoi.Mutator {
+	gitDiffMutator := chezmoi.NewGitDiffMutator(unifiedEncoder, c.mutator, c.DestDir+string(filepath.Separator))
+	c.reportScript = gitDiffMutator.ReportScript
+	return gitDiffMutator
+}
@@ -1379,6 +1379,15 @@ func init() {
 		"executed in alphabetical order. Scripts that should only be run when their\n" +
 		"contents change have the prefix `run_once_`.\n" +
 		"\n" +
+		"By default, scripts are run while chezmoi updates the destination directory, in\n" +
+		"the same order as the other entries. Scripts with the prefix `run_before_` are\n" +
+		"run before any files, directories, or symlinks are updated, and scripts with\n" +
+		"the prefix `run_after_` are run after all of them have been updated. This is\n" +
+		"useful for, for example, installing packages before their configuration files\n" +
+		"are written, or reloading a service after its configuration has been written.\n" +
+		"The `before_` and `after_` prefixes can be combined with `once_`, for example\n" +
+		"`run_once_before_install-packages.sh`.\n" +
+		"\n" +
 		"Scripts break chezmoi's declarative approach, and as such should be used\n" +
 		"sparingly. Any script should be idempotent, even `run_once_` scripts.\n" +
 		"\n" +
@@ -1392,6 +1401,12 @@ func init() {
 		"only whitespace or an empty string, then the script is not executed. This is\n" +
 		"useful for disabling scripts.\n" +
 		"\n" +
+		"Scripts that contain secrets can be encrypted with gpg, in the same way as\n" +
+		"files, by including `encrypted_` in their name after any `once_`, `before_`, or\n" +
+		"`after_` prefix, for example `run_once_encrypted_install-license.sh`. chezmoi\n" +
+		"decrypts the script before executing it as a template, and `chezmoi edit`\n" +
+		"transparently decrypts and re-encrypts it.\n" +
+		"\n" +
 		"### Install packages with scripts\n" +
 		"\n" +
 		"Change to the source directory and create a file called\n" +
@@ -1855,6 +1870,8 @@ func init() {
 		"<!--- toc --->\n" +
 		"* [Concepts](#concepts)\n" +
 		"* [Global command line flags](#global-command-line-flags)\n" +
+		"  * [`--backup`](#--backup)\n" +
+		"  * [`--backup-dir` *directory*](#--backup-dir-directory)\n" +
 		"  * [`--color` *value*](#--color-value)\n" +
 		"  * [`-c`, `--config` *filename*](#-c---config-filename)\n" +
 		"  * [`--debug`](#--debug)\n" +
@@ -1862,6 +1879,7 @@ func init() {
 		"  * [`--follow`](#--follow)\n" +
 		"  * [`-n`, `--dry-run`](#-n---dry-run)\n" +
 		"  * [`-h`, `--help`](#-h---help)\n" +
+		"  * [`-k`, `--keep-going`](#-k---keep-going)\n" +
 		"  * [`-r`. `--remove`](#-r---remove)\n" +
 		"  * [`-S`, `--source` *directory*](#-s---source-directory)\n" +
 		"  * [`-v`, `--verbose`](#-v---verbose)\n" +
@@ -1869,11 +1887,18 @@ func init() {
 		"* [Configuration file](#configuration-file)\n" +
 		"  * [Variables](#variables)\n" +
 		"  * [Examples](#examples)\n" +
+		"  * [Layered source directories](#layered-source-directories)\n" +
+		"  * [Persistent state](#persistent-state)\n" +
 		"* [Source state attributes](#source-state-attributes)\n" +
 		"* [Special files and directories](#special-files-and-directories)\n" +
 		"  * [`.chezmoi.<format>.tmpl`](#chezmoiformattmpl)\n" +
+		"  * [`.chezmoiattributes`](#chezmoiattributes)\n" +
+		"  * [`.chezmoidata.<format>`](#chezmoidataformat)\n" +
+		"  * [`.chezmoiexternal.<format>`](#chezmoiexternalformat)\n" +
 		"  * [`.chezmoiignore`](#chezmoiignore)\n" +
+		"  * [`.chezmoimap`](#chezmoimap)\n" +
 		"  * [`.chezmoiremove`](#chezmoiremove)\n" +
+		"  * [`.chezmoiroot`](#chezmoiroot)\n" +
 		"  * [`.chezmoitemplates`](#chezmoitemplates)\n" +
 		"  * [`.chezmoiversion`](#chezmoiversion)\n" +
 		"* [Commands](#commands)\n" +
@@ -1903,10 +1928,13 @@ func init() {
 		"  * [`merge` *targets*](#merge-targets)\n" +
 		"  * [`purge`](#purge)\n" +
 		"  * [`remove` *targets*](#remove-targets)\n" +
+		"  * [`restore` [*backup* [*targets*]]](#restore-backup-targets)\n" +
 		"  * [`rm` *targets*](#rm-targets)\n" +
 		"  * [`secret`](#secret)\n" +
 		"  * [`source` [*args*]](#source-args)\n" +
 		"  * [`source-path` [*targets*]](#source-path-targets)\n" +
+		"  * [`state` *subcommand*](#state-subcommand)\n" +
+		"  * [`status` [*targets*]](#status-targets)\n" +
 		"  * [`unmanage` *targets*](#unmanage-targets)\n" +
 		"  * [`unmanaged`](#unmanaged)\n" +
 		"  * [`update`](#update)\n" +
@@ -1969,6 +1997,23 @@ func init() {
 		"\n" +
 		"Command line flags override any values set in the configuration file.\n" +
 		"\n" +
+		"### `--backup`\n" +
+		"\n" +
+		"Before `apply` or `restore` changes or removes a target, save a copy of its\n" +
+		"contents and permissions in a new backup in the backup directory. Each run\n" +
+		"creates a backup named after the time that it started, for example\n" +
+		"`20201017T143000.000Z`. Backups can be restored with the `restore` command.\n" +
+		"\n" +
+		"### `--backup-dir` *directory*\n" +
+		"\n" +
+		"Use *directory* as the backup directory. The default is `chezmoi-backups` in\n" +
+		"the XDG data directory, normally `~/.local/share/chezmoi-backups`.\n" +
+		"\n" +
+		"### `--cache` *directory*\n" +
+		"\n" +
+		"Use *directory* as the cache directory. The default is `chezmoi` in the XDG\n" +
+		"cache directory, normally `~/.cache/chezmoi`.\n" +
+		"\n" +
 		"### `--color` *value*\n" +
 		"\n" +
 		"Colorize diffs, *value* can be `on`, `off`, `auto`, or any boolean-like value\n" +
@@ -1977,6 +2022,15 @@ func init() {
 		"value is `auto` which will colorize diffs only if the the environment variable\n" +
 		"`NO_COLOR` is not set and stdout is a terminal.\n" +
 		"\n" +
+		"### `--conflict` *policy*\n" +
+		"\n" +
+		"Set what `apply` does when a target has been changed both locally and in the\n" +
+		"source state since chezmoi last wrote it. *policy* can be `prompt` (ask whether\n" +
+		"to overwrite the target), `skip` (leave the target unchanged and print a\n" +
+		"warning), `fail` (stop with an error), or `overwrite`. The default is `prompt`.\n" +
+		"If there is no input to answer the prompt, for example when chezmoi is run\n" +
+		"non-interactively, then `prompt` fails like `fail`.\n" +
+		"\n" +
 		"### `-c`, `--config` *filename*\n" +
 		"\n" +
 		"Read the configuration from *filename*.\n" +
@@ -2004,13 +2058,22 @@ func init() {
 		"\n" +
 		"Print help.\n" +
 		"\n" +
+		"### `-k`, `--keep-going`\n" +
+		"\n" +
+		"Keep going as far as possible after an error. Targets that cannot be applied,\n" +
+		"for example because their templates fail or they cannot be decrypted, are\n" +
+		"skipped, and the remaining targets are applied. At the end, all the targets that\n" +
+		"failed are printed with their errors, and chezmoi exits with code 1.\n" +
+		"\n" +
 		"### `-r`. `--remove`\n" +
 		"\n" +
 		"Also remove targets according to `.chezmoiremove`.\n" +
 		"\n" +
 		"### `-S`, `--source` *directory*\n" +
 		"\n" +
-		"Use *directory* as the source directory.\n" +
+		"Use *directory* as the source directory. When `sourceDirs` is set, this selects\n" +
+		"the layer that `add`, `edit`, and other commands that modify the source state\n" +
+		"write to.\n" +
 		"\n" +
 		"### `-v`, `--verbose`\n" +
 		"\n" +
@@ -2038,41 +2101,48 @@ func init() {
 		"\n" +
 		"The following configuration variables are available:\n" +
 		"\n" +
-		"| Section         | Variable     | Type     | Default value             | Description                                         |\n" +
-		"| --------------- | ------------ | -------- | ------------------------- | --------------------------------------------------- |\n" +
-		"| Top level       | `color`      | string   | `auto`                    | Colorize diffs                                      |\n" +
-		"|                 | `data`       | any      | *none*                    | Template data                                       |\n" +
-		"|                 | `destDir`    | string   | `~`                       | Destination directory                               |\n" +
-		"|                 | `dryRun`     | bool     | `false`                   | Dry run mode                                        |\n" +
-		"|                 | `follow`     | bool     | `false`                   | Follow symlinks                                     |\n" +
-		"|                 | `remove`     | bool     | `false`                   | Remove targets                                      |\n" +
-		"|                 | `sourceDir`  | string   | `~/.local/share/chezmoi`  | Source directory                                    |\n" +
-		"|                 | `umask`      | int      | *from system*             | Umask                                               |\n" +
-		"|                 | `verbose`    | bool     | `false`                   | Verbose mode                                        |\n" +
-		"| `bitwarden`     | `command`    | string   | `bw`                      | Bitwarden CLI command                               |\n" +
-		"| `cd`            | `args`       | []string | *none*                    | Extra args to shell in `cd` command                 |\n" +
-		"|                 | `command`    | string   | *none*                    | Shell to run in `cd` command                        |\n" +
-		"| `diff`          | `format`     | string   | `chezmoi`                 | Diff format, either `chezmoi` or `git`              |\n" +
-		"|                 | `pager`      | string   | *none*                    | Pager                                               |\n" +
-		"| `genericSecret` | `command`    | string   | *none*                    | Generic secret command                              |\n" +
-		"| `gopass`        | `command`    | string   | `gopass`                  | gopass CLI command                                  |\n" +
-		"| `gpg`           | `command`    | string   | `gpg`                     | GPG CLI command                                     |\n" +
-		"|                 | `recipient`  | string   | *none*                    | GPG recipient                                       |\n" +
-		"|                 | `symmetric`  | bool     | `false`                   | Use symmetric GPG encryption                        |\n" +
-		"| `keepassxc`     | `args`       | []string | *none*                    | Extra args to KeePassXC CLI command                 |\n" +
-		"|                 | `command`    | string   | `keepassxc-cli`           | KeePassXC CLI command                               |\n" +
-		"|                 | `database`   | string   | *none*                    | KeePassXC database                                  |\n" +
-		"| `lastpass`      | `command`    | string   | `lpass`                   | Lastpass CLI command                                |\n" +
-		"| `merge`         | `args`       | []string | *none*                    | Extra args to 3-way merge command                   |\n" +
-		"|                 | `command`    | string   | `vimdiff`                 | 3-way merge command                                 |\n" +
-		"| `onepassword`   | `cache`      | bool     | `true`                    | Enable optional caching provided by `op`            |\n" +
-		"|                 | `command`    | string   | `op`                      | 1Password CLI command                               |\n" +
-		"| `pass`          | `command`    | string   | `pass`                    | Pass CLI command                                    |\n" +
-		"| `sourceVCS`     | `autoCommit` | bool     | `false`                   | Commit changes to the source state after any change |\n" +
-		"|                 | `autoPush`   | bool     | `false`                   | Push changes to the source state after any change   |\n" +
-		"|                 | `command`    | string   | `git`                     | Source version control system                       |\n" +
-		"| `template`      | `options`    | []string | `[\"missingkey=error\"]`    | Template options                                    |\n" +
-		"| `vault`         | `command`    | string   | `vault`                   | Vault CLI command                                   |\n" +
+		"| Section           | Variable     | Type     | Default value                    | Description                                         |\n" +
+		"| ----------------- | ------------ | -------- | -------------------------------- | --------------------------------------------------- |\n" +
+		"| Top level         | `backup`     | bool     | `false`                          | Back up targets before changing them                |\n" +
+		"|                   | `backupDir`  | string   | `~/.local/share/chezmoi-backups` | Backup directory                                    |\n" +
+		"|                   | `cacheDir`   | string   | `~/.cache/chezmoi`               | Cache directory                                     |\n" +
+		"|                   | `color`      | string   | `auto`                           | Colorize diffs                                      |\n" +
+		"|                   | `conflict`   | string   | `prompt`                         | Conflict policy                                     |\n" +
+		"|                   | `data`       | any      | *none*                           | Template data                                       |\n" +
+		"|                   | `destDir`    | string   | `~`                              | Destination directory                               |\n" +
+		"|                   | `dryRun`     | bool     | `false`                          | Dry run mode                                        |\n" +
+		"|                   | `follow`     | bool     | `false`                          | Follow symlinks                                     |\n" +
+		"|                   | `keepGoing`  | bool     | `false`                          | Keep going after errors                             |\n" +
+		"|                   | `remove`     | bool     | `false`                          | Remove targets                                      |\n" +
+		"|                   | `sourceDir`  | string   | `~/.local/share/chezmoi`         | Source directory                                    |\n" +
+		"|                   | `sourceDirs` | []string | *none*                           | Layered source directories                          |\n" +
+		"|                   | `umask`      | int      | *from system*                    | Umask                                               |\n" +
+		"|                   | `verbose`    | bool     | `false`                          | Verbose mode                                        |\n" +
+		"| `bitwarden`       | `command`    | string   | `bw`                             | Bitwarden CLI command                               |\n" +
+		"| `cd`              | `args`       | []string | *none*                           | Extra args to shell in `cd` command                 |\n" +
+		"|                   | `command`    | string   | *none*                           | Shell to run in `cd` command                        |\n" +
+		"| `diff`            | `format`     | string   | `chezmoi`                        | Diff format, either `chezmoi` or `git`              |\n" +
+		"|                   | `pager`      | string   | *none*                           | Pager                                               |\n" +
+		"| `genericSecret`   | `command`    | string   | *none*                           | Generic secret command                              |\n" +
+		"| `gopass`          | `command`    | string   | `gopass`                         | gopass CLI command                                  |\n" +
+		"| `gpg`             | `command`    | string   | `gpg`                            | GPG CLI command                                     |\n" +
+		"|                   | `recipient`  | string   | *none*                           | GPG recipient                                       |\n" +
+		"|                   | `symmetric`  | bool     | `false`                          | Use symmetric GPG encryption                        |\n" +
+		"| `keepassxc`       | `args`       | []string | *none*                           | Extra args to KeePassXC CLI command                 |\n" +
+		"|                   | `command`    | string   | `keepassxc-cli`                  | KeePassXC CLI command                               |\n" +
+		"|                   | `database`   | string   | *none*                           | KeePassXC database                                  |\n" +
+		"| `lastpass`        | `command`    | string   | `lpass`                          | Lastpass CLI command                                |\n" +
+		"| `merge`           | `args`       | []string | *none*                           | Extra args to 3-way merge command                   |\n" +
+		"|                   | `command`    | string   | `vimdiff`                        | 3-way merge command                                 |\n" +
+		"| `onepassword`     | `cache`      | bool     | `true`                           | Enable optional caching provided by `op`            |\n" +
+		"|                   | `command`    | string   | `op`                             | 1Password CLI command                               |\n" +
+		"| `pass`            | `command`    | string   | `pass`                           | Pass CLI command                                    |\n" +
+		"| `persistentState` | `backend`    | string   | `bolt`                           | Persistent state backend                            |\n" +
+		"| `sourceVCS`       | `autoCommit` | bool     | `false`                          | Commit changes to the source state after any change |\n" +
+		"|                   | `autoPush`   | bool     | `false`                          | Push changes to the source state after any change   |\n" +
+		"|                   | `command`    | string   | `git`                            | Source version control system                       |\n" +
+		"| `template`        | `options`    | []string | `[\"missingkey=error\"]`           | Template options                                    |\n" +
+		"| `vault`           | `command`    | string   | `vault`                          | Vault CLI command                                   |\n" +
 		"\n" +
 		"### Examples\n" +
 		"\n" +
@@ -2103,6 +2173,55 @@ func init() {
 		"    format: git\n" +
 		"```\n" +
 		"\n" +
+		"### Layered source directories\n" +
+		"\n" +
+		"`sourceDirs` is an ordered list of source directories, or layers, that are\n" +
+		"combined into a single source state. Entries, template data, `.chezmoiignore`\n" +
+		"and `.chezmoiremove` patterns, and `.chezmoitemplates` in later layers override\n" +
+		"those in earlier layers. A directory that exists in several layers takes its\n" +
+		"attributes from the last layer and contains the entries from all of them. A\n" +
+		"pattern in a later layer's `.chezmoiignore` or `.chezmoiremove` replaces the\n" +
+		"same pattern, with or without a leading `!`, from earlier layers. Each layer\n" +
+		"may contain its own `.chezmoiroot` file, and the `include` template function\n" +
+		"looks for files in later layers first.\n" +
+		"\n" +
+		"`sourceDir` is always a layer, and is added as the last layer if it is not\n" +
+		"listed in `sourceDirs`. Commands that modify the source state, like `add`,\n" +
+		"write to `sourceDir`. `edit` first copies the source file of an entry from an\n" +
+		"earlier layer to `sourceDir`, so that the change overrides the earlier layer.\n" +
+		"Other commands, like `chattr` and `forget`, modify the entry in the layer that\n" +
+		"it came from. `source-path`, `managed --format`, and `dump` show which layer\n" +
+		"each entry came from.\n" +
+		"\n" +
+		"For example, to layer your personal dotfiles on top of a shared repository:\n" +
+		"\n" +
+		"```toml\n" +
+		"sourceDirs = [\"/home/user/company-dotfiles\", \"/home/user/.local/share/chezmoi\"]\n" +
+		"```\n" +
+		"\n" +
+		"Run `chezmoi --source=/home/user/company-dotfiles add ...` to add files to the\n" +
+		"shared repository instead.\n" +
+		"\n" +
+		"### Persistent state\n" +
+		"\n" +
+		"chezmoi records which scripts it has run and the state of the targets that it\n" +
+		"has written in a persistent state, stored next to the configuration file.\n" +
+		"`persistentState.backend` chooses where it is stored:\n" +
+		"\n" +
+		"| Backend  | Storage                                                                   |\n" +
+		"| -------- | ------------------------------------------------------------------------- |\n" +
+		"| `bolt`   | A locked database in `chezmoistate.boltdb`.                               |\n" +
+		"| `json`   | A JSON file, `chezmoistate.json`, that is replaced atomically on exit.    |\n" +
+		"| `memory` | Memory only, so nothing is remembered between runs.                      |\n" +
+		"\n" +
+		"The `json` backend does not lock its file, so it works on network filesystems.\n" +
+		"When several instances of chezmoi run at the same time, the `json` backend keeps\n" +
+		"the changes made by each instance, but a change can be lost if two instances\n" +
+		"exit at the same moment. In dry runs the\n" +
+		"persistent state is copied into memory, so it is never changed. Use `state\n" +
+		"export` and `state import` to move the persistent state between machines or\n" +
+		"backends.\n" +
+		"\n" +
 		"## Source state attributes\n" +
 		"\n" +
 		"chezmoi stores the source state of files, symbolic links, and directories in\n" +
@@ -2115,12 +2234,20 @@ func init() {
 		"\n" +
 		"| Prefix       | Effect                                                                         |\n" +
 		"| ------------ | ------------------------------------------------------------------------------ |\n" +
+		"| `after_`     | Run the script after updating the destination.                                 |\n" +
+		"| `before_`    | Run the script before updating the destination.                                |\n" +
+		"| `block_`     | Manage only a block of text in the target file.                                |\n" +
+		"| `create_`    | Create the file if it does not exist, but never overwrite an existing file.    |\n" +
 		"| `encrypted_` | Encrypt the file in the source state.                                          |\n" +
 		"| `once_`      | Only run script once.                                                          |\n" +
 		"| `private_`   | Remove all group and world permissions from the target file or directory.      |\n" +
+		"| `readonly_`  | Remove all write permissions from the target file or directory.                |\n" +
 		"| `empty_`     | Ensure the file exists, even if is empty. By default, empty files are removed. |\n" +
 		"| `exact_`     | Remove anything not managed by chezmoi.                                        |\n" +
 		"| `executable_`| Add executable permissions to the target file.                                 |\n" +
+		"| `merge_`     | Merge the contents as a partial document into the target file.                 |\n" +
+		"| `mode_`      | Set exact permissions, e.g. `mode_0640_foo`, ignoring the umask.               |\n" +
+		"| `modify_`    | Treat the contents as a script that modifies an existing file.                 |\n" +
 		"| `run_`       | Treat the contents as a script to run.                                         |\n" +
 		"| `symlink_`   | Create a symlink instead of a regular file.                                    |\n" +
 		"| `dot_`       | Rename to use a leading dot, e.g. `dot_foo` becomes `.foo`.                    |\n" +
@@ -2129,17 +2256,110 @@ func init() {
 		"| ------- | ---------------------------------------------------- |\n" +
 		"| `.tmpl` | Treat the contents of the source file as a template. |\n" +
 		"\n" +
-		"Order of prefixes is important, the order is `run_`, `exact_`, `private_`,\n" +
-		"`empty_`, `executable_`, `symlink_`, `once_`, `dot_`.\n" +
+		"Order of prefixes is important, the order is `run_`, `create_`, `modify_`,\n" +
+		"`block_`, or `merge_`, `encrypted_`, `exact_`, `mode_` or `private_`,\n" +
+		"`readonly_`, `empty_`, `executable_`, `symlink_`, `once_`, `before_` or\n" +
+		"`after_`, `dot_`. For scripts, the `encrypted_` prefix\n" +
+		"comes after `once_`, `before_`, and `after_`, for example\n" +
+		"`run_once_before_encrypted_install.sh`.\n" +
 		"\n" +
 		"Different target types allow different prefixes and suffixes:\n" +
 		"\n" +
-		"| Target type   | Allowed prefixes                                          | Allowed suffixes |\n" +
-		"| ------------- | --------------------------------------------------------- | ---------------- |\n" +
-		"| Directory     | `exact_`, `private_`, `dot_`                              | *none*           |\n" +
-		"| Regular file  | `encrypted_`, `private_`, `empty_`, `executable_`, `dot_` | `.tmpl`          |\n" +
-		"| Script        | `run_`, `once_`                                           | `.tmpl`          |\n" +
-		"| Symbolic link | `symlink_`, `dot_`,                                       | `.tmpl`          |\n" +
+		"| Target type   | Allowed prefixes                                                                                     | Allowed suffixes |\n" +
+		"| ------------- | ---------------------------------------------------------------------------------------------------- | ---------------- |\n" +
+		"| Directory     | `exact_`, `mode_`, `private_`, `readonly_`, `dot_`                                                   | *none*           |\n" +
+		"| Regular file  | `create_`, `encrypted_`, `mode_`, `private_`, `readonly_`, `empty_`, `executable_`, `dot_`           | `.tmpl`          |\n" +
+		"| Modify file   | `modify_`, `encrypted_`, `mode_`, `private_`, `readonly_`, `executable_`, `dot_`                     | `.tmpl`          |\n" +
+		"| Block file    | `block_`, `encrypted_`, `mode_`, `private_`, `readonly_`, `executable_`, `dot_`                      | `.tmpl`          |\n" +
+		"| Merge file    | `merge_`, `encrypted_`, `mode_`, `private_`, `readonly_`, `executable_`, `dot_`                      | `.tmpl`          |\n" +
+		"| Script        | `run_`, `once_`, `before_`, `after_`, `encrypted_`                                                   | `.tmpl`          |\n" +
+		"| Symbolic link | `symlink_`, `dot_`,                                                                                  | `.tmpl`          |\n" +
+		"\n" +
+		"The `mode_` prefix is followed by octal permissions and an underscore, for\n" +
+		"example `mode_0640_dot_netrc` or `mode_0750_dir`. These permissions are used\n" +
+		"exactly, without applying the umask, and replace the `private_`, `readonly_`,\n" +
+		"and `executable_` attributes. `add` uses the `mode_` prefix when a target's\n" +
+		"permissions cannot be expressed with the other attributes and the umask.\n" +
+		"chezmoi temporarily makes read-only directories writable while it changes their\n" +
+		"contents.\n" +
+		"\n" +
+		"Each target must come from exactly one source path in a source directory. If two\n" +
+		"source paths have the same target, for example `dot_foo` and `private_dot_foo`\n" +
+		"or `foo` and `foo.tmpl`, then chezmoi fails with an error that names both source\n" +
+		"paths.\n" +
+		"\n" +
+		"Files with the `modify_` prefix are scripts that compute the contents of an\n" +
+		"existing file in the destination directory. chezmoi runs the script with the\n" +
+		"current contents of the target file on its standard input, or with no input if\n" +
+		"the target does not exist, and uses the script's standard output as the target\n" +
+		"contents. This allows chezmoi to manage parts of files that are also modified by\n" +
+		"other programs. Modify scripts are run whenever the target contents are needed,\n" +
+		"for example by `apply`, `diff`, and `verify`, so they should be idempotent. If\n" +
+		"the target file is not empty and the script produces no output then chezmoi\n" +
+		"reports an error and leaves the target file unchanged.\n" +
+		"\n" +
+		"Files with the `block_` prefix manage only the lines between a begin marker and\n" +
+		"an end marker in the target file, and leave the rest of the file intact. The\n" +
+		"contents of the source file are the contents of the block. If the target file\n" +
+		"does not contain the markers then the block is appended to it, and if the source\n" +
+		"file is empty then the block and its markers are removed. The target file is\n" +
+		"never removed, even if it is empty once the block is removed. By default the\n" +
+		"marker lines are:\n" +
+		"\n" +
+		"    # BEGIN chezmoi managed block\n" +
+		"    # END chezmoi managed block\n" +
+		"\n" +
+		"If the first line of the source file is a comment string followed by\n" +
+		"`chezmoi:block`, for example `// chezmoi:block`, then that line is not part of\n" +
+		"the block and the marker lines start with the comment string instead of `#`.\n" +
+		"As only the block changes, `diff` and `verify` only report changes to the block,\n" +
+		"and `merge` merges only the block.\n" +
+		"\n" +
+		"Files with the `merge_` prefix contain a partial structured document that is\n" +
+		"deep-merged into the existing target file. The format is chosen by the target\n" +
+		"file's extension, which must be one of `.ini`, `.json`, `.toml`, `.yaml`, or\n" +
+		"`.yml`. Maps are merged recursively and other values in the partial document\n" +
+		"replace values in the target file. If the target file does not exist then it is\n" +
+		"created with the contents of the partial document. The target file is re-encoded\n" +
+		"when merging changes it, so comments and formatting in the target file are only\n" +
+		"preserved if it is already up to date. The target file is never removed, even if\n" +
+		"the merged document is empty. The merge can be controlled with the\n" +
+		"optional `chezmoi:merge` key in the partial document, which is not itself\n" +
+		"merged:\n" +
+		"\n" +
+		"| Key      | Type         | Effect                                                                      |\n" +
+		"| -------- | ------------ | --------------------------------------------------------------------------- |\n" +
+		"| `lists`  | string       | `replace` (the default) replaces lists, `append` appends missing elements.  |\n" +
+		"| `delete` | list         | Keys to delete from the target file, as dot-separated paths like `a.b`.     |\n" +
+		"\n" +
+		"For example, `merge_dot_settings.json` might contain:\n" +
+		"\n" +
+		"```json\n" +
+		"{\n" +
+		"  \"chezmoi:merge\": {\n" +
+		"    \"lists\": \"append\",\n" +
+		"    \"delete\": [\"telemetry\"]\n" +
+		"  },\n" +
+		"  \"plugins\": [\"vim\"]\n" +
+		"}\n" +
+		"```\n" +
+		"\n" +
+		"In INI files, `chezmoi:merge` is a section and `delete` is a comma-separated\n" +
+		"list of key paths, where a key's path is its section and name, for example\n" +
+		"`core.pager`. `diff` and `verify` compare against the merged document.\n" +
+		"\n" +
+		"After its attributes are removed, the name of a file, script, symbolic link, or\n" +
+		"directory may be a template, in which case it is executed with the template\n" +
+		"data to give the target name. For example, the source directory\n" +
+		"`dot_config/{{ .codedir }}/User` has the target `~/.config/Code - OSS/User` if\n" +
+		"the `codedir` variable is `Code - OSS`, and the source file\n" +
+		"`dot_{{ .chezmoi.hostname }}.conf` is written to `~/.myhost.conf` on the machine\n" +
+		"`myhost`. The executed name must not be empty or contain a path separator, and\n" +
+		"it is an error if it is the same as the target name of another entry in the\n" +
+		"same source directory. `add` and `source-path` use the executed name, and `add`\n" +
+		"keeps the template when it updates an existing file. Note that some characters\n" +
+		"that are commonly used in templates, like `\"` and `|`, cannot be used in file\n" +
+		"names on Windows.\n" +
 		"\n" +
 		"## Special files and directories\n" +
 		"\n" +
@@ -2158,6 +2378,132 @@ func init() {
 		"    data:\n" +
 		"        email: \"{{ $email }}\"\n" +
 		"\n" +
+		"### `.chezmoiattributes`\n" +
+		"\n" +
+		"If a file called `.chezmoiattributes` exists in a directory in the source state\n" +
+		"then it declares attributes for the files and directories in that directory, as\n" +
+		"an alternative to encoding them in their source names. Each line contains a\n" +
+		"source name, as it appears in the directory, followed by its attributes,\n" +
+		"separated by whitespace. The attributes are `after`, `before`, `block`,\n" +
+		"`create`, `empty`, `encrypted`, `exact`, `executable`, `merge`, `modify`,\n" +
+		"`once`, `private`, `readonly`, `run`, `symlink`, `template`, and\n" +
+		"`mode=`*octal-mode*, and they have the same effect as the corresponding\n" +
+		"prefixes and suffixes.\n" +
+		"\n" +
+		"Declared attributes are combined with the attributes in the source name. It is\n" +
+		"an error to declare attributes that do not apply to the type of the target, or\n" +
+		"that conflict with each other or with the attributes in the source name, for\n" +
+		"example `mode=0640` for `private_foo`, or `block` for `modify_foo`. Each source\n" +
+		"name can only appear once.\n" +
+		"\n" +
+		"Comments are introduced with the `#` character and run until the end of the\n" +
+		"line.\n" +
+		"\n" +
+		"#### `.chezmoiattributes` examples\n" +
+		"\n" +
+		"    dot_netrc   private template # instead of private_dot_netrc.tmpl\n" +
+		"    dot_ssh     exact private\n" +
+		"    install.sh  run once before\n" +
+		"    app.conf    mode=0640\n" +
+		"\n" +
+		"### `.chezmoidata.<format>`\n" +
+		"\n" +
+		"If a file called `.chezmoidata.<format>` exists in the root of the source\n" +
+		"directory, where *format* is one of `json`, `toml`, or `yaml`, then it is\n" +
+		"interpreted as template data. Template data can also be split across multiple\n" +
+		"files with these extensions in a directory called `.chezmoidata` in the root of\n" +
+		"the source directory. `.chezmoidata.<format>` files are not templates.\n" +
+		"\n" +
+		"All these files are read in order, `.chezmoidata.json`, `.chezmoidata.toml`,\n" +
+		"and `.chezmoidata.yaml` followed by the files in the `.chezmoidata` directory\n" +
+		"in alphabetical order, and deep-merged, with later files taking precedence over\n" +
+		"earlier ones. The `data` section of the config file is then merged on top, so\n" +
+		"the config file can override any values from the source directory. It is an\n" +
+		"error if a key is a map or a list in one place and not in another.\n" +
+		"\n" +
+		"#### `.chezmoidata.<format>` examples\n" +
+		"\n" +
+		"If `.chezmoidata.toml` contains:\n" +
+		"\n" +
+		"    [fonts]\n" +
+		"        size = 12\n" +
+		"        family = \"Hack\"\n" +
+		"\n" +
+		"and the config file contains:\n" +
+		"\n" +
+		"    [data.fonts]\n" +
+		"        size = 14\n" +
+		"\n" +
+		"then `.fonts.size` is `14` and `.fonts.family` is `Hack`.\n" +
+		"\n" +
+		"### `.chezmoiexternal.<format>`\n" +
+		"\n" +
+		"If a file called `.chezmoiexternal.<format>` exists in the source state, where\n" +
+		"*format* is one of `json`, `toml`, or `yaml`, then it is interpreted as a list\n" +
+		"of targets that are populated from URLs, instead of from the source state.\n" +
+		"`.chezmoiexternal.<format>` is interpreted as a template. The keys are target\n" +
+		"paths relative to the directory containing the `.chezmoiexternal.<format>`\n" +
+		"file, and must not be absolute or contain `..` components that would leave\n" +
+		"that directory. The values are tables with the following variables:\n" +
+		"\n" +
+		"| Variable          | Type   | Default value | Description                                           |\n" +
+		"| ----------------- | ------ | ------------- | ----------------------------------------------------- |\n" +
+		"| `type`            | string | `file`        | Either `file`, `archive`, or `git-repo`               |\n" +
+		"| `url`             | string | *none*        | URL, either `http`, `https`, or `file`                |\n" +
+		"| `checksum`        | string | *none*        | Expected SHA256 sum of the contents of the URL        |\n" +
+		"| `exact`           | bool   | `false`       | Remove anything not in the archive                    |\n" +
+		"| `executable`      | bool   | `false`       | Add executable permissions to the file                |\n" +
+		"| `format`          | string | *from URL*    | Archive format: `tar`, `tar.gz`, `tar.bz2`, or `zip`  |\n" +
+		"| `ref`             | string | *none*        | Git branch, tag, or commit to check out               |\n" +
+		"| `refreshPeriod`   | string | *never*       | Duration after which the URL is downloaded again      |\n" +
+		"| `stripComponents` | int    | `0`           | Number of leading path components to strip            |\n" +
+		"\n" +
+		"If `type` is `file` then the target is a regular file with the contents of the\n" +
+		"URL. If `type` is `archive` then the target is a directory containing the\n" +
+		"contents of the archive. The archive format is determined from the extension of\n" +
+		"the URL unless `format` is set.\n" +
+		"\n" +
+		"If `type` is `git-repo` then the target is a git repository that is cloned from\n" +
+		"`url`, which can be any URL understood by `git clone`, including the path of a\n" +
+		"local repository. If `ref` is set then chezmoi checks out `ref` after cloning,\n" +
+		"and checks it out again whenever it refers to a different commit in the remote\n" +
+		"repository. `ref` must be a tag, a branch, or a full 40 character commit hash;\n" +
+		"abbreviated commit hashes are not supported. Like `git`, chezmoi looks up `ref`\n" +
+		"as a tag before looking it up as a branch, unless `ref` is a full ref name like\n" +
+		"`refs/heads/main`. If `ref` is not set then chezmoi fast-forwards the repository\n" +
+		"to the remote's default branch. `verify` fails if the repository has not been cloned or is not\n" +
+		"at the expected commit. The contents of git repositories are not included by\n" +
+		"`archive`. Repositories are cloned and updated with the `sourceVCS.command`\n" +
+		"configuration variable if it is `git`, like the `git` command.\n" +
+		"\n" +
+		"Downloaded URLs are cached in the cache directory. If `refreshPeriod` is set, for\n" +
+		"example to `168h`, then the URL is downloaded again when the cached copy is\n" +
+		"older than the refresh period. Otherwise, the cached copy is used until the\n" +
+		"cache directory is removed. URLs are only downloaded when the contents of their\n" +
+		"targets are needed, so commands like `add`, `data`, and `edit` do not need\n" +
+		"network access. `managed` lists the target of an archive, but not the entries\n" +
+		"in it.\n" +
+		"\n" +
+		"Targets populated from `.chezmoiexternal.<format>` files take part in `apply`,\n" +
+		"`archive`, `diff`, `dump`, `managed`, and `verify` like any other target, but\n" +
+		"cannot be modified with `chattr`, `forget`, `merge`, or `remove`. Instead, edit\n" +
+		"the `.chezmoiexternal.<format>` file.\n" +
+		"\n" +
+		"#### `.chezmoiexternal.<format>` examples\n" +
+		"\n" +
+		"    [\".oh-my-zsh\"]\n" +
+		"        type = \"archive\"\n" +
+		"        url = \"https://github.com/ohmyzsh/ohmyzsh/archive/master.tar.gz\"\n" +
+		"        exact = true\n" +
+		"        stripComponents = 1\n" +
+		"        refreshPeriod = \"168h\"\n" +
+		"    [\".vim/autoload/plug.vim\"]\n" +
+		"        url = \"https://raw.githubusercontent.com/junegunn/vim-plug/master/plug.vim\"\n" +
+		"    [\".tmux/plugins/tpm\"]\n" +
+		"        type = \"git-repo\"\n" +
+		"        url = \"https://github.com/tmux-plugins/tpm.git\"\n" +
+		"        ref = \"v3.0.0\"\n" +
+		"\n" +
 		"### `.chezmoiignore`\n" +
 		"\n" +
 		"If a file called `.chezmoiignore` exists in the source state then it is\n" +
@@ -2193,12 +2539,71 @@ func init() {
 		"    .personal-file\n" +
 		"    {{- end }}\n" +
 		"\n" +
+		"### `.chezmoimap`\n" +
+		"\n" +
+		"If a file called `.chezmoimap` exists in the root of the source directory then\n" +
+		"it maps source files to target paths, overriding the target paths given by\n" +
+		"their source names. Each line contains a source path, relative to the source\n" +
+		"directory, followed by a target path, relative to the destination directory.\n" +
+		"Paths that contain whitespace can be written as double-quoted strings. Comments\n" +
+		"are introduced with the `#` character and run until the end of the line.\n" +
+		"\n" +
+		"`.chezmoimap` is interpreted as a template, so the same source file can be\n" +
+		"mapped to different target paths on different machines. Only files and symbolic\n" +
+		"links can be mapped. Mappings of source paths that do not exist are ignored.\n" +
+		"Source files that are not mapped keep their usual target paths, so alternatives\n" +
+		"for other machines should be ignored in `.chezmoiignore`.\n" +
+		"Directories that contain mapped targets and are not otherwise in the source\n" +
+		"state are created as needed and, like externals, cannot be edited or have files\n" +
+		"added to them. Commands like `source-path`, `managed`, and `forget` use the\n" +
+		"mapped target paths. `forget` also removes the mapping from `.chezmoimap`.\n" +
+		"\n" +
+		"With layered source directories, each source directory has its own\n" +
+		"`.chezmoimap`, and entries from later source directories override mapped\n" +
+		"entries from earlier ones, as usual.\n" +
+		"\n" +
+		"#### `.chezmoimap` examples\n" +
+		"\n" +
+		"    # Install the configuration for the current distribution.\n" +
+		"    linux/{{ .distro }}.conf .config/app/app.conf\n" +
+		"    \"vscode.json\" \".config/Code - OSS/User/settings.json\"\n" +
+		"\n" +
 		"### `.chezmoiremove`\n" +
 		"\n" +
 		"If a file called `.chezmoiremove` exists in the source state then it is\n" +
 		"interpreted as a list of targets to remove. `.chezmoiremove` is interpreted as a\n" +
 		"template.\n" +
 		"\n" +
+		"### `.chezmoiroot`\n" +
+		"\n" +
+		"If a file called `.chezmoiroot` exists in the root of the source directory then\n" +
+		"its contents, with leading and trailing whitespace removed, name a subdirectory\n" +
+		"of the source directory which is used as the root of the source state. This\n" +
+		"allows the repository containing your dotfiles to contain other files, like a\n" +
+		"`README.md` or CI configuration, that are not part of the source state.\n" +
+		"\n" +
+		"All commands that read or modify the source state, including `add`, `chattr`,\n" +
+		"`edit`, `forget`, and `source-path`, use this subdirectory. The\n" +
+		"`.chezmoidata.<format>` files, the `.chezmoi.<format>.tmpl` config file\n" +
+		"template, files included with the `include` template function, and\n" +
+		"`.chezmoi.sourceDir` are all relative to it. Version control commands, like\n" +
+		"`git`, `update`, and automatic commits and pushes, operate on the source\n" +
+		"directory itself.\n" +
+		"\n" +
+		"#### `.chezmoiroot` examples\n" +
+		"\n" +
+		"Given a repository with the structure:\n" +
+		"\n" +
+		"    .chezmoiroot\n" +
+		"    README.md\n" +
+		"    home/dot_bashrc\n" +
+		"\n" +
+		"where `.chezmoiroot` contains:\n" +
+		"\n" +
+		"    home\n" +
+		"\n" +
+		"then `~/.bashrc` is managed and `README.md` is not.\n" +
+		"\n" +
 		"### `.chezmoitemplates`\n" +
 		"\n" +
 		"If a directory called `.chezmoitemplates` exists, then all files in this\n" +
@@ -2281,10 +2686,74 @@ func init() {
 		"Ensure that *targets* are in the target state, updating them if necessary. If no\n" +
 		"targets are specified, the state of all targets are ensured.\n" +
 		"\n" +
+		"chezmoi records the contents and permissions of each file and symlink that it\n" +
+		"writes in its persistent state. If a target has since been changed locally, and\n" +
+		"its target state has also changed, then `apply` resolves the conflict according\n" +
+		"to the `--conflict` policy instead of overwriting the local changes. Files with\n" +
+		"the `create_`, `modify_`, `block_`, or `merge_` attributes are expected to be\n" +
+		"changed locally and are not checked for conflicts.\n" +
+		"\n" +
+		"#### `-x`, `--exclude` *types*\n" +
+		"\n" +
+		"Exclude entries of type *types*. *types* is a comma-separated list of types of\n" +
+		"entry, as for `--include`. By default, no entries are excluded.\n" +
+		"\n" +
+		"#### `-i`, `--include` *types*\n" +
+		"\n" +
+		"Only include entries of type *types*. *types* is a comma-separated list of types\n" +
+		"and attributes of entry. Valid types are `dirs`, `files`, `symlinks`, and\n" +
+		"`scripts`, and valid attributes are `encrypted`, `templates`, and `once`.\n" +
+		"`dirs`, `files`, and `symlinks` can be abbreviated to `d`, `f`, and `s`\n" +
+		"respectively, and `all` and `none` select all or no types. An entry is included\n" +
+		"if any of its type and attributes are included and none of them are excluded.\n" +
+		"By default, entries of all types are included.\n" +
+		"\n" +
+		"If a directory is excluded, then it is not created and its permissions are not\n" +
+		"changed. If it is exact, entries that are not in the source state are not\n" +
+		"removed from it. Its included entries are still applied if the directory\n" +
+		"already exists.\n" +
+		"\n" +
+		"#### `--interactive`\n" +
+		"\n" +
+		"Before each change, show it as a diff in the format set by the `diff.format`\n" +
+		"configuration variable and ask what to do. The choices are:\n" +
+		"\n" +
+		"* `o`: make the change.\n" +
+		"* `s`: skip the change.\n" +
+		"* `m`: merge the change into the existing file using the `merge.command`.\n" +
+		"* `q`: stop without making any further changes.\n" +
+		"* `a`: make this change and all remaining changes.\n" +
+		"* `n`: skip this change and all remaining changes.\n" +
+		"\n" +
+		"Further changes to a target after the first are made or skipped without asking\n" +
+		"again. If the creation of a directory is skipped, then all the changes in it are\n" +
+		"skipped too. Merging is only offered for changes to existing files. With the\n" +
+		"default `prompt` conflict policy, conflicts are not asked about separately.\n" +
+		"\n" +
+		"#### `--output-format` *format*\n" +
+		"\n" +
+		"Set the output format, either `text`, the default, or `json`. In `json` format,\n" +
+		"each change to a target is written to stdout as a JSON object on its own line,\n" +
+		"with the fields:\n" +
+		"\n" +
+		"* `path`: the path of the target.\n" +
+		"* `type`: the type of the target, `dir`, `file`, `symlink`, or `script`.\n" +
+		"* `action`: the change, `mkdir`, `write`, `chmod`, `remove`, `symlink`, `rename`, or `run-script`.\n" +
+		"* `old`, `new`: the state of the target before and after the change, if it exists.\n" +
+		"* `error`: the error, if the change failed.\n" +
+		"\n" +
+		"States contain the target's `mode` and, for files and symlinks, the SHA256 sum\n" +
+		"of its contents or link target in `contentsSHA256`. Scripts are reported when\n" +
+		"they would be run, including in dry runs and by `diff` and `verify`, although\n" +
+		"scripts do not cause `verify` to fail. The standard output of scripts is written to stderr so that\n" +
+		"stdout only contains JSON.\n" +
+		"\n" +
 		"#### `apply` examples\n" +
 		"\n" +
 		"    chezmoi apply\n" +
 		"    chezmoi apply --dry-run --verbose\n" +
+		"    chezmoi apply --include=files --exclude=encrypted\n" +
+		"    chezmoi apply --interactive\n" +
 		"    chezmoi apply ~/.bashrc\n" +
 		"\n" +
 		"### `archive`\n" +
@@ -2292,6 +2761,15 @@ func init() {
 		"Generate a tar archive of the target state. This can be piped into `tar` to\n" +
 		"inspect the target state.\n" +
 		"\n" +
+		"#### `-x`, `--exclude` *types*\n" +
+		"\n" +
+		"Exclude entries of type *types*, as for `apply`.\n" +
+		"\n" +
+		"#### `-i`, `--include` *types*\n" +
+		"\n" +
+		"Only include entries of type *types*, as for `apply`. Excluded directories are\n" +
+		"omitted from the archive, but their included entries are not.\n" +
+		"\n" +
 		"#### `--output`, `-o` *filename*\n" +
 		"\n" +
 		"Write the output to *filename* instead of stdout.\n" +
@@ -2332,21 +2810,38 @@ func init() {
 		"\n" +
 		"| Attribute    | Abbreviation |\n" +
 		"| ------------ | ------------ |\n" +
+		"| `create`     | *none*       |\n" +
 		"| `empty`      | `e`          |\n" +
 		"| `encrypted`  | *none*       |\n" +
 		"| `exact`      | *none*       |\n" +
 		"| `executable` | `x`          |\n" +
 		"| `private`    | `p`          |\n" +
+		"| `readonly`   | `r`          |\n" +
 		"| `template`   | `t`          |\n" +
 		"\n" +
+		"Explicit permissions are set with `mode=`*octal-mode*, for example `mode=0640`,\n" +
+		"and removed with `nomode`. Modifying the `executable`, `private`, or `readonly`\n" +
+		"attributes also removes any explicit permissions.\n" +
+		"\n" +
 		"Multiple attributes modifications may be specified by separating them with a\n" +
 		"comma (`,`).\n" +
 		"\n" +
+		"Attributes declared in a `.chezmoiattributes` file can only be modified with\n" +
+		"the `--metadata` flag.\n" +
+		"\n" +
+		"#### `--metadata`\n" +
+		"\n" +
+		"Modify the attributes declared in `.chezmoiattributes` files instead of renaming\n" +
+		"files in the source state. Attributes that are set by source names cannot be\n" +
+		"removed with this flag.\n" +
+		"\n" +
 		"#### `chattr` examples\n" +
 		"\n" +
 		"    chezmoi chattr template ~/.bashrc\n" +
 		"    chezmoi chattr noempty ~/.profile\n" +
 		"    chezmoi chattr private,template ~/.netrc\n" +
+		"    chezmoi chattr mode=0640 ~/.config/app/secrets.conf\n" +
+		"    chezmoi chattr --metadata private,template ~/.netrc\n" +
 		"\n" +
 		"### `completion` *shell*\n" +
 		"\n" +
@@ -2364,7 +2859,8 @@ func init() {
 		"\n" +
 		"### `data`\n" +
 		"\n" +
-		"Write the computed template data in JSON format to stdout. The `data` command\n" +
+		"Write the computed template data in JSON format to stdout, including any data\n" +
+		"from `.chezmoidata.<format>` files in the source directory. The `data` command\n" +
 		"accepts additional flags:\n" +
 		"\n" +
 		"#### `-f`, `--format` *format*\n" +
@@ -2397,14 +2893,28 @@ func init() {
 		"\n" +
 		"##### `git`\n" +
 		"\n" +
-		"A [git format diff](https://git-scm.com/docs/diff-format), excluding scripts. In\n" +
-		"version 2.0.0 of chezmoi, `git` format diffs will become the default and include\n" +
-		"scripts and the `chezmoi` format will be removed.\n" +
+		"A [git format diff](https://git-scm.com/docs/diff-format). Scripts that would be\n" +
+		"run are shown as new executable files. In version 2.0.0 of chezmoi, `git` format\n" +
+		"diffs will become the default and the `chezmoi` format will be removed.\n" +
+		"\n" +
+		"#### `-x`, `--exclude` *types*\n" +
+		"\n" +
+		"Exclude entries of type *types*, as for `apply`.\n" +
+		"\n" +
+		"#### `-i`, `--include` *types*\n" +
+		"\n" +
+		"Only include entries of type *types*, as for `apply`.\n" +
 		"\n" +
 		"#### `--no-pager`\n" +
 		"\n" +
 		"Do not use the pager.\n" +
 		"\n" +
+		"#### `--output-format` *format*\n" +
+		"\n" +
+		"Set the output format, either `text`, the default, or `json`. In `json` format,\n" +
+		"each change that `apply` would make is written as a JSON object in the same\n" +
+		"format as `apply`, instead of as a diff, and the pager is not used.\n" +
+		"\n" +
 		"#### `diff` examples\n" +
 		"\n" +
 		"    chezmoi diff\n" +
@@ -2425,6 +2935,8 @@ func init() {
 		"### `doctor`\n" +
 		"\n" +
 		"Check for potential problems.\n" +
+		"`doctor` also reports source paths that have the same target, and targets that\n" +
+		"differ only in case, which collide on case-insensitive filesystems.\n" +
 		"\n" +
 		"#### `doctor` examples\n" +
 		"\n" +
@@ -2435,11 +2947,20 @@ func init() {
 		"Dump the target state in JSON format. If no targets are specified, then the\n" +
 		"entire target state. The `dump` command accepts additional arguments:\n" +
 		"\n" +
+		"#### `-x`, `--exclude` *types*\n" +
+		"\n" +
+		"Exclude entries of type *types*, as for `apply`.\n" +
+		"\n" +
 		"#### `-f`, `--format` *format*\n" +
 		"\n" +
 		"Print the target state in the given format. The accepted formats are `json`\n" +
 		"(JSON) and `yaml` (YAML).\n" +
 		"\n" +
+		"#### `-i`, `--include` *types*\n" +
+		"\n" +
+		"Only include entries of type *types*, as for `apply`. Excluded directories are\n" +
+		"only dumped if they contain included entries.\n" +
+		"\n" +
 		"#### `dump` examples\n" +
 		"\n" +
 		"    chezmoi dump ~/.bashrc\n" +
@@ -2447,9 +2968,11 @@ func init() {
 		"\n" +
 		"### `edit` [*targets*]\n" +
 		"\n" +
-		"Edit the source state of *targets*, which must be files or symlinks. If no\n" +
-		"targets are given the the source directory itself is opened with `$EDITOR`. The\n" +
-		"`edit` command accepts additional arguments:\n" +
+		"Edit the source state of *targets*, which must be files, scripts, or symlinks.\n" +
+		"If no targets are given the the source directory itself is opened with\n" +
+		"`$EDITOR`. Encrypted files and scripts are decrypted before editing and\n" +
+		"re-encrypted afterwards. Scripts are never run by `edit`. The `edit` command\n" +
+		"accepts additional arguments:\n" +
 		"\n" +
 		"#### `-a`, `--apply`\n" +
 		"\n" +
@@ -2523,7 +3046,8 @@ func init() {
 		"\n" +
 		"### `forget` *targets*\n" +
 		"\n" +
-		"Remove *targets* from the source state, i.e. stop managing them.\n" +
+		"Remove *targets* from the source state, i.e. stop managing them. If a target is\n" +
+		"mapped by `.chezmoimap` then its mapping is also removed.\n" +
 		"\n" +
 		"#### `forget` examples\n" +
 		"\n" +
@@ -2616,12 +3140,20 @@ func init() {
 		"\n" +
 		"List all managed entries in the destination directory in alphabetical order.\n" +
 		"\n" +
+		"#### `-x`, `--exclude` *types*\n" +
+		"\n" +
+		"Exclude entries of type *types*, as for `apply`.\n" +
+		"\n" +
+		"#### `-f`, `--format` *format*\n" +
+		"\n" +
+		"Print the target path, source path, and source directory of each entry in the\n" +
+		"given format instead of just the target path. The accepted formats are `json`\n" +
+		"(JSON), `toml` (TOML), and `yaml` (YAML).\n" +
+		"\n" +
 		"#### `-i`, `--include` *types*\n" +
 		"\n" +
-		"Only list entries of type *types*. *types* is a comma-separated list of types of\n" +
-		"entry to include. Valid types are `dirs`, `files`, and `symlinks` which can be\n" +
-		"abbreviated to `d`, `f`, and `s` respectively. By default, `manage` will list\n" +
-		"entries of all types.\n" +
+		"Only list entries of type *types*, as for `apply`. By default, `managed` will\n" +
+		"list entries of all types.\n" +
 		"\n" +
 		"#### `managed` examples\n" +
 		"\n" +
@@ -2630,6 +3162,8 @@ func init() {
 		"    chezmoi managed --include=files,symlinks\n" +
 		"    chezmoi managed -i d\n" +
 		"    chezmoi managed -i d,f\n" +
+		"    chezmoi managed --exclude=encrypted\n" +
+		"    chezmoi managed --format=json\n" +
 		"\n" +
 		"### `merge` *targets*\n" +
 		"\n" +
@@ -2640,6 +3174,10 @@ func init() {
 		"example if source is a template containing errors or an encrypted file that\n" +
 		"cannot be decrypted) a two-way merge is performed instead.\n" +
 		"\n" +
+		"For files with the `block_` attribute, only the managed block of the\n" +
+		"destination state and the target state are merged, and any changes to the\n" +
+		"destination state's block are written back to the destination file.\n" +
+		"\n" +
 		"#### `merge` examples\n" +
 		"\n" +
 		"    chezmoi merge ~/.bashrc\n" +
@@ -2666,6 +3204,22 @@ func init() {
 		"\n" +
 		"Remove without prompting.\n" +
 		"\n" +
+		"### `restore` [*backup* [*targets*]]\n" +
+		"\n" +
+		"Restore targets from backups made with the `--backup` flag. With no arguments,\n" +
+		"list each backup and the targets that it contains. With *backup*, restore all\n" +
+		"the targets in *backup*. With *backup* and *targets*, restore only *targets*.\n" +
+		"\n" +
+		"Restored files and symlinks replace the current targets. Restored directories\n" +
+		"are merged with the current directories, so files created since the backup was\n" +
+		"made are kept.\n" +
+		"\n" +
+		"#### `restore` examples\n" +
+		"\n" +
+		"    chezmoi restore\n" +
+		"    chezmoi restore 20201017T143000.000Z\n" +
+		"    chezmoi restore 20201017T143000.000Z ~/.bashrc\n" +
+		"\n" +
 		"### `rm` *targets*\n" +
 		"\n" +
 		"`rm` is an alias for `remove`.\n" +
@@ -2708,14 +3262,82 @@ func init() {
 		"\n" +
 		"### `source-path` [*targets*]\n" +
 		"\n" +
-		"Print the path to each target's source state. If no targets are specified then\n" +
-		"print the source directory.\n" +
+		"Print the path to each target's source state, in the layer that it came from.\n" +
+		"If no targets are specified then print the source directory.\n" +
 		"\n" +
 		"#### `source-path` examples\n" +
 		"\n" +
 		"    chezmoi source-path\n" +
 		"    chezmoi source-path ~/.bashrc\n" +
 		"\n" +
+		"### `state` *subcommand*\n" +
+		"\n" +
+		"Inspect and edit chezmoi's persistent state, which records the state of targets\n" +
+		"that chezmoi has written in the `entryState` bucket and the scripts with the\n" +
+		"`once_` attribute that have been run in the `script` bucket. The subcommands\n" +
+		"are:\n" +
+		"\n" +
+		"* `dump` writes all buckets and keys.\n" +
+		"* `get --bucket` *bucket* `--key` *key* writes the value of *key* in *bucket*.\n" +
+		"* `delete --bucket` *bucket* `--key` *key* deletes *key* from *bucket*.\n" +
+		"* `reset --bucket` *bucket* deletes all keys in *bucket*.\n" +
+		"* `scripts` lists the scripts with the `once_` attribute that have been run.\n" +
+		"* `export` writes the whole persistent state as JSON.\n" +
+		"* `import` [*filename*] adds the keys written by `export` from *filename*.\n" +
+		"\n" +
+		"`import` reads from stdin if no *filename* is given.\n" +
+		"\n" +
+		"Deleting a script's key from the `script` bucket causes it to be run again by\n" +
+		"the next `apply`.\n" +
+		"\n" +
+		"#### `-f`, `--format` *format*\n" +
+		"\n" +
+		"Print `dump` and `scripts` output in the given format. The accepted formats are\n" +
+		"`json` (JSON), `toml` (TOML), and `yaml` (YAML).\n" +
+		"\n" +
+		"#### `state` examples\n" +
+		"\n" +
+		"    chezmoi state dump\n" +
+		"    chezmoi state get --bucket entryState --key ~/.bashrc\n" +
+		"    chezmoi state scripts --format=yaml\n" +
+		"    chezmoi state delete --bucket script --key \"install.sh:$HASH\"\n" +
+		"    chezmoi state reset --bucket script\n" +
+		"    chezmoi state export > state.json\n" +
+		"    chezmoi state import state.json\n" +
+		"\n" +
+		"### `status` [*targets*]\n" +
+		"\n" +
+		"Print a line for each of *targets* that `apply` would change, similar to\n" +
+		"`git status --short`. If no targets are specified, the status of all targets is\n" +
+		"printed. Each line contains two status codes followed by the target's path.\n" +
+		"\n" +
+		"The first column describes how the target has changed in the destination\n" +
+		"directory since chezmoi last wrote it, and the second column describes the\n" +
+		"change that `apply` would make to the target. The codes are:\n" +
+		"\n" +
+		"* A space: no change.\n" +
+		"* `A`: the target would be added.\n" +
+		"* `D`: the target was deleted, or would be deleted.\n" +
+		"* `M`: the target's contents or permissions were modified, or would be modified.\n" +
+		"* `T`: the target's type, file, directory, or symlink, would change.\n" +
+		"* `R`: the script would be run.\n" +
+		"\n" +
+		"The first column is only set for files and symlinks that chezmoi has written.\n" +
+		"\n" +
+		"#### `-x`, `--exclude` *types*\n" +
+		"\n" +
+		"Exclude entries of type *types*, as for `apply`.\n" +
+		"\n" +
+		"#### `-i`, `--include` *types*\n" +
+		"\n" +
+		"Only include entries of type *types*, as for `apply`.\n" +
+		"\n" +
+		"#### `status` examples\n" +
+		"\n" +
+		"    chezmoi status\n" +
+		"    chezmoi status ~/.bashrc\n" +
+		"    chezmoi status --exclude=scripts\n" +
+		"\n" +
 		"### `unmanage` *targets*\n" +
 		"\n" +
 		"`unmanage` is an alias for `forget` for symmetry with `manage`.\n" +
@@ -2762,11 +3384,29 @@ func init() {
 		"\n" +
 		"Verify that all *targets* match their target state. chezmoi exits with code 0\n" +
 		"(success) if all targets match their target state, or 1 (failure) otherwise. If\n" +
-		"no targets are specified then all targets are checked.\n" +
+		"no targets are specified then all targets are checked. With `--verbose`, each\n" +
+		"file and symlink that does not match is printed with whether it has changed\n" +
+		"locally, in the source state, or both since chezmoi last wrote it.\n" +
+		"\n" +
+		"#### `-x`, `--exclude` *types*\n" +
+		"\n" +
+		"Exclude entries of type *types*, as for `apply`.\n" +
+		"\n" +
+		"#### `-i`, `--include` *types*\n" +
+		"\n" +
+		"Only include entries of type *types*, as for `apply`.\n" +
+		"\n" +
+		"#### `--output-format` *format*\n" +
+		"\n" +
+		"Set the output format, either `text`, the default, or `json`. In `json` format,\n" +
+		"each change that `apply` would make is written as a JSON object in the same\n" +
+		"format as `apply`, so that the reasons for a failure can be read by other\n" +
+		"programs.\n" +
 		"\n" +
 		"#### `verify` examples\n" +
 		"\n" +
 		"    chezmoi verify\n" +
+		"    chezmoi verify --output-format=json\n" +
 		"    chezmoi verify ~/.bashrc\n" +
 		"\n" +
 		"## Editor configuration\n" +
@@ -2778,9 +3418,11 @@ func init() {
 		"## Umask configuration\n" +
 		"\n" +
 		"By default, chezmoi uses your current umask as set by your operating system and\n" +
-		"shell. chezmoi only stores crude permissions in its source state, namely in the\n" +
-		"`executable`  and `private` attributes, corresponding to the umasks of `0o111`\n" +
-		"and `0o077` respectively.\n" +
+		"shell. chezmoi normally only stores crude permissions in its source state,\n" +
+		"namely in the `executable`, `private`, and `readonly` attributes, corresponding\n" +
+		"to the umasks of `0o111`, `0o077`, and `0o222` respectively, and the umask is\n" +
+		"applied to the result. Permissions given with the `mode_` attribute are exact\n" +
+		"and the umask is not applied to them.\n" +
 		"\n" +
 		"For machine-specific control of umask, set the `umask` configuration variable in\n" +
 		"chezmoi's configuration file, for example:\n" +
@@ -2831,7 +3473,8 @@ func init() {
 		"| `.chezmoi.sourceDir`    | The source directory.                                                                                                           |\n" +
 		"| `.chezmoi.username`     | The username of the user running chezmoi.                                                                                       |\n" +
 		"\n" +
-		"Additional variables can be defined in the config file in the `data` section.\n" +
+		"Additional variables can be defined in the config file in the `data` section,\n" +
+		"and in `.chezmoidata.<format>` files in the source directory.\n" +
 		"Variable names must consist of a letter and be followed by zero or more letters\n" +
 		"and/or digits.\n" +
 		"\n" +
@@ -7,11 +7,14 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 
 	"github.com/coreos/go-semver/semver"
 	"github.com/spf13/cobra"
 	shell "github.com/twpayne/go-shell"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
 var doctorCmd = &cobra.Command{
@@ -78,6 +81,12 @@ type doctorSuspiciousFilesCheck struct {
 	found     []string
 }
 
+type doctorTargetsCheck struct {
+	getTargetState func() (*chezmoi.TargetState, error)
+	err            error
+	caseCollisions []string
+}
+
 type doctorVersionCheck struct{}
 
 var gpgBinaryCheck = &doctorBinaryCheck{
@@ -131,6 +140,11 @@ func (c *Config) runDoctorCmd(cmd *cobra.Command, args []string) error {
 				".chezmoignore": true,
 			},
 		},
+		&doctorTargetsCheck{
+			getTargetState: func() (*chezmoi.TargetState, error) {
+				return c.getTargetState(nil)
+			},
+		},
 		&doctorDirectoryCheck{
 			name: "destination directory",
 			path: c.DestDir,
@@ -430,6 +444,58 @@ func (c *doctorSuspiciousFilesCheck) Skip() bool {
 	return false
 }
 
+func (c *doctorTargetsCheck) Check() (bool, error) {
+	ts, err := c.getTargetState()
+	if err != nil {
+		c.err = err
+		return false, nil
+	}
+	// Find targets whose names differ only in case, which collide on
+	// case-insensitive filesystems.
+	sourcePathsByName := make(map[string][]string)
+	var names []string
+	for _, entry := range ts.AllEntries() {
+		name := strings.ToLower(entry.TargetName())
+		if _, ok := sourcePathsByName[name]; !ok {
+			names = append(names, name)
+		}
+		sourcePathsByName[name] = append(sourcePathsByName[name], filepath.Join(ts.EntrySourceDir(entry), entry.SourceName()))
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if sourcePaths := sourcePathsByName[name]; len(sourcePaths) > 1 {
+			sort.Strings(sourcePaths)
+			c.caseCollisions = append(c.caseCollisions, strings.Join(sourcePaths, " and "))
+		}
+	}
+	return len(c.caseCollisions) == 0, nil
+}
+
+func (c *doctorTargetsCheck) Enabled() bool {
+	return true
+}
+
+func (c *doctorTargetsCheck) MustSucceed() bool {
+	// Targets that differ only in case are only a problem on some
+	// filesystems.
+	return c.err != nil
+}
+
+func (c *doctorTargetsCheck) Result() string {
+	switch {
+	case c.err != nil:
+		return fmt.Sprintf("%v (source state)", c.err)
+	case len(c.caseCollisions) != 0:
+		return fmt.Sprintf("%s (targets differ only in case)", strings.Join(c.caseCollisions, ", "))
+	default:
+		return "no duplicate targets (source state)"
+	}
+}
+
+func (c *doctorTargetsCheck) Skip() bool {
+	return false
+}
+
 func (doctorVersionCheck) Check() (bool, error) {
 	if VersionStr == "" || Commit == "" || Date == "" {
 		return false, nil
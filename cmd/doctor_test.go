@@ -1,12 +1,16 @@
 package cmd
 
 import (
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/coreos/go-semver/semver"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
 func TestDoctorBinaryCheck(t *testing.T) {
@@ -66,3 +70,60 @@ func TestDoctorBinaryCheck(t *testing.T) {
 		})
 	}
 }
+
+func TestDoctorTargetsCheck(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		root           interface{}
+		expectedPrefix string
+		expectedResult string
+	}{
+		{
+			name: "ok",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/dot_foo": "foo",
+			},
+			expectedPrefix: okPrefix,
+			expectedResult: "no duplicate targets (source state)",
+		},
+		{
+			name: "duplicate_targets",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"dot_foo":         "foo",
+					"private_dot_foo": "foo",
+				},
+			},
+			expectedPrefix: errorPrefix,
+			expectedResult: ".foo: duplicate target from " +
+				filepath.FromSlash("/home/user/.local/share/chezmoi/dot_foo") + " and " +
+				filepath.FromSlash("/home/user/.local/share/chezmoi/private_dot_foo") + " (source state)",
+		},
+		{
+			name: "case_collision",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"dot_Foo": "Foo",
+					"dot_foo": "foo",
+				},
+			},
+			expectedPrefix: warningPrefix,
+			expectedResult: filepath.FromSlash("/home/user/.local/share/chezmoi/dot_Foo") + " and " +
+				filepath.FromSlash("/home/user/.local/share/chezmoi/dot_foo") + " (targets differ only in case)",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			c := newTestConfig(fs)
+			dcr := runDoctorCheck(&doctorTargetsCheck{
+				getTargetState: func() (*chezmoi.TargetState, error) {
+					return c.getTargetState(nil)
+				},
+			})
+			assert.Equal(t, tc.expectedPrefix, dcr.prefix)
+			assert.Equal(t, tc.expectedResult, dcr.result)
+		})
+	}
+}
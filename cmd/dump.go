@@ -26,7 +26,9 @@ func init() {
 	rootCmd.AddCommand(dumpCmd)
 
 	persistentFlags := dumpCmd.PersistentFlags()
+	persistentFlags.StringSliceVarP(&config.exclude, "exclude", "x", config.exclude, "exclude entry types")
 	persistentFlags.StringVarP(&config.dump.format, "format", "f", "json", "format (JSON, TOML, or YAML)")
+	persistentFlags.StringSliceVarP(&config.include, "include", "i", config.include, "include entry types")
 	persistentFlags.BoolVarP(&config.dump.recursive, "recursive", "r", true, "recursive")
 
 	markRemainingZshCompPositionalArgumentsAsFiles(dumpCmd, 1)
@@ -37,13 +39,17 @@ func (c *Config) runDumpCmd(cmd *cobra.Command, args []string) error {
 	if !ok {
 		return fmt.Errorf("%s: unknown format", c.dump.format)
 	}
+	filter, err := c.getEntryTypeFilter()
+	if err != nil {
+		return err
+	}
 	ts, err := c.getTargetState(nil)
 	if err != nil {
 		return err
 	}
 	var concreteValue interface{}
 	if len(args) == 0 {
-		concreteValue, err = ts.ConcreteValue(c.dump.recursive)
+		concreteValue, err = ts.ConcreteValue(filter, c.dump.recursive)
 		if err != nil {
 			return err
 		}
@@ -54,7 +60,7 @@ func (c *Config) runDumpCmd(cmd *cobra.Command, args []string) error {
 		}
 		var concreteValues []interface{}
 		for _, entry := range entries {
-			entryConcreteValue, err := entry.ConcreteValue(ts.TargetIgnore.Match, ts.SourceDir, os.FileMode(c.Umask), c.dump.recursive)
+			entryConcreteValue, err := entry.ConcreteValue(ts.TargetIgnore.Match, filter, ts.EntrySourceDir, os.FileMode(c.Umask), c.dump.recursive)
 			if err != nil {
 				return err
 			}
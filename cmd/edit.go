@@ -41,7 +41,8 @@ func init() {
 
 type encryptedFile struct {
 	index          int
-	file           *chezmoi.File
+	sourceName     string
+	contents       func() ([]byte, error)
 	ciphertextPath string
 	plaintextPath  string
 }
@@ -77,23 +78,34 @@ func (c *Config) runEditCmd(cmd *cobra.Command, args []string) error {
 	}
 
 	// Build a list of source file names to pass to the editor. Check that each
-	// is either a file or a symlink. If the entry is an encrypted file then
-	// remember it.
+	// is either a file, a script, or a symlink. If the entry is an encrypted
+	// file or script then remember it.
 	argv := make([]string, len(entries))
 	var encryptedFiles []encryptedFile
 	for i, entry := range entries {
-		argv[i] = filepath.Join(c.SourceDir, entry.SourceName())
-		if file, ok := entry.(*chezmoi.File); ok {
-			if file.Encrypted {
-				ef := encryptedFile{
-					index:          i,
-					file:           file,
-					ciphertextPath: argv[i],
-				}
+		argv[i], err = c.getEditSourcePath(ts, entry)
+		if err != nil {
+			return err
+		}
+		ef := encryptedFile{
+			index:          i,
+			sourceName:     entry.SourceName(),
+			ciphertextPath: argv[i],
+		}
+		switch entry := entry.(type) {
+		case *chezmoi.File:
+			if entry.Encrypted {
+				ef.contents = entry.Contents
 				encryptedFiles = append(encryptedFiles, ef)
 			}
-		} else if _, ok := entry.(*chezmoi.Symlink); !ok {
-			return fmt.Errorf("%s: not a file or symlink", args[i])
+		case *chezmoi.Script:
+			if entry.Encrypted {
+				ef.contents = entry.Contents
+				encryptedFiles = append(encryptedFiles, ef)
+			}
+		case *chezmoi.Symlink:
+		default:
+			return fmt.Errorf("%s: not a file, script, or symlink", args[i])
 		}
 	}
 
@@ -108,11 +120,11 @@ func (c *Config) runEditCmd(cmd *cobra.Command, args []string) error {
 		defer os.RemoveAll(tempDir)
 		for i := range encryptedFiles {
 			ef := &encryptedFiles[i]
-			plaintext, err := ef.file.Contents()
+			plaintext, err := ef.contents()
 			if err != nil {
 				return err
 			}
-			ef.plaintextPath = filepath.Join(tempDir, ef.file.SourceName())
+			ef.plaintextPath = filepath.Join(tempDir, ef.sourceName)
 			if err := os.MkdirAll(filepath.Dir(ef.plaintextPath), 0o700&^os.FileMode(c.Umask)); err != nil {
 				return err
 			}
@@ -164,6 +176,12 @@ func (c *Config) runEditCmd(cmd *cobra.Command, args []string) error {
 		Verbose:           c.Verbose,
 	}
 	for i, entry := range entries {
+		// Edited scripts are not run, even with --apply. Their contents have no
+		// diff to show, and checking whether a run_once script has already run
+		// would need the persistent state, which editing does not open.
+		if _, ok := entry.(*chezmoi.Script); ok {
+			continue
+		}
 		anyMutator := chezmoi.NewAnyMutator(chezmoi.NullMutator{})
 		var mutator chezmoi.Mutator = anyMutator
 		if c.edit.diff {
@@ -195,3 +213,35 @@ func (c *Config) runEditCmd(cmd *cobra.Command, args []string) error {
 	}
 	return nil
 }
+
+// getEditSourcePath returns the path of the source file of entry to edit. If
+// entry comes from an earlier source directory than ts.SourceDir then its
+// source file is first copied to ts.SourceDir so that edits are written there.
+// Externals are always edited in place.
+func (c *Config) getEditSourcePath(ts *chezmoi.TargetState, entry chezmoi.Entry) (string, error) {
+	entrySourceDir := ts.EntrySourceDir(entry)
+	entrySourcePath := filepath.Join(entrySourceDir, entry.SourceName())
+	if entrySourceDir == ts.SourceDir || chezmoi.IsExternal(entry) {
+		return entrySourcePath, nil
+	}
+	sourcePath := filepath.Join(ts.SourceDir, entry.SourceName())
+	for _, sourceDir := range ts.SourceDirs {
+		switch sourceDir {
+		case entrySourceDir:
+			contents, err := c.fs.ReadFile(entrySourcePath)
+			if err != nil {
+				return "", err
+			}
+			if err := vfs.MkdirAll(c.mutator, filepath.Dir(sourcePath), 0o777&^os.FileMode(c.Umask)); err != nil {
+				return "", err
+			}
+			if err := c.mutator.WriteFile(sourcePath, contents, 0o666&^os.FileMode(c.Umask), nil); err != nil {
+				return "", err
+			}
+			return sourcePath, nil
+		case ts.SourceDir:
+			return "", fmt.Errorf("%s: overridden by %s", sourcePath, entrySourcePath)
+		}
+	}
+	return entrySourcePath, nil
+}
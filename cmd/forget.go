@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
 
 	"github.com/spf13/cobra"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
 var forgetCmd = &cobra.Command{
@@ -34,9 +38,33 @@ func (c *Config) runForgetCmd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	for _, entry := range entries {
-		if err := c.mutator.RemoveAll(filepath.Join(c.SourceDir, entry.SourceName())); err != nil {
+		if chezmoi.IsExternal(entry) {
+			return fmt.Errorf("%s: external, edit %s instead", filepath.Join(c.DestDir, entry.TargetName()), filepath.Join(ts.EntrySourceDir(entry), entry.SourceName()))
+		}
+		if err := c.mutator.RemoveAll(filepath.Join(ts.EntrySourceDir(entry), entry.SourceName())); err != nil {
+			return err
+		}
+		if err := c.forgetTargetMapping(ts, ts.EntrySourceDir(entry), entry.SourceName()); err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+// forgetTargetMapping removes the mapping of sourceName from the .chezmoimap
+// file in sourceDir, if there is one.
+func (c *Config) forgetTargetMapping(ts *chezmoi.TargetState, sourceDir, sourceName string) error {
+	path := filepath.Join(sourceDir, chezmoi.TargetMapName)
+	data, err := c.fs.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		return nil
+	case err != nil:
+		return err
+	}
+	newData, removed := ts.RemoveTargetMapping(data, sourceName)
+	if !removed {
+		return nil
+	}
+	return c.mutator.WriteFile(path, newData, 0o644, data)
+}
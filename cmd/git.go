@@ -19,9 +19,14 @@ func init() {
 }
 
 func (c *Config) runGitCmd(cmd *cobra.Command, args []string) error {
-	name := "git"
+	return c.run(c.SourceDir, c.getGitCommand(), args...)
+}
+
+// getGitCommand returns the git command, which is the source VCS command if it
+// is git.
+func (c *Config) getGitCommand() string {
 	if trimExecutableSuffix(filepath.Base(c.SourceVCS.Command)) == "git" {
-		name = c.SourceVCS.Command
+		return c.SourceVCS.Command
 	}
-	return c.run(c.SourceDir, name, args...)
+	return "git"
 }
@@ -55,10 +55,78 @@ var helps = map[string]help{
 		long: "" +
 			"Description:\n" +
 			"  Ensure that *targets* are in the target state, updating them if necessary.\n" +
-			"  If no targets are specified, the state of all targets are ensured.",
+			"  If no targets are specified, the state of all targets are ensured.\n" +
+			"\n" +
+			"  chezmoi records the contents and permissions of each file and symlink that\n" +
+			"  it writes in its persistent state. If a target has since been changed\n" +
+			"  locally, and its target state has also changed, then `apply` resolves the\n" +
+			"  conflict according to the `--conflict` policy instead of overwriting the local\n" +
+			"  changes. Files with the `create_`, `modify_`, `block_`, or `merge_`\n" +
+			"  attributes are expected to be changed locally and are not checked for\n" +
+			"  conflicts.\n" +
+			"\n" +
+			"  `-x`, `--exclude` *types*\n" +
+			"\n" +
+			"  Exclude entries of type *types*. *types* is a comma-separated list of types\n" +
+			"  of entry, as for `--include`. By default, no entries are excluded.\n" +
+			"\n" +
+			"  `-i`, `--include` *types*\n" +
+			"\n" +
+			"  Only include entries of type *types*. *types* is a comma-separated list of\n" +
+			"  types and attributes of entry. Valid types are `dirs`, `files`, `symlinks`,\n" +
+			"  and `scripts`, and valid attributes are `encrypted`, `templates`, and\n" +
+			"  `once`. `dirs`, `files`, and `symlinks` can be abbreviated to `d`, `f`, and\n" +
+			"  `s` respectively, and `all` and `none` select all or no types. An entry is\n" +
+			"  included if any of its type and attributes are included and none of them are\n" +
+			"  excluded. By default, entries of all types are included.\n" +
+			"\n" +
+			"  If a directory is excluded, then it is not created and its permissions are\n" +
+			"  not changed. If it is exact, entries that are not in the source state are\n" +
+			"  not removed from it. Its included entries are still applied if the directory\n" +
+			"  already exists.\n" +
+			"\n" +
+			"  `--interactive`\n" +
+			"\n" +
+			"  Before each change, show it as a diff in the format set by the `diff.format`\n" +
+			"  configuration variable and ask what to do. The choices are:\n" +
+			"\n" +
+			"  • `o`: make the change.\n" +
+			"  • `s`: skip the change.\n" +
+			"  • `m`: merge the change into the existing file using the `merge.command`.\n" +
+			"  • `q`: stop without making any further changes.\n" +
+			"  • `a`: make this change and all remaining changes.\n" +
+			"  • `n`: skip this change and all remaining changes.\n" +
+			"\n" +
+			"  Further changes to a target after the first are made or skipped without\n" +
+			"  asking again. If the creation of a directory is skipped, then all the\n" +
+			"  changes in it are skipped too. Merging is only offered for changes to\n" +
+			"  existing files. With the default `prompt` conflict policy, conflicts are not\n" +
+			"  asked about separately.\n" +
+			"\n" +
+			"  `--output-format` *format*\n" +
+			"\n" +
+			"  Set the output format, either `text`, the default, or `json`. In `json`\n" +
+			"  format, each change to a target is written to stdout as a JSON object on its\n" +
+			"  own line, with the fields:\n" +
+			"\n" +
+			"  • `path`: the path of the target.\n" +
+			"  • `type`: the type of the target, `dir`, `file`, `symlink`, or `script`.\n" +
+			"  • `action`: the change, `mkdir`, `write`, `chmod`, `remove`, `symlink`,\n" +
+			"  `rename`, or `run-script`.\n" +
+			"  • `old`, `new`: the state of the target before and after the change, if it\n" +
+			"  exists.\n" +
+			"  • `error`: the error, if the change failed.\n" +
+			"\n" +
+			"  States contain the target's `mode` and, for files and symlinks, the SHA256\n" +
+			"  sum of its contents or link target in `contentsSHA256`. Scripts are reported\n" +
+			"  when they would be run, including in dry runs and by `diff` and `verify`,\n" +
+			"  although scripts do not cause `verify` to fail. The standard output of\n" +
+			"  scripts is written to stderr so that stdout only contains JSON.",
 		example: "" +
 			"    chezmoi apply\n" +
 			"    chezmoi apply --dry-run --verbose\n" +
+			"    chezmoi apply --include=files --exclude=encrypted\n" +
+			"    chezmoi apply --interactive\n" +
 			"    chezmoi apply ~/.bashrc",
 	},
 	"archive": {
@@ -67,6 +135,15 @@ var helps = map[string]help{
 			"  Generate a tar archive of the target state. This can be piped into `tar` to\n" +
 			"  inspect the target state.\n" +
 			"\n" +
+			"  `-x`, `--exclude` *types*\n" +
+			"\n" +
+			"  Exclude entries of type *types*, as for `apply`.\n" +
+			"\n" +
+			"  `-i`, `--include` *types*\n" +
+			"\n" +
+			"  Only include entries of type *types*, as for `apply`. Excluded directories\n" +
+			"  are omitted from the archive, but their included entries are not.\n" +
+			"\n" +
 			"  `--output`, `-o` *filename*\n" +
 			"\n" +
 			"  Write the output to *filename* instead of stdout.",
@@ -104,19 +181,36 @@ var helps = map[string]help{
 			"\n" +
 			"    ATTRIBUTE  | ABBREVIATION\n" +
 			"  -------------+---------------\n" +
+			"    create     | none\n" +
 			"    empty      | e\n" +
 			"    encrypted  | none\n" +
 			"    exact      | none\n" +
 			"    executable | x\n" +
 			"    private    | p\n" +
+			"    readonly   | r\n" +
 			"    template   | t\n" +
 			"\n" +
+			"  Explicit permissions are set with `mode=`*octal-mode*, for example\n" +
+			"  `mode=0640`, and removed with `nomode`. Modifying the `executable`,\n" +
+			"  `private`, or `readonly` attributes also removes any explicit permissions.\n" +
+			"\n" +
 			"  Multiple attributes modifications may be specified by separating them with a\n" +
-			"  comma (`,`).",
+			"  comma (`,`).\n" +
+			"\n" +
+			"  Attributes declared in a `.chezmoiattributes` file can only be modified with\n" +
+			"  the `--metadata` flag.\n" +
+			"\n" +
+			"  `--metadata`\n" +
+			"\n" +
+			"  Modify the attributes declared in `.chezmoiattributes` files instead of\n" +
+			"  renaming files in the source state. Attributes that are set by source names\n" +
+			"  cannot be removed with this flag.",
 		example: "" +
 			"    chezmoi chattr template ~/.bashrc\n" +
 			"    chezmoi chattr noempty ~/.profile\n" +
-			"    chezmoi chattr private,template ~/.netrc",
+			"    chezmoi chattr private,template ~/.netrc\n" +
+			"    chezmoi chattr mode=0640 ~/.config/app/secrets.conf\n" +
+			"    chezmoi chattr --metadata private,template ~/.netrc",
 	},
 	"completion": {
 		long: "" +
@@ -134,7 +228,8 @@ var helps = map[string]help{
 	"data": {
 		long: "" +
 			"Description:\n" +
-			"  Write the computed template data in JSON format to stdout. The `data`\n" +
+			"  Write the computed template data in JSON format to stdout, including any\n" +
+			"  data from `.chezmoidata.<format>` files in the source directory. The `data`\n" +
 			"  command accepts additional flags:\n" +
 			"\n" +
 			"  `-f`, `--format` *format*\n" +
@@ -167,13 +262,28 @@ var helps = map[string]help{
 			"\n" +
 			"  ##### `git`\n" +
 			"\n" +
-			"  A git format diff https://git-scm.com/docs/diff-format, excluding scripts. In\n" +
-			"  version 2.0.0 of chezmoi, `git` format diffs will become the default and\n" +
-			"  include scripts and the `chezmoi` format will be removed.\n" +
+			"  A git format diff https://git-scm.com/docs/diff-format. Scripts that would be\n" +
+			"  run are shown as new executable files. In version 2.0.0 of chezmoi, `git`\n" +
+			"  format diffs will become the default and the `chezmoi` format will be\n" +
+			"  removed.\n" +
+			"\n" +
+			"  `-x`, `--exclude` *types*\n" +
+			"\n" +
+			"  Exclude entries of type *types*, as for `apply`.\n" +
+			"\n" +
+			"  `-i`, `--include` *types*\n" +
+			"\n" +
+			"  Only include entries of type *types*, as for `apply`.\n" +
 			"\n" +
 			"  `--no-pager`\n" +
 			"\n" +
-			"  Do not use the pager.",
+			"  Do not use the pager.\n" +
+			"\n" +
+			"  `--output-format` *format*\n" +
+			"\n" +
+			"  Set the output format, either `text`, the default, or `json`. In `json`\n" +
+			"  format, each change that `apply` would make is written as a JSON object in\n" +
+			"  the same format as `apply`, instead of as a diff, and the pager is not used.",
 		example: "" +
 			"    chezmoi diff\n" +
 			"    chezmoi diff ~/.bashrc\n" +
@@ -192,7 +302,9 @@ var helps = map[string]help{
 	"doctor": {
 		long: "" +
 			"Description:\n" +
-			"  Check for potential problems.",
+			"  Check for potential problems. `doctor` also reports source paths that have\n" +
+			"  the same target, and targets that differ only in case, which collide on case-\n" +
+			"  insensitive filesystems.",
 		example: "" +
 			"    chezmoi doctor",
 	},
@@ -202,10 +314,19 @@ var helps = map[string]help{
 			"  Dump the target state in JSON format. If no targets are specified, then the\n" +
 			"  entire target state. The `dump` command accepts additional arguments:\n" +
 			"\n" +
+			"  `-x`, `--exclude` *types*\n" +
+			"\n" +
+			"  Exclude entries of type *types*, as for `apply`.\n" +
+			"\n" +
 			"  `-f`, `--format` *format*\n" +
 			"\n" +
 			"  Print the target state in the given format. The accepted formats are `json`\n" +
-			"  (JSON) and `yaml` (YAML).",
+			"  (JSON) and `yaml` (YAML).\n" +
+			"\n" +
+			"  `-i`, `--include` *types*\n" +
+			"\n" +
+			"  Only include entries of type *types*, as for `apply`. Excluded directories\n" +
+			"  are only dumped if they contain included entries.",
 		example: "" +
 			"    chezmoi dump ~/.bashrc\n" +
 			"    chezmoi dump --format=yaml",
@@ -213,9 +334,11 @@ var helps = map[string]help{
 	"edit": {
 		long: "" +
 			"Description:\n" +
-			"  Edit the source state of *targets*, which must be files or symlinks. If no\n" +
-			"  targets are given the the source directory itself is opened with `$EDITOR`.\n" +
-			"  The `edit` command accepts additional arguments:\n" +
+			"  Edit the source state of *targets*, which must be files, scripts, or\n" +
+			"  symlinks. If no targets are given the the source directory itself is opened\n" +
+			"  with `$EDITOR`. Encrypted files and scripts are decrypted before editing and\n" +
+			"  re-encrypted afterwards. Scripts are never run by `edit`. The `edit` command\n" +
+			"  accepts additional arguments:\n" +
 			"\n" +
 			"  `-a`, `--apply`\n" +
 			"\n" +
@@ -291,7 +414,8 @@ var helps = map[string]help{
 	"forget": {
 		long: "" +
 			"Description:\n" +
-			"  Remove *targets* from the source state, i.e. stop managing them.",
+			"  Remove *targets* from the source state, i.e. stop managing them. If a target\n" +
+			"  is mapped by `.chezmoimap` then its mapping is also removed.",
 		example: "" +
 			"    chezmoi forget ~/.bashrc",
 	},
@@ -385,18 +509,28 @@ var helps = map[string]help{
 			"Description:\n" +
 			"  List all managed entries in the destination directory in alphabetical order.\n" +
 			"\n" +
+			"  `-x`, `--exclude` *types*\n" +
+			"\n" +
+			"  Exclude entries of type *types*, as for `apply`.\n" +
+			"\n" +
+			"  `-f`, `--format` *format*\n" +
+			"\n" +
+			"  Print the target path, source path, and source directory of each entry in\n" +
+			"  the given format instead of just the target path. The accepted formats are\n" +
+			"  `json` (JSON), `toml` (TOML), and `yaml` (YAML).\n" +
+			"\n" +
 			"  `-i`, `--include` *types*\n" +
 			"\n" +
-			"  Only list entries of type *types*. *types* is a comma-separated list of types\n" +
-			"  of entry to include. Valid types are `dirs`, `files`, and `symlinks` which\n" +
-			"  can be abbreviated to `d`, `f`, and `s` respectively. By default, `manage`\n" +
+			"  Only list entries of type *types*, as for `apply`. By default, `managed`\n" +
 			"  will list entries of all types.",
 		example: "" +
 			"    chezmoi managed\n" +
 			"    chezmoi managed --include=files\n" +
 			"    chezmoi managed --include=files,symlinks\n" +
 			"    chezmoi managed -i d\n" +
-			"    chezmoi managed -i d,f",
+			"    chezmoi managed -i d,f\n" +
+			"    chezmoi managed --exclude=encrypted\n" +
+			"    chezmoi managed --format=json",
 	},
 	"merge": {
 		long: "" +
@@ -407,7 +541,11 @@ var helps = map[string]help{
 			"  specified the merge tool is invoked for each target. If the target state\n" +
 			"  cannot be computed (for example if source is a template containing errors or\n" +
 			"  an encrypted file that cannot be decrypted) a two-way merge is performed\n" +
-			"  instead.",
+			"  instead.\n" +
+			"\n" +
+			"  For files with the `block_` attribute, only the managed block of the\n" +
+			"  destination state and the target state are merged, and any changes to the\n" +
+			"  destination state's block are written back to the destination file.",
 		example: "" +
 			"    chezmoi merge ~/.bashrc",
 	},
@@ -433,6 +571,22 @@ var helps = map[string]help{
 			"\n" +
 			"  Remove without prompting.",
 	},
+	"restore": {
+		long: "" +
+			"Description:\n" +
+			"  Restore targets from backups made with the `--backup` flag. With no arguments,\n" +
+			"  list each backup and the targets that it contains. With *backup*, restore\n" +
+			"  all the targets in *backup*. With *backup* and *targets*, restore only\n" +
+			"  *targets*.\n" +
+			"\n" +
+			"  Restored files and symlinks replace the current targets. Restored\n" +
+			"  directories are merged with the current directories, so files created since\n" +
+			"  the backup was made are kept.",
+		example: "" +
+			"    chezmoi restore\n" +
+			"    chezmoi restore 20201017T143000.000Z\n" +
+			"    chezmoi restore 20201017T143000.000Z ~/.bashrc",
+	},
 	"rm": {
 		long: "" +
 			"Description:\n" +
@@ -476,14 +630,83 @@ var helps = map[string]help{
 	"source-path": {
 		long: "" +
 			"Description:\n" +
-			"  Print the path to each target's source state. If no targets are specified\n" +
-			"  then print the source directory.\n" +
+			"  Print the path to each target's source state, in the layer that it came\n" +
+			"  from. If no targets are specified then print the source directory.\n" +
 			"\n" +
 			"  `source-path` examples\n" +
 			"\n" +
 			"    chezmoi source-path\n" +
 			"    chezmoi source-path ~/.bashrc",
 	},
+	"state": {
+		long: "" +
+			"Description:\n" +
+			"  Inspect and edit chezmoi's persistent state, which records the state of\n" +
+			"  targets that chezmoi has written in the `entryState` bucket and the scripts\n" +
+			"  with the `once_` attribute that have been run in the `script` bucket. The\n" +
+			"  subcommands are:\n" +
+			"\n" +
+			"  • `dump` writes all buckets and keys.\n" +
+			"  • `get --bucket` *bucket* `--key` *key* writes the value of *key* in *bucket*.\n" +
+			"  • `delete --bucket` *bucket* `--key` *key* deletes *key* from *bucket*.\n" +
+			"  • `reset --bucket` *bucket* deletes all keys in *bucket*.\n" +
+			"  • `scripts` lists the scripts with the `once_` attribute that have been run.\n" +
+			"  • `export` writes the whole persistent state as JSON.\n" +
+			"  • `import` [*filename*] adds the keys written by `export` from *filename*.\n" +
+			"\n" +
+			"  `import` reads from stdin if no *filename* is given.\n" +
+			"\n" +
+			"  Deleting a script's key from the `script` bucket causes it to be run again\n" +
+			"  by the next `apply`.\n" +
+			"\n" +
+			"  `-f`, `--format` *format*\n" +
+			"\n" +
+			"  Print `dump` and `scripts` output in the given format. The accepted formats\n" +
+			"  are `json` (JSON), `toml` (TOML), and `yaml` (YAML).",
+		example: "" +
+			"    chezmoi state dump\n" +
+			"    chezmoi state get --bucket entryState --key ~/.bashrc\n" +
+			"    chezmoi state scripts --format=yaml\n" +
+			"    chezmoi state delete --bucket script --key \"install.sh:$HASH\"\n" +
+			"    chezmoi state reset --bucket script\n" +
+			"    chezmoi state export > state.json\n" +
+			"    chezmoi state import state.json",
+	},
+	"status": {
+		long: "" +
+			"Description:\n" +
+			"  Print a line for each of *targets* that `apply` would change, similar to\n" +
+			"  `git status --short`. If no targets are specified, the status of all targets\n" +
+			"  is printed. Each line contains two status codes followed by the target's\n" +
+			"  path.\n" +
+			"\n" +
+			"  The first column describes how the target has changed in the destination\n" +
+			"  directory since chezmoi last wrote it, and the second column describes the\n" +
+			"  change that `apply` would make to the target. The codes are:\n" +
+			"\n" +
+			"  • A space: no change.\n" +
+			"  • `A`: the target would be added.\n" +
+			"  • `D`: the target was deleted, or would be deleted.\n" +
+			"  • `M`: the target's contents or permissions were modified, or would be\n" +
+			"  modified.\n" +
+			"  • `T`: the target's type, file, directory, or symlink, would change.\n" +
+			"  • `R`: the script would be run.\n" +
+			"\n" +
+			"  The first column is only set for files and symlinks that chezmoi has\n" +
+			"  written.\n" +
+			"\n" +
+			"  `-x`, `--exclude` *types*\n" +
+			"\n" +
+			"  Exclude entries of type *types*, as for `apply`.\n" +
+			"\n" +
+			"  `-i`, `--include` *types*\n" +
+			"\n" +
+			"  Only include entries of type *types*, as for `apply`.",
+		example: "" +
+			"    chezmoi status\n" +
+			"    chezmoi status ~/.bashrc\n" +
+			"    chezmoi status --exclude=scripts",
+	},
 	"unmanage": {
 		long: "" +
 			"Description:\n" +
@@ -529,9 +752,28 @@ var helps = map[string]help{
 			"Description:\n" +
 			"  Verify that all *targets* match their target state. chezmoi exits with code\n" +
 			"  0 (success) if all targets match their target state, or 1 (failure)\n" +
-			"  otherwise. If no targets are specified then all targets are checked.",
+			"  otherwise. If no targets are specified then all targets are checked. With `--\n" +
+			"  verbose`, each file and symlink that does not match is printed with whether\n" +
+			"  it has changed locally, in the source state, or both since chezmoi last\n" +
+			"  wrote it.\n" +
+			"\n" +
+			"  `-x`, `--exclude` *types*\n" +
+			"\n" +
+			"  Exclude entries of type *types*, as for `apply`.\n" +
+			"\n" +
+			"  `-i`, `--include` *types*\n" +
+			"\n" +
+			"  Only include entries of type *types*, as for `apply`.\n" +
+			"\n" +
+			"  `--output-format` *format*\n" +
+			"\n" +
+			"  Set the output format, either `text`, the default, or `json`. In `json`\n" +
+			"  format, each change that `apply` would make is written as a JSON object in\n" +
+			"  the same format as `apply`, so that the reasons for a failure can be read by\n" +
+			"  other programs.",
 		example: "" +
 			"    chezmoi verify\n" +
+			"    chezmoi verify --output-format=json\n" +
 			"    chezmoi verify ~/.bashrc",
 	},
 }
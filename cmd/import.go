@@ -75,7 +75,7 @@ func (c *Config) runImportCmd(cmd *cobra.Command, args []string) error {
 		entry, err := ts.Get(c.fs, c._import.importTAROptions.DestinationDir)
 		switch {
 		case err == nil:
-			if err := c.mutator.RemoveAll(filepath.Join(c.SourceDir, entry.SourceName())); err != nil {
+			if err := c.mutator.RemoveAll(filepath.Join(ts.EntrySourceDir(entry), entry.SourceName())); err != nil {
 				return err
 			}
 		case os.IsNotExist(err):
@@ -109,9 +109,13 @@ func (c *Config) runInitCmd(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return err
 		}
+		defer persistentState.Close()
 		if err := c.applyArgs(nil, persistentState); err != nil {
 			return err
 		}
+		if err := persistentState.Close(); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -174,8 +178,12 @@ func (c *Config) createConfigFile() error {
 }
 
 func (c *Config) findConfigTemplate() (string, string, string, error) {
+	sourceRootDir, err := c.getSourceRootDir()
+	if err != nil {
+		return "", "", "", err
+	}
 	for _, ext := range viper.SupportedExts {
-		contents, err := c.fs.ReadFile(filepath.Join(c.SourceDir, ".chezmoi."+ext+chezmoi.TemplateSuffix))
+		contents, err := c.fs.ReadFile(filepath.Join(sourceRootDir, ".chezmoi."+ext+chezmoi.TemplateSuffix))
 		switch {
 		case os.IsNotExist(err):
 			continue
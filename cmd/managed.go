@@ -2,8 +2,10 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -21,62 +23,73 @@ var managedCmd = &cobra.Command{
 }
 
 type managedCmdConfig struct {
-	include []string
+	format string
+}
+
+type managedEntry struct {
+	TargetPath string `json:"targetPath" toml:"targetPath" yaml:"targetPath"`
+	SourcePath string `json:"sourcePath" toml:"sourcePath" yaml:"sourcePath"`
+	SourceDir  string `json:"sourceDir" toml:"sourceDir" yaml:"sourceDir"`
 }
 
 func init() {
 	rootCmd.AddCommand(managedCmd)
 
 	persistentFlags := managedCmd.PersistentFlags()
-	persistentFlags.StringSliceVarP(&config.managed.include, "include", "i", []string{"dirs", "files", "symlinks"}, "include")
+	persistentFlags.StringSliceVarP(&config.exclude, "exclude", "x", config.exclude, "exclude entry types")
+	persistentFlags.StringVarP(&config.managed.format, "format", "f", "", "format (JSON, TOML, or YAML)")
+	persistentFlags.StringSliceVarP(&config.include, "include", "i", config.include, "include entry types")
 }
 
 func (c *Config) runManagedCmd(cmd *cobra.Command, args []string) error {
-	ts, err := c.getTargetState(nil)
+	var format func(io.Writer, interface{}) error
+	if c.managed.format != "" {
+		var ok bool
+		format, ok = formatMap[strings.ToLower(c.managed.format)]
+		if !ok {
+			return fmt.Errorf("%s: unknown format", c.managed.format)
+		}
+	}
+
+	filter, err := c.getEntryTypeFilter()
 	if err != nil {
 		return err
 	}
 
-	var (
-		includeDirs     = false
-		includeFiles    = false
-		includeSymlinks = false
-	)
-	for _, what := range c.managed.include {
-		switch what {
-		case "dirs", "d":
-			includeDirs = true
-		case "files", "f":
-			includeFiles = true
-		case "symlinks", "s":
-			includeSymlinks = true
-		default:
-			return fmt.Errorf("unrecognized include: %q", what)
-		}
+	ts, err := c.getTargetState(nil)
+	if err != nil {
+		return err
 	}
 
 	allEntries := ts.AllEntries()
 
-	targetNames := make([]string, 0, len(allEntries))
+	entries := make([]chezmoi.Entry, 0, len(allEntries))
 	for _, entry := range allEntries {
-		if _, ok := entry.(*chezmoi.Dir); ok && !includeDirs {
-			continue
-		}
-		if _, ok := entry.(*chezmoi.File); ok && !includeFiles {
-			continue
-		}
-		if _, ok := entry.(*chezmoi.Symlink); ok && !includeSymlinks {
+		if !filter.IncludeEntry(entry) || ts.TargetIgnore.Match(entry.TargetName()) {
 			continue
 		}
-		targetNames = append(targetNames, entry.TargetName())
+		entries = append(entries, entry)
 	}
 
-	sort.Strings(targetNames)
-	for _, targetName := range targetNames {
-		if ts.TargetIgnore.Match(targetName) {
-			continue
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].TargetName() < entries[j].TargetName()
+	})
+
+	if format != nil {
+		managedEntries := make([]managedEntry, 0, len(entries))
+		for _, entry := range entries {
+			sourceDir := ts.EntrySourceDir(entry)
+			managedEntries = append(managedEntries, managedEntry{
+				TargetPath: filepath.Join(ts.DestDir, entry.TargetName()),
+				SourcePath: filepath.Join(sourceDir, entry.SourceName()),
+				SourceDir:  sourceDir,
+			})
 		}
-		fmt.Fprintln(c.Stdout, filepath.Join(ts.DestDir, targetName))
+		return format(c.Stdout, managedEntries)
+	}
+
+	for _, entry := range entries {
+		fmt.Fprintln(c.Stdout, filepath.Join(ts.DestDir, entry.TargetName()))
 	}
 
 	return nil
@@ -15,6 +15,7 @@ import (
 func TestManagedCmd(t *testing.T) {
 	for _, tc := range []struct {
 		include             []string
+		exclude             []string
 		expectedTargetNames []string
 	}{
 		{
@@ -65,13 +66,21 @@ func TestManagedCmd(t *testing.T) {
 				"/home/user/symlink",
 			},
 		},
+		{
+			include: []string{"all"},
+			exclude: []string{"dirs", "templates"},
+			expectedTargetNames: []string{
+				"/home/user/dir/file1",
+				"/home/user/symlink",
+			},
+		},
 	} {
-		t.Run(strings.Join(tc.include, "_"), func(t *testing.T) {
+		t.Run(strings.Join(append(tc.include, tc.exclude...), "_"), func(t *testing.T) {
 			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
 				"/home/user/.local/share/chezmoi": map[string]interface{}{
-					"dir/file1":        "contents",
-					"dir/subdir/file2": "contents",
-					"symlink_symlink":  "target",
+					"dir/file1":             "contents",
+					"dir/subdir/file2.tmpl": "contents",
+					"symlink_symlink":       "target",
 				},
 			})
 			require.NoError(t, err)
@@ -80,9 +89,7 @@ func TestManagedCmd(t *testing.T) {
 			c := newTestConfig(
 				fs,
 				withStdout(stdout),
-				withManaged(managedCmdConfig{
-					include: tc.include,
-				}),
+				withEntryTypes(tc.include, tc.exclude),
 			)
 			assert.NoError(t, c.runManagedCmd(nil, nil))
 			posixTargetNames, err := extractPOSIXTargetNames(stdout.Bytes())
@@ -112,8 +119,9 @@ func posixify(path string) string {
 	return filepath.ToSlash(strings.TrimPrefix(path, filepath.VolumeName(path)))
 }
 
-func withManaged(managed managedCmdConfig) configOption {
+func withEntryTypes(include, exclude []string) configOption {
 	return func(c *Config) {
-		c.managed = managed
+		c.include = include
+		c.exclude = exclude
 	}
 }
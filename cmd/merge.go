@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -53,7 +54,7 @@ func (c *Config) runMergeCmd(cmd *cobra.Command, args []string) error {
 	defer os.RemoveAll(tempDir)
 
 	for i, entry := range entries {
-		if err := c.runMergeCommand(cmd, args[i], entry, tempDir); err != nil {
+		if err := c.runMergeCommand(cmd, args[i], ts.EntrySourceDir(entry), entry, tempDir); err != nil {
 			return err
 		}
 	}
@@ -61,18 +62,24 @@ func (c *Config) runMergeCmd(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func (c *Config) runMergeCommand(cmd *cobra.Command, arg string, entry chezmoi.Entry, tempDir string) error {
+func (c *Config) runMergeCommand(cmd *cobra.Command, arg, sourceDir string, entry chezmoi.Entry, tempDir string) error {
 	file, ok := entry.(*chezmoi.File)
 	if !ok {
 		return fmt.Errorf("%s: not a file", arg)
 	}
+	if file.External {
+		return fmt.Errorf("%s: external", arg)
+	}
+	if file.Block {
+		return c.runBlockMergeCommand(cmd, arg, sourceDir, file, tempDir)
+	}
 
 	// By default, perform a two-way merge between the destination state and the
 	// source state.
 	args := append(
 		append([]string{}, c.Merge.Args...),
 		filepath.Join(c.DestDir, file.TargetName()),
-		filepath.Join(c.SourceDir, file.SourceName()),
+		filepath.Join(sourceDir, file.SourceName()),
 	)
 
 	// Try to evaluate the target state. If this succeeds, perform a three-way
@@ -95,3 +102,65 @@ func (c *Config) runMergeCommand(cmd *cobra.Command, arg string, entry chezmoi.E
 
 	return nil
 }
+
+// runBlockMergeCommand is like runMergeCommand but merges only the managed
+// block in the destination state. Any changes to the destination state's
+// managed block are written back to the destination file.
+func (c *Config) runBlockMergeCommand(cmd *cobra.Command, arg, sourceDir string, file *chezmoi.File, tempDir string) error {
+	destPath := filepath.Join(c.DestDir, file.TargetName())
+	destContents, err := c.fs.ReadFile(destPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	destBlock, comment, err := chezmoi.ManagedBlock(destContents)
+	if err != nil {
+		return fmt.Errorf("%s: %w", destPath, err)
+	}
+	destBlockPath := filepath.Join(tempDir, filepath.Base(file.TargetName())+".destination")
+	if err := ioutil.WriteFile(destBlockPath, destBlock, 0o600); err != nil {
+		return err
+	}
+
+	args := append(
+		append([]string{}, c.Merge.Args...),
+		destBlockPath,
+		filepath.Join(sourceDir, file.SourceName()),
+	)
+
+	if contents, err := file.Contents(); err != nil {
+		cmd.Printf("warning: %s: cannot evaluate target state: %v\n", arg, err)
+	} else {
+		targetBlock, targetComment, err := chezmoi.ManagedBlock(contents)
+		if err != nil {
+			return fmt.Errorf("%s: %w", arg, err)
+		}
+		if comment == "" {
+			comment = targetComment
+		}
+		targetStatePath := filepath.Join(tempDir, filepath.Base(file.TargetName()))
+		if err := ioutil.WriteFile(targetStatePath, targetBlock, 0o600); err != nil {
+			return err
+		}
+		args = append(args, targetStatePath)
+	}
+
+	if err := c.run("", c.Merge.Command, args...); err != nil {
+		return fmt.Errorf("%s: %w", arg, err)
+	}
+
+	mergedBlock, err := ioutil.ReadFile(destBlockPath)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(mergedBlock, destBlock) {
+		return nil
+	}
+	if comment == "" {
+		comment = "#"
+	}
+	mergedContents, err := chezmoi.ReplaceManagedBlock(destContents, comment, mergedBlock)
+	if err != nil {
+		return fmt.Errorf("%s: %w", destPath, err)
+	}
+	return c.mutator.WriteFile(destPath, mergedContents, file.TargetPerm(os.FileMode(c.Umask)), destContents)
+}
@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 
 	"github.com/spf13/cobra"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
 type removeCmdConfig struct {
@@ -44,7 +46,10 @@ func (c *Config) runRemoveCmd(cmd *cobra.Command, args []string) error {
 	}
 	for _, entry := range entries {
 		destDirPath := filepath.Join(c.DestDir, entry.TargetName())
-		sourceDirPath := filepath.Join(c.SourceDir, entry.SourceName())
+		sourceDirPath := filepath.Join(ts.EntrySourceDir(entry), entry.SourceName())
+		if chezmoi.IsExternal(entry) {
+			return fmt.Errorf("%s: external, edit %s instead", destDirPath, sourceDirPath)
+		}
 		if !c.remove.force {
 			choice, err := c.prompt(fmt.Sprintf("Remove %s and %s", destDirPath, sourceDirPath), "ynqa")
 			if err != nil {
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	vfs "github.com/twpayne/go-vfs"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var restoreCmd = &cobra.Command{
+	Use:     "restore [backup [targets...]]",
+	Args:    cobra.ArbitraryArgs,
+	Short:   "Restore targets from a backup",
+	Long:    mustGetLongHelp("restore"),
+	Example: getExample("restore"),
+	PreRunE: config.ensureNoError,
+	RunE:    config.runRestoreCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(restoreCmd)
+
+	markRemainingZshCompPositionalArgumentsAsFiles(restoreCmd, 2)
+}
+
+func (c *Config) runRestoreCmd(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return c.listBackups()
+	}
+
+	destDir, err := filepath.Abs(c.DestDir)
+	if err != nil {
+		return err
+	}
+	backupDir := filepath.Join(c.BackupDir, args[0])
+	if info, err := c.fs.Stat(backupDir); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s: backup not found", args[0])
+	}
+
+	var relPaths []string
+	if len(args) == 1 {
+		infos, err := c.fs.ReadDir(backupDir)
+		if err != nil {
+			return err
+		}
+		for _, info := range infos {
+			relPaths = append(relPaths, info.Name())
+		}
+	} else {
+		for _, arg := range args[1:] {
+			targetPath, err := filepath.Abs(arg)
+			if err != nil {
+				return err
+			}
+			relPath, err := filepath.Rel(destDir, targetPath)
+			if err != nil || relPath == "." || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+				return fmt.Errorf("%s: not in destination directory (%s)", arg, destDir)
+			}
+			if _, err := c.fs.Lstat(filepath.Join(backupDir, relPath)); err != nil {
+				return fmt.Errorf("%s: not in backup %s", arg, args[0])
+			}
+			relPaths = append(relPaths, relPath)
+		}
+	}
+
+	mutator := c.getBackupMutator(c.mutator, destDir)
+	for _, relPath := range relPaths {
+		if err := chezmoi.RestoreBackup(c.fs, mutator, filepath.Join(backupDir, relPath), filepath.Join(destDir, relPath), os.FileMode(c.Umask)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listBackups prints the name of each backup and the targets that it contains.
+func (c *Config) listBackups() error {
+	destDir, err := filepath.Abs(c.DestDir)
+	if err != nil {
+		return err
+	}
+	infos, err := c.fs.ReadDir(c.BackupDir)
+	switch {
+	case os.IsNotExist(err):
+		return nil
+	case err != nil:
+		return err
+	}
+	var backups []string
+	for _, info := range infos {
+		if info.IsDir() {
+			backups = append(backups, info.Name())
+		}
+	}
+	sort.Strings(backups)
+	for _, backup := range backups {
+		backupDir := filepath.Join(c.BackupDir, backup)
+		if err := vfs.Walk(c.fs, backupDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if path == backupDir {
+				return nil
+			}
+			if info.IsDir() {
+				infos, err := c.fs.ReadDir(path)
+				if err != nil || len(infos) != 0 {
+					return err
+				}
+			}
+			relPath, err := filepath.Rel(backupDir, path)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(c.Stdout, "%s %s\n", backup, filepath.Join(destDir, relPath))
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -58,15 +58,32 @@ func init() {
 
 	persistentFlags := rootCmd.PersistentFlags()
 
+	persistentFlags.BoolVar(&config.Backup, "backup", false, "back up targets before changing them")
+	panicOnError(viper.BindPFlag("backup", persistentFlags.Lookup("backup")))
+
+	persistentFlags.StringVar(&config.BackupDir, "backup-dir", getDefaultBackupDir(config.bds), "backup directory")
+	panicOnError(viper.BindPFlag("backupDir", persistentFlags.Lookup("backup-dir")))
+	panicOnError(rootCmd.MarkPersistentFlagDirname("backup-dir"))
+
+	persistentFlags.StringVar(&config.CacheDir, "cache", getDefaultCacheDir(config.bds), "cache directory")
+	panicOnError(viper.BindPFlag("cache", persistentFlags.Lookup("cache")))
+	panicOnError(rootCmd.MarkPersistentFlagDirname("cache"))
+
 	persistentFlags.StringVarP(&config.configFile, "config", "c", getDefaultConfigFile(config.bds), "config file")
 	panicOnError(rootCmd.MarkPersistentFlagFilename("config"))
 
+	persistentFlags.StringVar(&config.Conflict, "conflict", config.Conflict, "conflict policy (prompt, skip, fail, or overwrite)")
+	panicOnError(viper.BindPFlag("conflict", persistentFlags.Lookup("conflict")))
+
 	persistentFlags.BoolVarP(&config.DryRun, "dry-run", "n", false, "dry run")
 	panicOnError(viper.BindPFlag("dry-run", persistentFlags.Lookup("dry-run")))
 
 	persistentFlags.BoolVar(&config.Follow, "follow", false, "follow symlinks")
 	panicOnError(viper.BindPFlag("follow", persistentFlags.Lookup("follow")))
 
+	persistentFlags.BoolVarP(&config.KeepGoing, "keep-going", "k", false, "keep going as far as possible after an error")
+	panicOnError(viper.BindPFlag("keepGoing", persistentFlags.Lookup("keep-going")))
+
 	persistentFlags.BoolVar(&config.Remove, "remove", false, "remove targets")
 	panicOnError(viper.BindPFlag("remove", persistentFlags.Lookup("remove")))
 
@@ -182,7 +199,12 @@ func (c *Config) persistentPreRunRootE(cmd *cobra.Command, args []string) error
 	}
 
 	c.fs = vfs.OSFS
-	c.mutator = chezmoi.NewFSMutator(config.fs)
+	fsMutator := chezmoi.NewFSMutator(config.fs)
+	if c.outputFormat == "json" {
+		// Keep scripts' output separate from the JSON output.
+		fsMutator.ScriptStdout = c.Stderr
+	}
+	c.mutator = fsMutator
 	if c.DryRun {
 		c.mutator = chezmoi.NullMutator{}
 	}
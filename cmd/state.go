@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var stateCmd = &cobra.Command{
+	Use:     "state",
+	Args:    cobra.NoArgs,
+	Short:   "Manipulate the persistent state",
+	Long:    mustGetLongHelp("state"),
+	Example: getExample("state"),
+}
+
+type stateCmdConfig struct {
+	bucket string
+	format string
+	key    string
+}
+
+func init() {
+	rootCmd.AddCommand(stateCmd)
+
+	persistentFlags := stateCmd.PersistentFlags()
+	persistentFlags.StringVarP(&config.state.format, "format", "f", "json", "format (JSON, TOML, or YAML)")
+}
+
+// stateBuckets returns the names of the buckets in persistentState, in order.
+// chezmoi's own buckets are always included, even if they do not exist yet.
+func (c *Config) stateBuckets(persistentState chezmoi.PersistentState) ([][]byte, error) {
+	buckets, err := persistentState.Buckets()
+	if err != nil {
+		return nil, err
+	}
+	for _, bucket := range [][]byte{c.entryStateBucket, c.scriptStateBucket} {
+		found := false
+		for _, b := range buckets {
+			if bytes.Equal(b, bucket) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			buckets = append(buckets, bucket)
+		}
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		return bytes.Compare(buckets[i], buckets[j]) < 0
+	})
+	return buckets, nil
+}
+
+// stateValue returns value decoded from JSON, or as a string if it is not
+// valid JSON.
+func stateValue(value []byte) interface{} {
+	var result interface{}
+	if err := json.Unmarshal(value, &result); err != nil {
+		return string(value)
+	}
+	return result
+}
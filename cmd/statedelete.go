@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var stateDeleteCmd = &cobra.Command{
+	Use:     "delete",
+	Args:    cobra.NoArgs,
+	Short:   "Delete a key from the persistent state",
+	PreRunE: config.ensureNoError,
+	RunE:    config.runStateDeleteCmd,
+}
+
+func init() {
+	stateCmd.AddCommand(stateDeleteCmd)
+
+	flags := stateDeleteCmd.Flags()
+
+	flags.StringVar(&config.state.bucket, "bucket", "", "bucket")
+	panicOnError(stateDeleteCmd.MarkFlagRequired("bucket"))
+
+	flags.StringVar(&config.state.key, "key", "", "key")
+	panicOnError(stateDeleteCmd.MarkFlagRequired("key"))
+}
+
+func (c *Config) runStateDeleteCmd(cmd *cobra.Command, args []string) error {
+	persistentState, err := c.getPersistentState(nil)
+	if err != nil {
+		return err
+	}
+	defer persistentState.Close()
+
+	if err := persistentState.Delete([]byte(c.state.bucket), []byte(c.state.key)); err != nil {
+		return err
+	}
+	return persistentState.Close()
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+var stateDumpCmd = &cobra.Command{
+	Use:     "dump",
+	Args:    cobra.NoArgs,
+	Short:   "Write all buckets and keys in the persistent state to stdout",
+	PreRunE: config.ensureNoError,
+	RunE:    config.runStateDumpCmd,
+}
+
+func init() {
+	stateCmd.AddCommand(stateDumpCmd)
+}
+
+func (c *Config) runStateDumpCmd(cmd *cobra.Command, args []string) error {
+	format, ok := formatMap[strings.ToLower(c.state.format)]
+	if !ok {
+		return fmt.Errorf("%s: unknown format", c.state.format)
+	}
+	persistentState, err := c.getPersistentState(&bolt.Options{
+		ReadOnly: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer persistentState.Close()
+
+	buckets, err := c.stateBuckets(persistentState)
+	if err != nil {
+		return err
+	}
+	data := make(map[string]map[string]interface{})
+	for _, bucket := range buckets {
+		bucketData := make(map[string]interface{})
+		if err := persistentState.ForEach(bucket, func(k, v []byte) error {
+			bucketData[string(k)] = stateValue(v)
+			return nil
+		}); err != nil {
+			return err
+		}
+		data[string(bucket)] = bucketData
+	}
+	return format(c.Stdout, data)
+}
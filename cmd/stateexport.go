@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var stateExportCmd = &cobra.Command{
+	Use:     "export",
+	Args:    cobra.NoArgs,
+	Short:   "Write the persistent state to stdout in a portable format",
+	PreRunE: config.ensureNoError,
+	RunE:    config.runStateExportCmd,
+}
+
+func init() {
+	stateCmd.AddCommand(stateExportCmd)
+}
+
+func (c *Config) runStateExportCmd(cmd *cobra.Command, args []string) error {
+	persistentState, err := c.getPersistentState(&bolt.Options{
+		ReadOnly: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer persistentState.Close()
+
+	stateData, err := chezmoi.PersistentStateData(persistentState)
+	if err != nil {
+		return err
+	}
+	return chezmoi.EncodeJSON(c.Stdout, stateData)
+}
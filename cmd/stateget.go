@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+)
+
+var stateGetCmd = &cobra.Command{
+	Use:     "get",
+	Args:    cobra.NoArgs,
+	Short:   "Get the value of a key in the persistent state",
+	PreRunE: config.ensureNoError,
+	RunE:    config.runStateGetCmd,
+}
+
+func init() {
+	stateCmd.AddCommand(stateGetCmd)
+
+	flags := stateGetCmd.Flags()
+
+	flags.StringVar(&config.state.bucket, "bucket", "", "bucket")
+	panicOnError(stateGetCmd.MarkFlagRequired("bucket"))
+
+	flags.StringVar(&config.state.key, "key", "", "key")
+	panicOnError(stateGetCmd.MarkFlagRequired("key"))
+}
+
+func (c *Config) runStateGetCmd(cmd *cobra.Command, args []string) error {
+	persistentState, err := c.getPersistentState(&bolt.Options{
+		ReadOnly: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer persistentState.Close()
+
+	value, err := persistentState.Get([]byte(c.state.bucket), []byte(c.state.key))
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		return fmt.Errorf("%s: %s: key not found", c.state.bucket, c.state.key)
+	}
+	_, err = fmt.Fprintf(c.Stdout, "%s\n", value)
+	return err
+}
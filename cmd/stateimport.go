@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var stateImportCmd = &cobra.Command{
+	Use:     "import [filename]",
+	Args:    cobra.MaximumNArgs(1),
+	Short:   "Read a persistent state written by export",
+	PreRunE: config.ensureNoError,
+	RunE:    config.runStateImportCmd,
+}
+
+func init() {
+	stateCmd.AddCommand(stateImportCmd)
+}
+
+func (c *Config) runStateImportCmd(cmd *cobra.Command, args []string) error {
+	var data []byte
+	var err error
+	if len(args) == 0 {
+		data, err = ioutil.ReadAll(c.Stdin)
+	} else {
+		data, err = c.fs.ReadFile(args[0])
+	}
+	if err != nil {
+		return err
+	}
+	var stateData map[string]map[string]string
+	if err := json.Unmarshal(data, &stateData); err != nil {
+		return fmt.Errorf("invalid state: %w", err)
+	}
+
+	persistentState, err := c.getPersistentState(nil)
+	if err != nil {
+		return err
+	}
+	defer persistentState.Close()
+
+	if err := chezmoi.SetPersistentStateData(persistentState, stateData); err != nil {
+		return err
+	}
+	return persistentState.Close()
+}
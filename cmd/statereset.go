@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var stateResetCmd = &cobra.Command{
+	Use:     "reset",
+	Args:    cobra.NoArgs,
+	Short:   "Delete all keys in a bucket of the persistent state",
+	PreRunE: config.ensureNoError,
+	RunE:    config.runStateResetCmd,
+}
+
+func init() {
+	stateCmd.AddCommand(stateResetCmd)
+
+	flags := stateResetCmd.Flags()
+
+	flags.StringVar(&config.state.bucket, "bucket", "", "bucket")
+	panicOnError(stateResetCmd.MarkFlagRequired("bucket"))
+}
+
+func (c *Config) runStateResetCmd(cmd *cobra.Command, args []string) error {
+	persistentState, err := c.getPersistentState(nil)
+	if err != nil {
+		return err
+	}
+	defer persistentState.Close()
+
+	if err := persistentState.DeleteBucket([]byte(c.state.bucket)); err != nil {
+		return err
+	}
+	return persistentState.Close()
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var stateScriptsCmd = &cobra.Command{
+	Use:     "scripts",
+	Args:    cobra.NoArgs,
+	Short:   "List the scripts with the once attribute that have been run",
+	PreRunE: config.ensureNoError,
+	RunE:    config.runStateScriptsCmd,
+}
+
+type stateScript struct {
+	Name       string    `json:"name" yaml:"name"`
+	ExecutedAt time.Time `json:"executedAt" yaml:"executedAt"`
+}
+
+func init() {
+	stateCmd.AddCommand(stateScriptsCmd)
+}
+
+func (c *Config) runStateScriptsCmd(cmd *cobra.Command, args []string) error {
+	format, ok := formatMap[strings.ToLower(c.state.format)]
+	if !ok {
+		return fmt.Errorf("%s: unknown format", c.state.format)
+	}
+	persistentState, err := c.getPersistentState(&bolt.Options{
+		ReadOnly: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer persistentState.Close()
+
+	scripts := make(map[string]stateScript)
+	if err := persistentState.ForEach(c.scriptStateBucket, func(k, v []byte) error {
+		var scriptState chezmoi.ScriptState
+		if err := json.Unmarshal(v, &scriptState); err != nil {
+			return fmt.Errorf("%s: %w", k, err)
+		}
+		scripts[string(k)] = stateScript{
+			Name:       scriptState.Name,
+			ExecutedAt: scriptState.ExecutedAt,
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return format(c.Stdout, scripts)
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	vfs "github.com/twpayne/go-vfs"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var statusCmd = &cobra.Command{
+	Use:     "status [targets...]",
+	Short:   "Show the status of targets",
+	Long:    mustGetLongHelp("status"),
+	Example: getExample("status"),
+	PreRunE: config.ensureNoError,
+	RunE:    config.runStatusCmd,
+}
+
+// A targetStatus is the status of a target. old is the state of the target
+// before its first change and new is its state after its last change.
+type targetStatus struct {
+	drift   chezmoi.Drift
+	changed bool
+	script  bool
+	old     *chezmoi.EntryState
+	new     *chezmoi.EntryState
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+
+	persistentFlags := statusCmd.PersistentFlags()
+	persistentFlags.StringSliceVarP(&config.exclude, "exclude", "x", config.exclude, "exclude entry types")
+	persistentFlags.StringSliceVarP(&config.include, "include", "i", config.include, "include entry types")
+
+	markRemainingZshCompPositionalArgumentsAsFiles(statusCmd, 1)
+}
+
+func (c *Config) runStatusCmd(cmd *cobra.Command, args []string) error {
+	c.DryRun = true // Prevent scripts from running.
+
+	statuses := make(map[string]*targetStatus)
+	getStatus := func(targetPath string) *targetStatus {
+		status, ok := statuses[targetPath]
+		if !ok {
+			status = &targetStatus{}
+			statuses[targetPath] = status
+		}
+		return status
+	}
+
+	c.mutator = chezmoi.NewReportingMutator(chezmoi.NullMutator{}, vfs.NewReadOnlyFS(c.fs), func(report *chezmoi.Report) error {
+		status := getStatus(report.Path)
+		if !status.changed {
+			status.changed = true
+			status.old = report.Old
+		}
+		status.new = report.New
+		return nil
+	})
+	c.reportDrift = func(targetPath string, drift chezmoi.Drift) {
+		getStatus(targetPath).drift = drift
+	}
+	c.reportScript = func(targetPath string, contents []byte) error {
+		getStatus(targetPath).script = true
+		return nil
+	}
+
+	persistentState, err := c.getPersistentState(&bolt.Options{
+		ReadOnly: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer persistentState.Close()
+
+	if err := c.applyArgs(args, persistentState); err != nil {
+		return err
+	}
+
+	targetPaths := make([]string, 0, len(statuses))
+	for targetPath, status := range statuses {
+		if status.script || status.changed && !status.old.Equal(status.new) {
+			targetPaths = append(targetPaths, targetPath)
+		}
+	}
+	sort.Strings(targetPaths)
+	for _, targetPath := range targetPaths {
+		status := statuses[targetPath]
+		fmt.Fprintf(c.Stdout, "%c%c %s\n", status.localCode(), status.targetCode(), targetPath)
+	}
+	return nil
+}
+
+// localCode returns the code for the change to s since chezmoi last wrote it.
+func (s *targetStatus) localCode() byte {
+	switch {
+	case s.drift&chezmoi.DriftLocal == 0:
+		return ' '
+	case s.old == nil:
+		return 'D'
+	default:
+		return 'M'
+	}
+}
+
+// targetCode returns the code for the change that applying s's target state
+// would make.
+func (s *targetStatus) targetCode() byte {
+	switch {
+	case s.script:
+		return 'R'
+	case s.old == nil:
+		return 'A'
+	case s.new == nil:
+		return 'D'
+	case s.old.Mode&os.ModeType != s.new.Mode&os.ModeType:
+		return 'T'
+	default:
+		return 'M'
+	}
+}
@@ -15,7 +15,21 @@ func init() {
 }
 
 func (c *Config) includeFunc(filename string) string {
-	contents, err := c.fs.ReadFile(filepath.Join(c.SourceDir, filename))
+	sourceRootDirs, err := c.getSourceRootDirs()
+	if err != nil {
+		panic(err)
+	}
+	// Prefer files in later source directories.
+	for i := len(sourceRootDirs) - 1; i > 0; i-- {
+		contents, err := c.fs.ReadFile(filepath.Join(sourceRootDirs[i], filename))
+		switch {
+		case err == nil:
+			return string(contents)
+		case !os.IsNotExist(err):
+			panic(err)
+		}
+	}
+	contents, err := c.fs.ReadFile(filepath.Join(sourceRootDirs[0], filename))
 	if err != nil {
 		panic(err)
 	}
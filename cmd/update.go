@@ -63,6 +63,9 @@ func (c *Config) runUpdateCmd(cmd *cobra.Command, args []string) error {
 		if err := c.applyArgs(nil, persistentState); err != nil {
 			return err
 		}
+		if err := persistentState.Close(); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 	bolt "go.etcd.io/bbolt"
 
@@ -19,12 +21,24 @@ var verifyCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(verifyCmd)
 
+	persistentFlags := verifyCmd.PersistentFlags()
+	persistentFlags.StringSliceVarP(&config.exclude, "exclude", "x", config.exclude, "exclude entry types")
+	persistentFlags.StringSliceVarP(&config.include, "include", "i", config.include, "include entry types")
+	persistentFlags.StringVar(&config.outputFormat, "output-format", config.outputFormat, "output format, \"text\" or \"json\"")
+
 	markRemainingZshCompPositionalArgumentsAsFiles(verifyCmd, 1)
 }
 
 func (c *Config) runVerifyCmd(cmd *cobra.Command, args []string) error {
+	c.DryRun = true // Prevent scripts from running.
+
 	mutator := chezmoi.NewAnyMutator(chezmoi.NullMutator{})
 	c.mutator = mutator
+	if c.Verbose && c.outputFormat != "json" {
+		c.reportDrift = func(targetPath string, drift chezmoi.Drift) {
+			fmt.Fprintf(c.Stdout, "%s: %s\n", targetPath, drift)
+		}
+	}
 
 	persistentState, err := c.getPersistentState(&bolt.Options{
 		ReadOnly: true,
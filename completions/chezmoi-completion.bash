@@ -382,6 +382,15 @@ _chezmoi_add()
     flags+=("-r")
     flags+=("--template")
     flags+=("-T")
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -391,6 +400,8 @@ _chezmoi_add()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -402,6 +413,8 @@ _chezmoi_add()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -432,6 +445,24 @@ _chezmoi_apply()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--exclude=")
+    two_word_flags+=("--exclude")
+    two_word_flags+=("-x")
+    flags+=("--include=")
+    two_word_flags+=("--include")
+    two_word_flags+=("-i")
+    flags+=("--interactive")
+    flags+=("--output-format=")
+    two_word_flags+=("--output-format")
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -441,6 +472,8 @@ _chezmoi_apply()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -452,6 +485,8 @@ _chezmoi_apply()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -482,6 +517,12 @@ _chezmoi_archive()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--exclude=")
+    two_word_flags+=("--exclude")
+    two_word_flags+=("-x")
+    flags+=("--include=")
+    two_word_flags+=("--include")
+    two_word_flags+=("-i")
     flags+=("--output=")
     two_word_flags+=("--output")
     flags_with_completion+=("--output")
@@ -489,6 +530,15 @@ _chezmoi_archive()
     two_word_flags+=("-o")
     flags_with_completion+=("-o")
     flags_completion+=("_filedir")
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -498,6 +548,8 @@ _chezmoi_archive()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -509,6 +561,8 @@ _chezmoi_archive()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -539,6 +593,15 @@ _chezmoi_cat()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -548,6 +611,8 @@ _chezmoi_cat()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -559,6 +624,8 @@ _chezmoi_cat()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -589,6 +656,15 @@ _chezmoi_cd()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -598,6 +674,8 @@ _chezmoi_cd()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -609,6 +687,8 @@ _chezmoi_cd()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -639,6 +719,16 @@ _chezmoi_chattr()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--metadata")
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -648,6 +738,8 @@ _chezmoi_chattr()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -659,6 +751,8 @@ _chezmoi_chattr()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -700,6 +794,15 @@ _chezmoi_completion()
     two_word_flags+=("-o")
     flags_with_completion+=("-o")
     flags_completion+=("_filedir")
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -709,6 +812,8 @@ _chezmoi_completion()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -720,6 +825,8 @@ _chezmoi_completion()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -757,6 +864,15 @@ _chezmoi_data()
     flags+=("--format=")
     two_word_flags+=("--format")
     two_word_flags+=("-f")
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -766,6 +882,8 @@ _chezmoi_data()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -777,6 +895,8 @@ _chezmoi_data()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -807,10 +927,27 @@ _chezmoi_diff()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--exclude=")
+    two_word_flags+=("--exclude")
+    two_word_flags+=("-x")
     flags+=("--format=")
     two_word_flags+=("--format")
     two_word_flags+=("-f")
+    flags+=("--include=")
+    two_word_flags+=("--include")
+    two_word_flags+=("-i")
     flags+=("--no-pager")
+    flags+=("--output-format=")
+    two_word_flags+=("--output-format")
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -820,6 +957,8 @@ _chezmoi_diff()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -831,6 +970,8 @@ _chezmoi_diff()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -861,6 +1002,15 @@ _chezmoi_docs()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -870,6 +1020,8 @@ _chezmoi_docs()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -881,6 +1033,8 @@ _chezmoi_docs()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -911,6 +1065,15 @@ _chezmoi_doctor()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -920,6 +1083,8 @@ _chezmoi_doctor()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -931,6 +1096,8 @@ _chezmoi_doctor()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -961,11 +1128,26 @@ _chezmoi_dump()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--exclude=")
+    two_word_flags+=("--exclude")
+    two_word_flags+=("-x")
     flags+=("--format=")
     two_word_flags+=("--format")
     two_word_flags+=("-f")
+    flags+=("--include=")
+    two_word_flags+=("--include")
+    two_word_flags+=("-i")
     flags+=("--recursive")
     flags+=("-r")
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -975,6 +1157,8 @@ _chezmoi_dump()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -986,6 +1170,8 @@ _chezmoi_dump()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1022,6 +1208,15 @@ _chezmoi_edit()
     flags+=("-d")
     flags+=("--prompt")
     flags+=("-p")
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -1031,6 +1226,8 @@ _chezmoi_edit()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1042,6 +1239,8 @@ _chezmoi_edit()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1072,6 +1271,15 @@ _chezmoi_edit-config()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -1081,6 +1289,8 @@ _chezmoi_edit-config()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1092,6 +1302,8 @@ _chezmoi_edit-config()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1134,6 +1346,15 @@ _chezmoi_execute-template()
     flags+=("--promptString=")
     two_word_flags+=("--promptString")
     two_word_flags+=("-p")
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -1143,6 +1364,8 @@ _chezmoi_execute-template()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1154,6 +1377,8 @@ _chezmoi_execute-template()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1184,6 +1409,15 @@ _chezmoi_forget()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -1193,6 +1427,8 @@ _chezmoi_forget()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1204,6 +1440,8 @@ _chezmoi_forget()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1234,6 +1472,15 @@ _chezmoi_git()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -1243,6 +1490,8 @@ _chezmoi_git()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1254,6 +1503,8 @@ _chezmoi_git()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1284,6 +1535,15 @@ _chezmoi_help()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -1293,6 +1553,8 @@ _chezmoi_help()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1304,6 +1566,8 @@ _chezmoi_help()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1334,6 +1598,15 @@ _chezmoi_hg()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -1343,6 +1616,8 @@ _chezmoi_hg()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1354,6 +1629,8 @@ _chezmoi_hg()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1390,6 +1667,15 @@ _chezmoi_import()
     flags+=("-r")
     flags+=("--strip-components=")
     two_word_flags+=("--strip-components")
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -1399,6 +1685,8 @@ _chezmoi_import()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1410,6 +1698,8 @@ _chezmoi_import()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1441,6 +1731,15 @@ _chezmoi_init()
     flags_completion=()
 
     flags+=("--apply")
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -1450,6 +1749,8 @@ _chezmoi_init()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1461,6 +1762,8 @@ _chezmoi_init()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1491,9 +1794,24 @@ _chezmoi_managed()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--exclude=")
+    two_word_flags+=("--exclude")
+    two_word_flags+=("-x")
+    flags+=("--format=")
+    two_word_flags+=("--format")
+    two_word_flags+=("-f")
     flags+=("--include=")
     two_word_flags+=("--include")
     two_word_flags+=("-i")
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -1503,6 +1821,8 @@ _chezmoi_managed()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1514,6 +1834,8 @@ _chezmoi_managed()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1544,6 +1866,15 @@ _chezmoi_merge()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -1553,6 +1884,8 @@ _chezmoi_merge()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1564,6 +1897,8 @@ _chezmoi_merge()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1596,6 +1931,15 @@ _chezmoi_purge()
 
     flags+=("--force")
     flags+=("-f")
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -1605,6 +1949,8 @@ _chezmoi_purge()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1616,6 +1962,8 @@ _chezmoi_purge()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1648,6 +1996,15 @@ _chezmoi_remove()
 
     flags+=("--force")
     flags+=("-f")
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -1657,6 +2014,8 @@ _chezmoi_remove()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1668,6 +2027,71 @@ _chezmoi_remove()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    flags_with_completion+=("--source")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-S")
+    flags_with_completion+=("-S")
+    flags_completion+=("_filedir -d")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_restore()
+{
+    last_command="chezmoi_restore"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    flags_with_completion+=("--config")
+    flags_completion+=("_filedir")
+    two_word_flags+=("-c")
+    flags_with_completion+=("-c")
+    flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    flags_with_completion+=("--destination")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-D")
+    flags_with_completion+=("-D")
+    flags_completion+=("_filedir -d")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1698,6 +2122,15 @@ _chezmoi_secret_bitwarden()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -1707,6 +2140,8 @@ _chezmoi_secret_bitwarden()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1718,6 +2153,8 @@ _chezmoi_secret_bitwarden()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1748,6 +2185,15 @@ _chezmoi_secret_generic()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -1757,6 +2203,8 @@ _chezmoi_secret_generic()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1768,6 +2216,8 @@ _chezmoi_secret_generic()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1798,6 +2248,15 @@ _chezmoi_secret_gopass()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -1807,6 +2266,8 @@ _chezmoi_secret_gopass()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1818,6 +2279,8 @@ _chezmoi_secret_gopass()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1848,6 +2311,15 @@ _chezmoi_secret_keepassxc()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -1857,6 +2329,8 @@ _chezmoi_secret_keepassxc()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1868,6 +2342,8 @@ _chezmoi_secret_keepassxc()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -1898,6 +2374,15 @@ _chezmoi_secret_keyring_get()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -1907,6 +2392,8 @@ _chezmoi_secret_keyring_get()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1918,6 +2405,8 @@ _chezmoi_secret_keyring_get()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--service=")
     two_word_flags+=("--service")
@@ -1954,6 +2443,15 @@ _chezmoi_secret_keyring_set()
 
     flags+=("--password=")
     two_word_flags+=("--password")
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -1963,6 +2461,8 @@ _chezmoi_secret_keyring_set()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -1974,6 +2474,8 @@ _chezmoi_secret_keyring_set()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--service=")
     two_word_flags+=("--service")
@@ -2014,6 +2516,15 @@ _chezmoi_secret_keyring()
     two_word_flags+=("--service")
     flags+=("--user=")
     two_word_flags+=("--user")
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -2023,6 +2534,8 @@ _chezmoi_secret_keyring()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -2034,6 +2547,8 @@ _chezmoi_secret_keyring()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -2066,6 +2581,15 @@ _chezmoi_secret_lastpass()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -2075,6 +2599,8 @@ _chezmoi_secret_lastpass()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -2086,6 +2612,8 @@ _chezmoi_secret_lastpass()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -2116,6 +2644,15 @@ _chezmoi_secret_onepassword()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -2125,6 +2662,8 @@ _chezmoi_secret_onepassword()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -2136,6 +2675,8 @@ _chezmoi_secret_onepassword()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -2166,6 +2707,15 @@ _chezmoi_secret_pass()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -2175,6 +2725,8 @@ _chezmoi_secret_pass()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -2186,6 +2738,8 @@ _chezmoi_secret_pass()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -2216,6 +2770,15 @@ _chezmoi_secret_vault()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -2225,6 +2788,8 @@ _chezmoi_secret_vault()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -2236,6 +2801,8 @@ _chezmoi_secret_vault()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -2275,6 +2842,15 @@ _chezmoi_secret()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -2284,6 +2860,8 @@ _chezmoi_secret()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -2295,6 +2873,8 @@ _chezmoi_secret()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -2325,6 +2905,15 @@ _chezmoi_source()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -2334,6 +2923,8 @@ _chezmoi_source()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -2345,6 +2936,8 @@ _chezmoi_source()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -2375,6 +2968,15 @@ _chezmoi_source-path()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -2384,6 +2986,8 @@ _chezmoi_source-path()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -2395,6 +2999,8 @@ _chezmoi_source-path()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -2411,9 +3017,9 @@ _chezmoi_source-path()
     noun_aliases=()
 }
 
-_chezmoi_unmanaged()
+_chezmoi_state_delete()
 {
-    last_command="chezmoi_unmanaged"
+    last_command="chezmoi_state_delete"
 
     command_aliases=()
 
@@ -2425,6 +3031,23 @@ _chezmoi_unmanaged()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--bucket=")
+    two_word_flags+=("--bucket")
+    local_nonpersistent_flags+=("--bucket")
+    local_nonpersistent_flags+=("--bucket=")
+    flags+=("--key=")
+    two_word_flags+=("--key")
+    local_nonpersistent_flags+=("--key")
+    local_nonpersistent_flags+=("--key=")
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -2434,6 +3057,8 @@ _chezmoi_unmanaged()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -2445,6 +3070,11 @@ _chezmoi_unmanaged()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--format=")
+    two_word_flags+=("--format")
+    two_word_flags+=("-f")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -2457,13 +3087,15 @@ _chezmoi_unmanaged()
     flags+=("-v")
 
     must_have_one_flag=()
+    must_have_one_flag+=("--bucket=")
+    must_have_one_flag+=("--key=")
     must_have_one_noun=()
     noun_aliases=()
 }
 
-_chezmoi_update()
+_chezmoi_state_dump()
 {
-    last_command="chezmoi_update"
+    last_command="chezmoi_state_dump"
 
     command_aliases=()
 
@@ -2475,8 +3107,15 @@ _chezmoi_update()
     flags_with_completion=()
     flags_completion=()
 
-    flags+=("--apply")
-    flags+=("-a")
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -2486,6 +3125,8 @@ _chezmoi_update()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -2497,6 +3138,11 @@ _chezmoi_update()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--format=")
+    two_word_flags+=("--format")
+    two_word_flags+=("-f")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -2513,9 +3159,9 @@ _chezmoi_update()
     noun_aliases=()
 }
 
-_chezmoi_upgrade()
+_chezmoi_state_export()
 {
-    last_command="chezmoi_upgrade"
+    last_command="chezmoi_state_export"
 
     command_aliases=()
 
@@ -2527,17 +3173,15 @@ _chezmoi_upgrade()
     flags_with_completion=()
     flags_completion=()
 
-    flags+=("--force")
-    flags+=("-f")
-    flags+=("--method=")
-    two_word_flags+=("--method")
-    two_word_flags+=("-m")
-    flags+=("--owner=")
-    two_word_flags+=("--owner")
-    two_word_flags+=("-o")
-    flags+=("--repo=")
-    two_word_flags+=("--repo")
-    two_word_flags+=("-r")
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -2547,6 +3191,8 @@ _chezmoi_upgrade()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -2558,6 +3204,11 @@ _chezmoi_upgrade()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--format=")
+    two_word_flags+=("--format")
+    two_word_flags+=("-f")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -2574,9 +3225,9 @@ _chezmoi_upgrade()
     noun_aliases=()
 }
 
-_chezmoi_verify()
+_chezmoi_state_get()
 {
-    last_command="chezmoi_verify"
+    last_command="chezmoi_state_get"
 
     command_aliases=()
 
@@ -2588,6 +3239,23 @@ _chezmoi_verify()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--bucket=")
+    two_word_flags+=("--bucket")
+    local_nonpersistent_flags+=("--bucket")
+    local_nonpersistent_flags+=("--bucket=")
+    flags+=("--key=")
+    two_word_flags+=("--key")
+    local_nonpersistent_flags+=("--key")
+    local_nonpersistent_flags+=("--key=")
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -2597,6 +3265,8 @@ _chezmoi_verify()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -2608,6 +3278,11 @@ _chezmoi_verify()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--format=")
+    two_word_flags+=("--format")
+    two_word_flags+=("-f")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -2620,61 +3295,19 @@ _chezmoi_verify()
     flags+=("-v")
 
     must_have_one_flag=()
+    must_have_one_flag+=("--bucket=")
+    must_have_one_flag+=("--key=")
     must_have_one_noun=()
     noun_aliases=()
 }
 
-_chezmoi_root_command()
+_chezmoi_state_import()
 {
-    last_command="chezmoi"
+    last_command="chezmoi_state_import"
 
     command_aliases=()
 
     commands=()
-    commands+=("add")
-    if [[ -z "${BASH_VERSION}" || "${BASH_VERSINFO[0]}" -gt 3 ]]; then
-        command_aliases+=("manage")
-        aliashash["manage"]="add"
-    fi
-    commands+=("apply")
-    commands+=("archive")
-    commands+=("cat")
-    commands+=("cd")
-    commands+=("chattr")
-    commands+=("completion")
-    commands+=("data")
-    commands+=("diff")
-    commands+=("docs")
-    commands+=("doctor")
-    commands+=("dump")
-    commands+=("edit")
-    commands+=("edit-config")
-    commands+=("execute-template")
-    commands+=("forget")
-    if [[ -z "${BASH_VERSION}" || "${BASH_VERSINFO[0]}" -gt 3 ]]; then
-        command_aliases+=("unmanage")
-        aliashash["unmanage"]="forget"
-    fi
-    commands+=("git")
-    commands+=("help")
-    commands+=("hg")
-    commands+=("import")
-    commands+=("init")
-    commands+=("managed")
-    commands+=("merge")
-    commands+=("purge")
-    commands+=("remove")
-    if [[ -z "${BASH_VERSION}" || "${BASH_VERSINFO[0]}" -gt 3 ]]; then
-        command_aliases+=("rm")
-        aliashash["rm"]="remove"
-    fi
-    commands+=("secret")
-    commands+=("source")
-    commands+=("source-path")
-    commands+=("unmanaged")
-    commands+=("update")
-    commands+=("upgrade")
-    commands+=("verify")
 
     flags=()
     two_word_flags=()
@@ -2682,6 +3315,680 @@ _chezmoi_root_command()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    flags_with_completion+=("--config")
+    flags_completion+=("_filedir")
+    two_word_flags+=("-c")
+    flags_with_completion+=("-c")
+    flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    flags_with_completion+=("--destination")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-D")
+    flags_with_completion+=("-D")
+    flags_completion+=("_filedir -d")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--format=")
+    two_word_flags+=("--format")
+    two_word_flags+=("-f")
+    flags+=("--keep-going")
+    flags+=("-k")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    flags_with_completion+=("--source")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-S")
+    flags_with_completion+=("-S")
+    flags_completion+=("_filedir -d")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_state_reset()
+{
+    last_command="chezmoi_state_reset"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--bucket=")
+    two_word_flags+=("--bucket")
+    local_nonpersistent_flags+=("--bucket")
+    local_nonpersistent_flags+=("--bucket=")
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    flags_with_completion+=("--config")
+    flags_completion+=("_filedir")
+    two_word_flags+=("-c")
+    flags_with_completion+=("-c")
+    flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    flags_with_completion+=("--destination")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-D")
+    flags_with_completion+=("-D")
+    flags_completion+=("_filedir -d")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--format=")
+    two_word_flags+=("--format")
+    two_word_flags+=("-f")
+    flags+=("--keep-going")
+    flags+=("-k")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    flags_with_completion+=("--source")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-S")
+    flags_with_completion+=("-S")
+    flags_completion+=("_filedir -d")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_flag+=("--bucket=")
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_state_scripts()
+{
+    last_command="chezmoi_state_scripts"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    flags_with_completion+=("--config")
+    flags_completion+=("_filedir")
+    two_word_flags+=("-c")
+    flags_with_completion+=("-c")
+    flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    flags_with_completion+=("--destination")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-D")
+    flags_with_completion+=("-D")
+    flags_completion+=("_filedir -d")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--format=")
+    two_word_flags+=("--format")
+    two_word_flags+=("-f")
+    flags+=("--keep-going")
+    flags+=("-k")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    flags_with_completion+=("--source")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-S")
+    flags_with_completion+=("-S")
+    flags_completion+=("_filedir -d")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_state()
+{
+    last_command="chezmoi_state"
+
+    command_aliases=()
+
+    commands=()
+    commands+=("delete")
+    commands+=("dump")
+    commands+=("export")
+    commands+=("get")
+    commands+=("import")
+    commands+=("reset")
+    commands+=("scripts")
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--format=")
+    two_word_flags+=("--format")
+    two_word_flags+=("-f")
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    flags_with_completion+=("--config")
+    flags_completion+=("_filedir")
+    two_word_flags+=("-c")
+    flags_with_completion+=("-c")
+    flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    flags_with_completion+=("--destination")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-D")
+    flags_with_completion+=("-D")
+    flags_completion+=("_filedir -d")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    flags_with_completion+=("--source")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-S")
+    flags_with_completion+=("-S")
+    flags_completion+=("_filedir -d")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_status()
+{
+    last_command="chezmoi_status"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--exclude=")
+    two_word_flags+=("--exclude")
+    two_word_flags+=("-x")
+    flags+=("--include=")
+    two_word_flags+=("--include")
+    two_word_flags+=("-i")
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    flags_with_completion+=("--config")
+    flags_completion+=("_filedir")
+    two_word_flags+=("-c")
+    flags_with_completion+=("-c")
+    flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    flags_with_completion+=("--destination")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-D")
+    flags_with_completion+=("-D")
+    flags_completion+=("_filedir -d")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    flags_with_completion+=("--source")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-S")
+    flags_with_completion+=("-S")
+    flags_completion+=("_filedir -d")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_unmanaged()
+{
+    last_command="chezmoi_unmanaged"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    flags_with_completion+=("--config")
+    flags_completion+=("_filedir")
+    two_word_flags+=("-c")
+    flags_with_completion+=("-c")
+    flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    flags_with_completion+=("--destination")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-D")
+    flags_with_completion+=("-D")
+    flags_completion+=("_filedir -d")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    flags_with_completion+=("--source")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-S")
+    flags_with_completion+=("-S")
+    flags_completion+=("_filedir -d")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_update()
+{
+    last_command="chezmoi_update"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--apply")
+    flags+=("-a")
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    flags_with_completion+=("--config")
+    flags_completion+=("_filedir")
+    two_word_flags+=("-c")
+    flags_with_completion+=("-c")
+    flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    flags_with_completion+=("--destination")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-D")
+    flags_with_completion+=("-D")
+    flags_completion+=("_filedir -d")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    flags_with_completion+=("--source")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-S")
+    flags_with_completion+=("-S")
+    flags_completion+=("_filedir -d")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_upgrade()
+{
+    last_command="chezmoi_upgrade"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--force")
+    flags+=("-f")
+    flags+=("--method=")
+    two_word_flags+=("--method")
+    two_word_flags+=("-m")
+    flags+=("--owner=")
+    two_word_flags+=("--owner")
+    two_word_flags+=("-o")
+    flags+=("--repo=")
+    two_word_flags+=("--repo")
+    two_word_flags+=("-r")
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    flags_with_completion+=("--config")
+    flags_completion+=("_filedir")
+    two_word_flags+=("-c")
+    flags_with_completion+=("-c")
+    flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    flags_with_completion+=("--destination")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-D")
+    flags_with_completion+=("-D")
+    flags_completion+=("_filedir -d")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    flags_with_completion+=("--source")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-S")
+    flags_with_completion+=("-S")
+    flags_completion+=("_filedir -d")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_verify()
+{
+    last_command="chezmoi_verify"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--exclude=")
+    two_word_flags+=("--exclude")
+    two_word_flags+=("-x")
+    flags+=("--include=")
+    two_word_flags+=("--include")
+    two_word_flags+=("-i")
+    flags+=("--output-format=")
+    two_word_flags+=("--output-format")
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    flags_with_completion+=("--config")
+    flags_completion+=("_filedir")
+    two_word_flags+=("-c")
+    flags_with_completion+=("-c")
+    flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    flags_with_completion+=("--destination")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-D")
+    flags_with_completion+=("-D")
+    flags_completion+=("_filedir -d")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
+    flags+=("--remove")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    flags_with_completion+=("--source")
+    flags_completion+=("_filedir -d")
+    two_word_flags+=("-S")
+    flags_with_completion+=("-S")
+    flags_completion+=("_filedir -d")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_root_command()
+{
+    last_command="chezmoi"
+
+    command_aliases=()
+
+    commands=()
+    commands+=("add")
+    if [[ -z "${BASH_VERSION}" || "${BASH_VERSINFO[0]}" -gt 3 ]]; then
+        command_aliases+=("manage")
+        aliashash["manage"]="add"
+    fi
+    commands+=("apply")
+    commands+=("archive")
+    commands+=("cat")
+    commands+=("cd")
+    commands+=("chattr")
+    commands+=("completion")
+    commands+=("data")
+    commands+=("diff")
+    commands+=("docs")
+    commands+=("doctor")
+    commands+=("dump")
+    commands+=("edit")
+    commands+=("edit-config")
+    commands+=("execute-template")
+    commands+=("forget")
+    if [[ -z "${BASH_VERSION}" || "${BASH_VERSINFO[0]}" -gt 3 ]]; then
+        command_aliases+=("unmanage")
+        aliashash["unmanage"]="forget"
+    fi
+    commands+=("git")
+    commands+=("help")
+    commands+=("hg")
+    commands+=("import")
+    commands+=("init")
+    commands+=("managed")
+    commands+=("merge")
+    commands+=("purge")
+    commands+=("remove")
+    if [[ -z "${BASH_VERSION}" || "${BASH_VERSINFO[0]}" -gt 3 ]]; then
+        command_aliases+=("rm")
+        aliashash["rm"]="remove"
+    fi
+    commands+=("restore")
+    commands+=("secret")
+    commands+=("source")
+    commands+=("source-path")
+    commands+=("state")
+    commands+=("status")
+    commands+=("unmanaged")
+    commands+=("update")
+    commands+=("upgrade")
+    commands+=("verify")
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--backup")
+    flags+=("--backup-dir=")
+    two_word_flags+=("--backup-dir")
+    flags_with_completion+=("--backup-dir")
+    flags_completion+=("_filedir -d")
+    flags+=("--cache=")
+    two_word_flags+=("--cache")
+    flags_with_completion+=("--cache")
+    flags_completion+=("_filedir -d")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -2691,6 +3998,8 @@ _chezmoi_root_command()
     two_word_flags+=("-c")
     flags_with_completion+=("-c")
     flags_completion+=("_filedir")
+    flags+=("--conflict=")
+    two_word_flags+=("--conflict")
     flags+=("--debug")
     flags+=("--destination=")
     two_word_flags+=("--destination")
@@ -2702,6 +4011,8 @@ _chezmoi_root_command()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--keep-going")
+    flags+=("-k")
     flags+=("--remove")
     flags+=("--source=")
     two_word_flags+=("--source")
@@ -17,15 +17,21 @@ Register-ArgumentCompleter -Native -CommandName 'chezmoi' -ScriptBlock {
     ) -join ';'
     $completions = @(switch ($command) {
         'chezmoi' {
+            [CompletionResult]::new('--backup', 'backup', [CompletionResultType]::ParameterName, 'back up targets before changing them')
+            [CompletionResult]::new('--backup-dir', 'backup-dir', [CompletionResultType]::ParameterName, 'backup directory')
+            [CompletionResult]::new('--cache', 'cache', [CompletionResultType]::ParameterName, 'cache directory')
             [CompletionResult]::new('--color', 'color', [CompletionResultType]::ParameterName, 'colorize diffs')
             [CompletionResult]::new('-c', 'c', [CompletionResultType]::ParameterName, 'config file')
             [CompletionResult]::new('--config', 'config', [CompletionResultType]::ParameterName, 'config file')
+            [CompletionResult]::new('--conflict', 'conflict', [CompletionResultType]::ParameterName, 'conflict policy (prompt, skip, fail, or overwrite)')
             [CompletionResult]::new('--debug', 'debug', [CompletionResultType]::ParameterName, 'write debug logs')
             [CompletionResult]::new('-D', 'D', [CompletionResultType]::ParameterName, 'destination directory')
             [CompletionResult]::new('--destination', 'destination', [CompletionResultType]::ParameterName, 'destination directory')
             [CompletionResult]::new('-n', 'n', [CompletionResultType]::ParameterName, 'dry run')
             [CompletionResult]::new('--dry-run', 'dry-run', [CompletionResultType]::ParameterName, 'dry run')
             [CompletionResult]::new('--follow', 'follow', [CompletionResultType]::ParameterName, 'follow symlinks')
+            [CompletionResult]::new('-k', 'k', [CompletionResultType]::ParameterName, 'keep going as far as possible after an error')
+            [CompletionResult]::new('--keep-going', 'keep-going', [CompletionResultType]::ParameterName, 'keep going as far as possible after an error')
             [CompletionResult]::new('--remove', 'remove', [CompletionResultType]::ParameterName, 'remove targets')
             [CompletionResult]::new('-S', 'S', [CompletionResultType]::ParameterName, 'source directory')
             [CompletionResult]::new('--source', 'source', [CompletionResultType]::ParameterName, 'source directory')
@@ -56,9 +62,12 @@ Register-ArgumentCompleter -Native -CommandName 'chezmoi' -ScriptBlock {
             [CompletionResult]::new('merge', 'merge', [CompletionResultType]::ParameterValue, 'Perform a three-way merge between the destination state, the source state, and the target state')
             [CompletionResult]::new('purge', 'purge', [CompletionResultType]::ParameterValue, 'Purge all of chezmoi''s configuration and data')
             [CompletionResult]::new('remove', 'remove', [CompletionResultType]::ParameterValue, 'Remove a target from the source state and the destination directory')
+            [CompletionResult]::new('restore', 'restore', [CompletionResultType]::ParameterValue, 'Restore targets from a backup')
             [CompletionResult]::new('secret', 'secret', [CompletionResultType]::ParameterValue, 'Interact with a secret manager')
             [CompletionResult]::new('source', 'source', [CompletionResultType]::ParameterValue, 'Run the source version control system command in the source directory')
             [CompletionResult]::new('source-path', 'source-path', [CompletionResultType]::ParameterValue, 'Print the path of a target in the source state')
+            [CompletionResult]::new('state', 'state', [CompletionResultType]::ParameterValue, 'Manipulate the persistent state')
+            [CompletionResult]::new('status', 'status', [CompletionResultType]::ParameterValue, 'Show the status of targets')
             [CompletionResult]::new('unmanaged', 'unmanaged', [CompletionResultType]::ParameterValue, 'List the unmanaged files in the destination directory')
             [CompletionResult]::new('update', 'update', [CompletionResultType]::ParameterValue, 'Pull changes from the source VCS and apply any changes')
             [CompletionResult]::new('upgrade', 'upgrade', [CompletionResultType]::ParameterValue, 'Upgrade chezmoi to the latest released version')
@@ -84,9 +93,13 @@ Register-ArgumentCompleter -Native -CommandName 'chezmoi' -ScriptBlock {
             break
         }
         'chezmoi;completion' {
+            [CompletionResult]::new('--backup', 'backup', [CompletionResultType]::ParameterName, 'back up targets before changing them')
+            [CompletionResult]::new('--backup-dir', 'backup-dir', [CompletionResultType]::ParameterName, 'backup directory')
+            [CompletionResult]::new('--cache', 'cache', [CompletionResultType]::ParameterName, 'cache directory')
             [CompletionResult]::new('--color', 'color', [CompletionResultType]::ParameterName, 'colorize diffs')
             [CompletionResult]::new('-c', 'c', [CompletionResultType]::ParameterName, 'config file')
             [CompletionResult]::new('--config', 'config', [CompletionResultType]::ParameterName, 'config file')
+            [CompletionResult]::new('--conflict', 'conflict', [CompletionResultType]::ParameterName, 'conflict policy (prompt, skip, fail, or overwrite)')
             [CompletionResult]::new('--debug', 'debug', [CompletionResultType]::ParameterName, 'write debug logs')
             [CompletionResult]::new('-D', 'D', [CompletionResultType]::ParameterName, 'destination directory')
             [CompletionResult]::new('--destination', 'destination', [CompletionResultType]::ParameterName, 'destination directory')
@@ -95,6 +108,8 @@ Register-ArgumentCompleter -Native -CommandName 'chezmoi' -ScriptBlock {
             [CompletionResult]::new('--follow', 'follow', [CompletionResultType]::ParameterName, 'follow symlinks')
             [CompletionResult]::new('-h', 'h', [CompletionResultType]::ParameterName, 'help for completion')
             [CompletionResult]::new('--help', 'help', [CompletionResultType]::ParameterName, 'help for completion')
+            [CompletionResult]::new('-k', 'k', [CompletionResultType]::ParameterName, 'keep going as far as possible after an error')
+            [CompletionResult]::new('--keep-going', 'keep-going', [CompletionResultType]::ParameterName, 'keep going as far as possible after an error')
             [CompletionResult]::new('-o', 'o', [CompletionResultType]::ParameterName, 'output filename')
             [CompletionResult]::new('--output', 'output', [CompletionResultType]::ParameterName, 'output filename')
             [CompletionResult]::new('--remove', 'remove', [CompletionResultType]::ParameterName, 'remove targets')
@@ -158,6 +173,9 @@ Register-ArgumentCompleter -Native -CommandName 'chezmoi' -ScriptBlock {
         'chezmoi;remove' {
             break
         }
+        'chezmoi;restore' {
+            break
+        }
         'chezmoi;secret' {
             [CompletionResult]::new('bitwarden', 'bitwarden', [CompletionResultType]::ParameterValue, 'Execute the Bitwarden CLI (bw)')
             [CompletionResult]::new('generic', 'generic', [CompletionResultType]::ParameterValue, 'Execute a generic secret command')
@@ -211,6 +229,45 @@ Register-ArgumentCompleter -Native -CommandName 'chezmoi' -ScriptBlock {
         'chezmoi;source-path' {
             break
         }
+        'chezmoi;state' {
+            [CompletionResult]::new('delete', 'delete', [CompletionResultType]::ParameterValue, 'Delete a key from the persistent state')
+            [CompletionResult]::new('dump', 'dump', [CompletionResultType]::ParameterValue, 'Write all buckets and keys in the persistent state to stdout')
+            [CompletionResult]::new('export', 'export', [CompletionResultType]::ParameterValue, 'Write the persistent state to stdout in a portable format')
+            [CompletionResult]::new('get', 'get', [CompletionResultType]::ParameterValue, 'Get the value of a key in the persistent state')
+            [CompletionResult]::new('import', 'import', [CompletionResultType]::ParameterValue, 'Read a persistent state written by export')
+            [CompletionResult]::new('reset', 'reset', [CompletionResultType]::ParameterValue, 'Delete all keys in a bucket of the persistent state')
+            [CompletionResult]::new('scripts', 'scripts', [CompletionResultType]::ParameterValue, 'List the scripts with the once attribute that have been run')
+            break
+        }
+        'chezmoi;state;delete' {
+            [CompletionResult]::new('--bucket', 'bucket', [CompletionResultType]::ParameterName, 'bucket')
+            [CompletionResult]::new('--key', 'key', [CompletionResultType]::ParameterName, 'key')
+            break
+        }
+        'chezmoi;state;dump' {
+            break
+        }
+        'chezmoi;state;export' {
+            break
+        }
+        'chezmoi;state;get' {
+            [CompletionResult]::new('--bucket', 'bucket', [CompletionResultType]::ParameterName, 'bucket')
+            [CompletionResult]::new('--key', 'key', [CompletionResultType]::ParameterName, 'key')
+            break
+        }
+        'chezmoi;state;import' {
+            break
+        }
+        'chezmoi;state;reset' {
+            [CompletionResult]::new('--bucket', 'bucket', [CompletionResultType]::ParameterName, 'bucket')
+            break
+        }
+        'chezmoi;state;scripts' {
+            break
+        }
+        'chezmoi;status' {
+            break
+        }
         'chezmoi;unmanaged' {
             break
         }
@@ -60,6 +60,12 @@ func (m *AnyMutator) RunCmd(cmd *exec.Cmd) error {
 	return m.m.RunCmd(cmd)
 }
 
+// RunScript implements Mutator.RunScript.
+func (m *AnyMutator) RunScript(name, dir string, data []byte) error {
+	m.mutated = true
+	return m.m.RunScript(name, dir, data)
+}
+
 // Stat implements Mutator.Stat.
 func (m *AnyMutator) Stat(path string) (os.FileInfo, error) {
 	return m.m.Stat(path)
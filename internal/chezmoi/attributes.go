@@ -0,0 +1,304 @@
+package chezmoi
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// AttributesName is the name of the file that declares attributes.
+const AttributesName = ".chezmoiattributes"
+
+// DeclaredAttributes holds the attributes declared for a source entry in a
+// .chezmoiattributes file. A nil *DeclaredAttributes declares no attributes.
+type DeclaredAttributes struct {
+	After        bool
+	Before       bool
+	Block        bool
+	Create       bool
+	Empty        bool
+	Encrypted    bool
+	Exact        bool
+	Executable   bool
+	ExplicitPerm bool
+	Merge        bool
+	Modify       bool
+	Once         bool
+	Perm         os.FileMode
+	Private      bool
+	ReadOnly     bool
+	Run          bool
+	Symlink      bool
+	Template     bool
+}
+
+// declaredAttributeFields maps attribute names in .chezmoiattributes files to
+// their fields, in the order in which they are written.
+var declaredAttributeFields = []struct {
+	name  string
+	field func(*DeclaredAttributes) *bool
+}{
+	{"run", func(d *DeclaredAttributes) *bool { return &d.Run }},
+	{"create", func(d *DeclaredAttributes) *bool { return &d.Create }},
+	{"modify", func(d *DeclaredAttributes) *bool { return &d.Modify }},
+	{"block", func(d *DeclaredAttributes) *bool { return &d.Block }},
+	{"merge", func(d *DeclaredAttributes) *bool { return &d.Merge }},
+	{"encrypted", func(d *DeclaredAttributes) *bool { return &d.Encrypted }},
+	{"exact", func(d *DeclaredAttributes) *bool { return &d.Exact }},
+	{"private", func(d *DeclaredAttributes) *bool { return &d.Private }},
+	{"readonly", func(d *DeclaredAttributes) *bool { return &d.ReadOnly }},
+	{"empty", func(d *DeclaredAttributes) *bool { return &d.Empty }},
+	{"executable", func(d *DeclaredAttributes) *bool { return &d.Executable }},
+	{"symlink", func(d *DeclaredAttributes) *bool { return &d.Symlink }},
+	{"once", func(d *DeclaredAttributes) *bool { return &d.Once }},
+	{"before", func(d *DeclaredAttributes) *bool { return &d.Before }},
+	{"after", func(d *DeclaredAttributes) *bool { return &d.After }},
+	{"template", func(d *DeclaredAttributes) *bool { return &d.Template }},
+}
+
+// ParseAttributesFile parses the contents of a .chezmoiattributes file and
+// returns a map of source names to their declared attributes.
+func ParseAttributesFile(data []byte) (map[string]*DeclaredAttributes, error) {
+	result := make(map[string]*DeclaredAttributes)
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for lineNumber := 1; s.Scan(); lineNumber++ {
+		name, declared, err := parseAttributesLine(s.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		if name == "" {
+			continue
+		}
+		if _, ok := result[name]; ok {
+			return nil, fmt.Errorf("line %d: %s: duplicate name", lineNumber, name)
+		}
+		result[name] = declared
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SetDeclaredAttributes returns the contents of the .chezmoiattributes file
+// data with the attributes of name set to declared, keeping all other lines.
+// If declared does not declare any attributes then name's line is removed.
+func SetDeclaredAttributes(data []byte, name string, declared *DeclaredAttributes) ([]byte, error) {
+	newLine := declared.line(name)
+	b := &bytes.Buffer{}
+	found := false
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		line := s.Text()
+		lineName, _, err := parseAttributesLine(line)
+		if err != nil {
+			return nil, err
+		}
+		if lineName == name {
+			found = true
+			if newLine == "" {
+				continue
+			}
+			line = newLine
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if !found && newLine != "" {
+		b.WriteString(newLine)
+		b.WriteByte('\n')
+	}
+	return b.Bytes(), nil
+}
+
+// ParseDirAttributes parses the source directory name sourceName and combines
+// its attributes with d.
+func (d *DeclaredAttributes) ParseDirAttributes(sourceName string) (DirAttributes, error) {
+	da := ParseDirAttributes(sourceName)
+	if d == nil {
+		return da, nil
+	}
+	if name := d.disallowed("exact", "mode", "private", "readonly"); name != "" {
+		return DirAttributes{}, fmt.Errorf("%s: not allowed for directories", name)
+	}
+	if d.ExplicitPerm && (da.ExplicitPerm || da.Perm != 0o777) || (d.ExplicitPerm || da.ExplicitPerm) && (d.Private || d.ReadOnly) {
+		return DirAttributes{}, errors.New("mode conflicts with other permissions")
+	}
+	da.Exact = da.Exact || d.Exact
+	if d.ExplicitPerm {
+		da.ExplicitPerm = true
+		da.Perm = d.Perm
+	} else {
+		if d.Private {
+			da.Perm &= 0o700
+		}
+		if d.ReadOnly {
+			da.Perm &^= 0o222
+		}
+	}
+	return da, nil
+}
+
+// ParseFileAttributes parses the source file name sourceName and combines its
+// attributes with d.
+func (d *DeclaredAttributes) ParseFileAttributes(sourceName string) (FileAttributes, error) {
+	fa := ParseFileAttributes(sourceName)
+	if d == nil {
+		return fa, nil
+	}
+	if name := d.disallowed("block", "create", "empty", "encrypted", "executable", "merge", "mode", "modify", "private", "readonly", "symlink", "template"); name != "" {
+		return FileAttributes{}, fmt.Errorf("%s: not allowed for files", name)
+	}
+	fa.Template = fa.Template || d.Template
+	if fa.Mode&os.ModeType == os.ModeSymlink || d.Symlink {
+		if name := d.disallowed("symlink", "template"); name != "" {
+			return FileAttributes{}, fmt.Errorf("%s: not allowed for symlinks", name)
+		}
+		if fa.Mode&os.ModeType == 0 && (fa.Create || fa.Modify || fa.Block || fa.Merge || fa.Encrypted || fa.Empty || fa.ExplicitPerm || fa.Mode.Perm() != 0o666) {
+			return FileAttributes{}, errors.New("symlink conflicts with other attributes")
+		}
+		fa.Mode = os.ModeSymlink | 0o666
+		return fa, nil
+	}
+	fa.Create = fa.Create || d.Create
+	fa.Empty = fa.Empty || d.Empty
+	fa.Encrypted = fa.Encrypted || d.Encrypted
+	fa.Modify = fa.Modify || d.Modify
+	fa.Block = fa.Block || d.Block
+	fa.Merge = fa.Merge || d.Merge
+	exclusive := 0
+	for _, b := range []bool{fa.Modify, fa.Block, fa.Merge} {
+		if b {
+			exclusive++
+		}
+	}
+	if exclusive > 1 {
+		return FileAttributes{}, errors.New("modify, block, and merge are mutually exclusive")
+	}
+	if d.ExplicitPerm && (fa.ExplicitPerm || fa.Mode.Perm() != 0o666) || (d.ExplicitPerm || fa.ExplicitPerm) && (d.Executable || d.Private || d.ReadOnly) {
+		return FileAttributes{}, errors.New("mode conflicts with other permissions")
+	}
+	if d.ExplicitPerm {
+		fa.ExplicitPerm = true
+		fa.Mode = d.Perm
+	} else {
+		if d.Executable {
+			fa.Mode |= 0o111
+		}
+		if d.Private {
+			fa.Mode &= 0o700
+		}
+		if d.ReadOnly {
+			fa.Mode &^= 0o222
+		}
+	}
+	return fa, nil
+}
+
+// ParseScriptAttributes parses the source script name sourceName and combines
+// its attributes with d.
+func (d *DeclaredAttributes) ParseScriptAttributes(sourceName string) (ScriptAttributes, error) {
+	sa := ParseScriptAttributes(sourceName)
+	if d == nil {
+		return sa, nil
+	}
+	if name := d.disallowed("after", "before", "encrypted", "once", "run", "template"); name != "" {
+		return ScriptAttributes{}, fmt.Errorf("%s: not allowed for scripts", name)
+	}
+	if d.Before && d.After || d.Before && sa.Phase == ScriptPhaseAfter || d.After && sa.Phase == ScriptPhaseBefore {
+		return ScriptAttributes{}, errors.New("before and after are mutually exclusive")
+	}
+	sa.Once = sa.Once || d.Once
+	switch {
+	case d.Before:
+		sa.Phase = ScriptPhaseBefore
+	case d.After:
+		sa.Phase = ScriptPhaseAfter
+	}
+	sa.Encrypted = sa.Encrypted || d.Encrypted
+	sa.Template = sa.Template || d.Template
+	return sa, nil
+}
+
+// disallowed returns the first attribute declared by d that is not in allowed,
+// or the empty string if all of d's attributes are allowed.
+func (d *DeclaredAttributes) disallowed(allowed ...string) string {
+FOR:
+	for _, name := range d.names() {
+		for _, allowedName := range allowed {
+			if name == allowedName || strings.HasPrefix(name, allowedName+"=") {
+				continue FOR
+			}
+		}
+		return name
+	}
+	return ""
+}
+
+// names returns the names of the attributes declared by d.
+func (d *DeclaredAttributes) names() []string {
+	if d == nil {
+		return nil
+	}
+	var names []string
+	for _, daf := range declaredAttributeFields {
+		if *daf.field(d) {
+			names = append(names, daf.name)
+		}
+		if daf.name == "private" && d.ExplicitPerm {
+			names = append(names, fmt.Sprintf("mode=%04o", d.Perm))
+		}
+	}
+	return names
+}
+
+// line returns the line in a .chezmoiattributes file that declares d for name,
+// or the empty string if d does not declare any attributes.
+func (d *DeclaredAttributes) line(name string) string {
+	names := d.names()
+	if len(names) == 0 {
+		return ""
+	}
+	return name + " " + strings.Join(names, " ")
+}
+
+// parseAttributesLine parses a single line of a .chezmoiattributes file. It
+// returns an empty name if the line is empty or a comment.
+func parseAttributesLine(line string) (string, *DeclaredAttributes, error) {
+	if index := strings.IndexRune(line, '#'); index != -1 {
+		line = line[:index]
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", nil, nil
+	}
+	name := fields[0]
+	declared := &DeclaredAttributes{}
+FIELD:
+	for _, field := range fields[1:] {
+		if strings.HasPrefix(field, "mode=") {
+			perm, err := strconv.ParseUint(strings.TrimPrefix(field, "mode="), 8, 32)
+			if err != nil || os.FileMode(perm) != os.FileMode(perm).Perm() {
+				return "", nil, fmt.Errorf("%s: %s: invalid mode", name, field)
+			}
+			declared.ExplicitPerm = true
+			declared.Perm = os.FileMode(perm)
+			continue
+		}
+		for _, daf := range declaredAttributeFields {
+			if field == daf.name {
+				*daf.field(declared) = true
+				continue FIELD
+			}
+		}
+		return "", nil, fmt.Errorf("%s: %s: unknown attribute", name, field)
+	}
+	return name, declared, nil
+}
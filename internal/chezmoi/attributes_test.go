@@ -0,0 +1,233 @@
+package chezmoi
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAttributesFile(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		data        string
+		expected    map[string]*DeclaredAttributes
+		expectedErr bool
+	}{
+		{
+			name:     "empty",
+			expected: map[string]*DeclaredAttributes{},
+		},
+		{
+			name: "attributes",
+			data: "# comment\n" +
+				"dot_netrc private template # comment\n" +
+				"\n" +
+				"bin exact\n" +
+				"install.sh run once before\n" +
+				"secrets.conf mode=0640\n",
+			expected: map[string]*DeclaredAttributes{
+				"dot_netrc": {
+					Private:  true,
+					Template: true,
+				},
+				"bin": {
+					Exact: true,
+				},
+				"install.sh": {
+					Run:    true,
+					Once:   true,
+					Before: true,
+				},
+				"secrets.conf": {
+					ExplicitPerm: true,
+					Perm:         0o640,
+				},
+			},
+		},
+		{
+			name:        "duplicate_name",
+			data:        "foo private\nfoo template\n",
+			expectedErr: true,
+		},
+		{
+			name:        "unknown_attribute",
+			data:        "foo unknown\n",
+			expectedErr: true,
+		},
+		{
+			name:        "invalid_mode",
+			data:        "foo mode=0999\n",
+			expectedErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := ParseAttributesFile([]byte(tc.data))
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestSetDeclaredAttributes(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		data       string
+		sourceName string
+		declared   *DeclaredAttributes
+		expected   string
+	}{
+		{
+			name:       "add",
+			sourceName: "foo",
+			declared: &DeclaredAttributes{
+				Template: true,
+				Private:  true,
+			},
+			expected: "foo private template\n",
+		},
+		{
+			name:       "replace",
+			data:       "# comment\nfoo private\nbar exact\n",
+			sourceName: "foo",
+			declared: &DeclaredAttributes{
+				ExplicitPerm: true,
+				Perm:         0o640,
+				Encrypted:    true,
+			},
+			expected: "# comment\nfoo encrypted mode=0640\nbar exact\n",
+		},
+		{
+			name:       "remove",
+			data:       "foo private\nbar exact\n",
+			sourceName: "foo",
+			declared:   &DeclaredAttributes{},
+			expected:   "bar exact\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := SetDeclaredAttributes([]byte(tc.data), tc.sourceName, tc.declared)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, string(actual))
+		})
+	}
+}
+
+func TestDeclaredAttributesParseFileAttributes(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		sourceName  string
+		declared    *DeclaredAttributes
+		expected    FileAttributes
+		expectedErr bool
+	}{
+		{
+			name:       "nil",
+			sourceName: "private_dot_foo",
+			expected: FileAttributes{
+				Name: ".foo",
+				Mode: 0o600,
+			},
+		},
+		{
+			name:       "combined",
+			sourceName: "encrypted_dot_foo",
+			declared: &DeclaredAttributes{
+				Executable: true,
+				Private:    true,
+				Template:   true,
+			},
+			expected: FileAttributes{
+				Name:      ".foo",
+				Mode:      0o700,
+				Encrypted: true,
+				Template:  true,
+			},
+		},
+		{
+			name:       "symlink",
+			sourceName: "dot_foo",
+			declared: &DeclaredAttributes{
+				Symlink: true,
+			},
+			expected: FileAttributes{
+				Name: ".foo",
+				Mode: os.ModeSymlink | 0o666,
+			},
+		},
+		{
+			name:       "mode",
+			sourceName: "dot_foo",
+			declared: &DeclaredAttributes{
+				ExplicitPerm: true,
+				Perm:         0o640,
+			},
+			expected: FileAttributes{
+				Name:         ".foo",
+				Mode:         0o640,
+				ExplicitPerm: true,
+			},
+		},
+		{
+			name:       "mode_conflict",
+			sourceName: "private_dot_foo",
+			declared: &DeclaredAttributes{
+				ExplicitPerm: true,
+				Perm:         0o640,
+			},
+			expectedErr: true,
+		},
+		{
+			name:       "modify_block_conflict",
+			sourceName: "modify_dot_foo",
+			declared: &DeclaredAttributes{
+				Block: true,
+			},
+			expectedErr: true,
+		},
+		{
+			name:       "symlink_conflict",
+			sourceName: "executable_foo",
+			declared: &DeclaredAttributes{
+				Symlink: true,
+			},
+			expectedErr: true,
+		},
+		{
+			name:       "exact_file",
+			sourceName: "foo",
+			declared: &DeclaredAttributes{
+				Exact: true,
+			},
+			expectedErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := tc.declared.ParseFileAttributes(tc.sourceName)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestDeclaredAttributesParseScriptAttributes(t *testing.T) {
+	sa, err := (&DeclaredAttributes{Run: true, Once: true, After: true}).ParseScriptAttributes("install.sh")
+	require.NoError(t, err)
+	assert.Equal(t, ScriptAttributes{
+		Name:  "install.sh",
+		Once:  true,
+		Phase: ScriptPhaseAfter,
+	}, sa)
+
+	_, err = (&DeclaredAttributes{Before: true}).ParseScriptAttributes("run_after_install.sh")
+	assert.Error(t, err)
+}
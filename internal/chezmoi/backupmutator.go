@@ -0,0 +1,357 @@
+package chezmoi
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// BackupTimeFormat is the format of the names of backup directories.
+const BackupTimeFormat = "20060102T150405.000Z"
+
+// A BackupMutator wraps a Mutator and, before it replaces or removes anything
+// in a destination directory, saves a copy of the old contents and permissions
+// in a backup directory.
+type BackupMutator struct {
+	m         Mutator
+	fs        vfs.FS
+	destDir   string
+	backupDir string
+	backedUp  map[string]struct{}
+}
+
+// NewBackupMutator returns a new BackupMutator that saves copies of targets in
+// destDir to backupDir in fs.
+func NewBackupMutator(m Mutator, fs vfs.FS, destDir, backupDir string) *BackupMutator {
+	return &BackupMutator{
+		m:         m,
+		fs:        fs,
+		destDir:   destDir,
+		backupDir: backupDir,
+		backedUp:  make(map[string]struct{}),
+	}
+}
+
+// Chmod implements Mutator.Chmod. Only the permissions of directories are
+// saved, not their contents.
+func (m *BackupMutator) Chmod(name string, mode os.FileMode) error {
+	if err := m.backupPerm(name); err != nil {
+		return err
+	}
+	return m.m.Chmod(name, mode)
+}
+
+// IdempotentCmdOutput implements Mutator.IdempotentCmdOutput.
+func (m *BackupMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
+	return m.m.IdempotentCmdOutput(cmd)
+}
+
+// Mkdir implements Mutator.Mkdir.
+func (m *BackupMutator) Mkdir(name string, perm os.FileMode) error {
+	return m.m.Mkdir(name, perm)
+}
+
+// RemoveAll implements Mutator.RemoveAll.
+func (m *BackupMutator) RemoveAll(name string) error {
+	if err := m.backup(name); err != nil {
+		return err
+	}
+	return m.m.RemoveAll(name)
+}
+
+// Rename implements Mutator.Rename.
+func (m *BackupMutator) Rename(oldpath, newpath string) error {
+	if err := m.backup(newpath); err != nil {
+		return err
+	}
+	return m.m.Rename(oldpath, newpath)
+}
+
+// RunCmd implements Mutator.RunCmd.
+func (m *BackupMutator) RunCmd(cmd *exec.Cmd) error {
+	return m.m.RunCmd(cmd)
+}
+
+// RunScript implements Mutator.RunScript.
+func (m *BackupMutator) RunScript(name, dir string, data []byte) error {
+	return m.m.RunScript(name, dir, data)
+}
+
+// Stat implements Mutator.Stat.
+func (m *BackupMutator) Stat(name string) (os.FileInfo, error) {
+	return m.m.Stat(name)
+}
+
+// WriteFile implements Mutator.WriteFile.
+func (m *BackupMutator) WriteFile(name string, data []byte, perm os.FileMode, currData []byte) error {
+	if err := m.backup(name); err != nil {
+		return err
+	}
+	return m.m.WriteFile(name, data, perm, currData)
+}
+
+// WriteSymlink implements Mutator.WriteSymlink.
+func (m *BackupMutator) WriteSymlink(oldname, newname string) error {
+	if err := m.backup(newname); err != nil {
+		return err
+	}
+	return m.m.WriteSymlink(oldname, newname)
+}
+
+// backup saves a copy of name, if it exists, is in m's destination directory,
+// and has not already been saved. Permissions are preserved.
+func (m *BackupMutator) backup(name string) error {
+	return m.backupPath(name, true)
+}
+
+// backupPerm saves a copy of name like backup, except that if name is a
+// directory then only its permissions are saved.
+func (m *BackupMutator) backupPerm(name string) error {
+	return m.backupPath(name, false)
+}
+
+// backupPath saves a copy of name. If recursive is false then the contents of
+// directories are not saved, and the directory is not recorded as saved so
+// that its contents can be saved later.
+func (m *BackupMutator) backupPath(name string, recursive bool) error {
+	relPath, err := filepath.Rel(m.destDir, name)
+	if err != nil || relPath == "." || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return nil
+	}
+	for dir := relPath; dir != "."; dir = filepath.Dir(dir) {
+		if _, ok := m.backedUp[dir]; ok {
+			return nil
+		}
+	}
+	info, err := m.fs.Lstat(name)
+	switch {
+	case os.IsNotExist(err):
+		return nil
+	case err != nil:
+		return err
+	}
+	if err := vfs.MkdirAll(m.fs, m.backupDir, 0o700); err != nil {
+		return err
+	}
+
+	// Directories are created writable so that their contents can be saved,
+	// and their permissions are set afterwards, deepest first.
+	var dirs []string
+	var dirPerms []os.FileMode
+
+	// Save the permissions of the directories that contain name, so that
+	// restoring the backup does not change them.
+	var parentRelPaths []string
+	for dir := filepath.Dir(relPath); dir != "."; dir = filepath.Dir(dir) {
+		parentRelPaths = append([]string{dir}, parentRelPaths...)
+	}
+	for _, parentRelPath := range parentRelPaths {
+		info, err := m.fs.Stat(filepath.Join(m.destDir, parentRelPath))
+		if err != nil {
+			return err
+		}
+		if err := m.saveDir(filepath.Join(m.backupDir, parentRelPath), info.Mode().Perm(), &dirs, &dirPerms); err != nil {
+			return err
+		}
+	}
+
+	if err := vfs.Walk(m.fs, name, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		pathRelPath, err := filepath.Rel(m.destDir, path)
+		if err != nil {
+			return err
+		}
+		if _, ok := m.backedUp[pathRelPath]; ok {
+			// Keep the copy that was saved before it was changed.
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		backupPath := filepath.Join(m.backupDir, pathRelPath)
+		switch {
+		case info.IsDir():
+			if err := m.saveDir(backupPath, info.Mode().Perm(), &dirs, &dirPerms); err != nil {
+				return err
+			}
+			if !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		case info.Mode().IsRegular():
+			data, err := m.fs.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if err := m.fs.WriteFile(backupPath, data, 0o600); err != nil {
+				return err
+			}
+			return m.fs.Chmod(backupPath, info.Mode().Perm())
+		case info.Mode()&os.ModeType == os.ModeSymlink:
+			linkname, err := m.fs.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return m.fs.Symlink(linkname, backupPath)
+		default:
+			return nil
+		}
+	}); err != nil {
+		return err
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := m.fs.Chmod(dirs[i], dirPerms[i]); err != nil {
+			return err
+		}
+	}
+	if recursive || !info.IsDir() {
+		m.backedUp[relPath] = struct{}{}
+	}
+	return nil
+}
+
+// saveDir makes sure that the directory backupPath exists in the backup and is
+// writable, and appends it and the permissions that it should have to dirs
+// and dirPerms. If backupPath already exists then it keeps the permissions
+// that were saved when it was created, which are the oldest.
+func (m *BackupMutator) saveDir(backupPath string, perm os.FileMode, dirs *[]string, dirPerms *[]os.FileMode) error {
+	info, err := m.fs.Lstat(backupPath)
+	switch {
+	case os.IsNotExist(err):
+		if err := m.fs.Mkdir(backupPath, 0o700); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	case !info.IsDir():
+		return fmt.Errorf("%s: not a directory", backupPath)
+	default:
+		perm = info.Mode().Perm()
+		if perm&0o700 == 0o700 {
+			return nil
+		}
+		if err := m.fs.Chmod(backupPath, 0o700); err != nil {
+			return err
+		}
+	}
+	*dirs = append(*dirs, backupPath)
+	*dirPerms = append(*dirPerms, perm)
+	return nil
+}
+
+// RestoreBackup restores the files, directories, and symlinks in backupPath in
+// fs to targetPath using mutator. Existing files and symlinks are replaced.
+// Existing directories are kept, and anything in them that is not in
+// backupPath is left unchanged.
+func RestoreBackup(fs vfs.FS, mutator Mutator, backupPath, targetPath string, umask os.FileMode) error {
+	if err := vfs.MkdirAll(mutator, filepath.Dir(targetPath), 0o777&^umask); err != nil {
+		return err
+	}
+	// Directories that are not writable are made writable so that their
+	// contents can be restored, and their permissions are set afterwards.
+	var dirs []string
+	var dirPerms []os.FileMode
+	if err := vfs.Walk(fs, backupPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(backupPath, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.Join(targetPath, relPath)
+		targetInfo, err := fs.Lstat(name)
+		switch {
+		case os.IsNotExist(err):
+			targetInfo = nil
+		case err != nil:
+			return err
+		}
+		switch {
+		case info.IsDir():
+			perm := info.Mode().Perm()
+			writable := perm&0o300 == 0o300
+			if targetInfo != nil && targetInfo.IsDir() {
+				switch {
+				case writable && targetInfo.Mode().Perm() != perm:
+					return mutator.Chmod(name, perm)
+				case writable:
+					return nil
+				case targetInfo.Mode().Perm() != 0o700:
+					if err := mutator.Chmod(name, 0o700); err != nil {
+						return err
+					}
+				}
+			} else {
+				if targetInfo != nil {
+					if err := mutator.RemoveAll(name); err != nil {
+						return err
+					}
+				}
+				if writable {
+					if err := mutator.Mkdir(name, perm); err != nil {
+						return err
+					}
+					if perm&^umask != perm {
+						return mutator.Chmod(name, perm)
+					}
+					return nil
+				}
+				if err := mutator.Mkdir(name, 0o700); err != nil {
+					return err
+				}
+			}
+			dirs = append(dirs, name)
+			dirPerms = append(dirPerms, perm)
+			return nil
+		case info.Mode().IsRegular():
+			data, err := fs.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			var currData []byte
+			switch {
+			case targetInfo == nil:
+			case targetInfo.Mode().IsRegular():
+				if currData, err = fs.ReadFile(name); err != nil {
+					return err
+				}
+			default:
+				if err := mutator.RemoveAll(name); err != nil {
+					return err
+				}
+			}
+			if err := mutator.WriteFile(name, data, info.Mode().Perm(), currData); err != nil {
+				return err
+			}
+			// Writing an existing file does not change its permissions, and
+			// new files are created subject to the umask.
+			if targetInfo != nil && targetInfo.Mode().IsRegular() && targetInfo.Mode().Perm() != info.Mode().Perm() || info.Mode().Perm()&^umask != info.Mode().Perm() {
+				return mutator.Chmod(name, info.Mode().Perm())
+			}
+			return nil
+		case info.Mode()&os.ModeType == os.ModeSymlink:
+			linkname, err := fs.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return mutator.WriteSymlink(linkname, name)
+		default:
+			return nil
+		}
+	}); err != nil {
+		return err
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := mutator.Chmod(dirs[i], dirPerms[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
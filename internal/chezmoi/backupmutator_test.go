@@ -0,0 +1,292 @@
+package chezmoi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	vfs "github.com/twpayne/go-vfs"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestBackupMutator(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".bashrc": &vfst.File{
+				Perm:     0o600,
+				Contents: []byte("# old .bashrc\n"),
+			},
+			".dir": map[string]interface{}{
+				"file": "# old file\n",
+			},
+			".symlink": &vfst.Symlink{Target: ".bashrc"},
+		},
+		"/home/user/backups": &vfst.Dir{Perm: 0o755},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	mutator := NewBackupMutator(NewFSMutator(fs), fs, "/home/user", "/home/user/backups/1")
+	require.NoError(t, mutator.WriteFile("/home/user/.bashrc", []byte("# new .bashrc\n"), 0o644, nil))
+	require.NoError(t, mutator.WriteFile("/home/user/.bashrc", []byte("# newer .bashrc\n"), 0o644, nil))
+	require.NoError(t, mutator.WriteFile("/home/user/.dir/file", []byte("# new file\n"), 0o644, nil))
+	require.NoError(t, mutator.RemoveAll("/home/user/.dir"))
+	require.NoError(t, mutator.WriteSymlink(".dir", "/home/user/.symlink"))
+	require.NoError(t, mutator.WriteFile("/home/user/.new", []byte("# new\n"), 0o644, nil))
+
+	vfst.RunTests(t, fs, "backup",
+		vfst.TestPath("/home/user/backups/1/.bashrc",
+			vfst.TestModeIsRegular,
+			vfst.TestModePerm(0o600),
+			vfst.TestContentsString("# old .bashrc\n"),
+		),
+		vfst.TestPath("/home/user/backups/1/.dir/file",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("# old file\n"),
+		),
+		vfst.TestPath("/home/user/backups/1/.symlink",
+			vfst.TestModeType(os.ModeSymlink),
+			vfst.TestSymlinkTarget(".bashrc"),
+		),
+		vfst.TestPath("/home/user/backups/1/.new",
+			vfst.TestDoesNotExist,
+		),
+	)
+
+	require.NoError(t, fs.Mkdir("/home/user/.dir", 0o755))
+	require.NoError(t, fs.WriteFile("/home/user/.dir/new", []byte("# new\n"), 0o644))
+	require.NoError(t, RestoreBackup(fs, NewFSMutator(fs), "/home/user/backups/1/.bashrc", "/home/user/.bashrc", 0o22))
+	require.NoError(t, RestoreBackup(fs, NewFSMutator(fs), "/home/user/backups/1/.dir", "/home/user/.dir", 0o22))
+	require.NoError(t, RestoreBackup(fs, NewFSMutator(fs), "/home/user/backups/1/.symlink", "/home/user/.symlink", 0o22))
+
+	vfst.RunTests(t, fs, "restore",
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestModeIsRegular,
+			vfst.TestModePerm(0o600),
+			vfst.TestContentsString("# old .bashrc\n"),
+		),
+		vfst.TestPath("/home/user/.dir",
+			vfst.TestIsDir,
+			vfst.TestModePerm(0o755),
+		),
+		vfst.TestPath("/home/user/.dir/file",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("# old file\n"),
+		),
+		vfst.TestPath("/home/user/.dir/new",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("# new\n"),
+		),
+		vfst.TestPath("/home/user/.symlink",
+			vfst.TestModeType(os.ModeSymlink),
+			vfst.TestSymlinkTarget(".bashrc"),
+		),
+		vfst.TestPath("/home/user/.new",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("# new\n"),
+		),
+	)
+}
+
+func TestBackupMutatorPerms(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".config": &vfst.Dir{
+				Perm: 0o755,
+				Entries: map[string]interface{}{
+					"app": &vfst.Dir{
+						Perm: 0o750,
+						Entries: map[string]interface{}{
+							"conf": "# old conf\n",
+						},
+					},
+				},
+			},
+			".dir": &vfst.Dir{
+				Perm: 0o755,
+				Entries: map[string]interface{}{
+					"file": "# contents of .dir/file\n",
+				},
+			},
+			".file": &vfst.File{
+				Perm:     0o644,
+				Contents: []byte("# contents of .file\n"),
+			},
+		},
+		"/home/user/backups": &vfst.Dir{Perm: 0o755},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	mutator := NewBackupMutator(NewFSMutator(fs), fs, "/home/user", "/home/user/backups/1")
+	require.NoError(t, mutator.WriteFile("/home/user/.config/app/conf", []byte("# new conf\n"), 0o644, nil))
+	require.NoError(t, mutator.Chmod("/home/user/.config/app", 0o700))
+	require.NoError(t, mutator.Chmod("/home/user/.dir", 0o700))
+	require.NoError(t, mutator.WriteFile("/home/user/.dir/file", []byte("# new contents of .dir/file\n"), 0o644, nil))
+	require.NoError(t, mutator.Chmod("/home/user/.file", 0o600))
+
+	vfst.RunTests(t, fs, "backup",
+		vfst.TestPath("/home/user/backups/1/.config",
+			vfst.TestIsDir,
+			vfst.TestModePerm(0o755),
+		),
+		vfst.TestPath("/home/user/backups/1/.config/app",
+			vfst.TestIsDir,
+			vfst.TestModePerm(0o750),
+		),
+		vfst.TestPath("/home/user/backups/1/.dir",
+			vfst.TestIsDir,
+			vfst.TestModePerm(0o755),
+		),
+		vfst.TestPath("/home/user/backups/1/.dir/file",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("# contents of .dir/file\n"),
+		),
+		vfst.TestPath("/home/user/backups/1/.file",
+			vfst.TestModeIsRegular,
+			vfst.TestModePerm(0o644),
+		),
+	)
+
+	for _, name := range []string{".config", ".dir", ".file"} {
+		require.NoError(t, RestoreBackup(fs, NewFSMutator(fs), "/home/user/backups/1/"+name, "/home/user/"+name, 0o22))
+	}
+
+	vfst.RunTests(t, fs, "restore",
+		vfst.TestPath("/home/user/.config",
+			vfst.TestIsDir,
+			vfst.TestModePerm(0o755),
+		),
+		vfst.TestPath("/home/user/.config/app",
+			vfst.TestIsDir,
+			vfst.TestModePerm(0o750),
+		),
+		vfst.TestPath("/home/user/.config/app/conf",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("# old conf\n"),
+		),
+		vfst.TestPath("/home/user/.dir",
+			vfst.TestIsDir,
+			vfst.TestModePerm(0o755),
+		),
+		vfst.TestPath("/home/user/.dir/file",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("# contents of .dir/file\n"),
+		),
+		vfst.TestPath("/home/user/.file",
+			vfst.TestModeIsRegular,
+			vfst.TestModePerm(0o644),
+		),
+	)
+}
+
+func TestRestoreBackupReadOnlyDir(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".existing": &vfst.Dir{Perm: 0o555},
+			"backups/1": map[string]interface{}{
+				".existing": &vfst.Dir{
+					Perm: 0o500,
+					Entries: map[string]interface{}{
+						"file": "# contents of .existing/file\n",
+					},
+				},
+				".new": &vfst.Dir{
+					Perm: 0o555,
+					Entries: map[string]interface{}{
+						"dir": &vfst.Dir{
+							Perm: 0o500,
+							Entries: map[string]interface{}{
+								"file":    "# contents of .new/dir/file\n",
+								"symlink": &vfst.Symlink{Target: "file"},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	// The test might run as root, so check explicitly that only writable
+	// directories are changed.
+	mutator := &writableDirMutator{Mutator: NewFSMutator(fs), fs: fs}
+	require.NoError(t, RestoreBackup(fs, mutator, "/home/user/backups/1/.existing", "/home/user/.existing", 0o22))
+	require.NoError(t, RestoreBackup(fs, mutator, "/home/user/backups/1/.new", "/home/user/.new", 0o22))
+
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.existing",
+			vfst.TestIsDir,
+			vfst.TestModePerm(0o500),
+		),
+		vfst.TestPath("/home/user/.existing/file",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("# contents of .existing/file\n"),
+		),
+		vfst.TestPath("/home/user/.new",
+			vfst.TestIsDir,
+			vfst.TestModePerm(0o555),
+		),
+		vfst.TestPath("/home/user/.new/dir",
+			vfst.TestIsDir,
+			vfst.TestModePerm(0o500),
+		),
+		vfst.TestPath("/home/user/.new/dir/file",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("# contents of .new/dir/file\n"),
+		),
+		vfst.TestPath("/home/user/.new/dir/symlink",
+			vfst.TestModeType(os.ModeSymlink),
+			vfst.TestSymlinkTarget("file"),
+		),
+	)
+}
+
+// A writableDirMutator is a Mutator that fails to change the contents of
+// directories that are not writable, even when running as root.
+type writableDirMutator struct {
+	Mutator
+	fs vfs.FS
+}
+
+func (m *writableDirMutator) Mkdir(name string, perm os.FileMode) error {
+	if err := m.checkDir(name); err != nil {
+		return err
+	}
+	return m.Mutator.Mkdir(name, perm)
+}
+
+func (m *writableDirMutator) RemoveAll(name string) error {
+	if err := m.checkDir(name); err != nil {
+		return err
+	}
+	return m.Mutator.RemoveAll(name)
+}
+
+func (m *writableDirMutator) WriteFile(name string, data []byte, perm os.FileMode, currData []byte) error {
+	if err := m.checkDir(name); err != nil {
+		return err
+	}
+	return m.Mutator.WriteFile(name, data, perm, currData)
+}
+
+func (m *writableDirMutator) WriteSymlink(oldname, newname string) error {
+	if err := m.checkDir(newname); err != nil {
+		return err
+	}
+	return m.Mutator.WriteSymlink(oldname, newname)
+}
+
+// checkDir returns an error if the directory containing name is not writable.
+func (m *writableDirMutator) checkDir(name string) error {
+	info, err := m.fs.Stat(filepath.Dir(name))
+	if err != nil {
+		return err
+	}
+	if info.Mode().Perm()&0o200 == 0 {
+		return &os.PathError{Op: "write", Path: name, Err: os.ErrPermission}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package chezmoi
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+)
+
+const (
+	blockBeginMarker    = "BEGIN chezmoi managed block"
+	blockEndMarker      = "END chezmoi managed block"
+	blockHeader         = "chezmoi:block"
+	defaultBlockComment = "#"
+)
+
+var errUnterminatedBlock = errors.New("unterminated managed block")
+
+// ManagedBlock returns the text of the managed block in contents and the
+// comment that starts its marker lines. comment is empty if contents does not
+// contain a managed block.
+func ManagedBlock(contents []byte) (block []byte, comment string, err error) {
+	lines := bytes.SplitAfter(contents, []byte("\n"))
+	beginIndex, endIndex, comment := findBlock(lines)
+	switch {
+	case beginIndex == -1:
+		return nil, "", nil
+	case endIndex == -1:
+		return nil, "", errUnterminatedBlock
+	default:
+		return bytes.Join(lines[beginIndex+1:endIndex], nil), comment, nil
+	}
+}
+
+// ReplaceManagedBlock returns contents with the text of its managed block
+// replaced by block. If contents does not contain a managed block then one is
+// appended, with marker lines that start with comment. If block is empty then
+// the managed block, including its marker lines, is removed.
+func ReplaceManagedBlock(contents []byte, comment string, block []byte) ([]byte, error) {
+	lines := bytes.SplitAfter(contents, []byte("\n"))
+	beginIndex, endIndex, existingComment := findBlock(lines)
+	if beginIndex != -1 && endIndex == -1 {
+		return nil, errUnterminatedBlock
+	}
+	if beginIndex != -1 {
+		comment = existingComment
+	}
+
+	var newBlock []byte
+	if !isEmpty(block) {
+		newBlock = append(newBlock, comment+" "+blockBeginMarker+"\n"...)
+		newBlock = append(newBlock, block...)
+		if block[len(block)-1] != '\n' {
+			newBlock = append(newBlock, '\n')
+		}
+		newBlock = append(newBlock, comment+" "+blockEndMarker+"\n"...)
+	}
+
+	if beginIndex == -1 {
+		if newBlock == nil {
+			return contents, nil
+		}
+		result := append([]byte{}, contents...)
+		if len(result) != 0 && result[len(result)-1] != '\n' {
+			result = append(result, '\n')
+		}
+		return append(result, newBlock...), nil
+	}
+
+	result := bytes.Join(lines[:beginIndex], nil)
+	result = append(result, newBlock...)
+	return append(result, bytes.Join(lines[endIndex+1:], nil)...), nil
+}
+
+// blockContents returns currentContents with its managed block replaced by
+// source. If the first line of source is a comment followed by chezmoi:block
+// then it is removed and its comment is used for the marker lines.
+func blockContents(source, currentContents []byte) ([]byte, error) {
+	comment := defaultBlockComment
+	firstLine := source
+	rest := []byte(nil)
+	if index := bytes.IndexByte(source, '\n'); index != -1 {
+		firstLine, rest = source[:index], source[index+1:]
+	}
+	if fields := strings.Fields(string(firstLine)); len(fields) == 2 && fields[1] == blockHeader {
+		comment = fields[0]
+		source = rest
+	}
+	return ReplaceManagedBlock(currentContents, comment, source)
+}
+
+// findBlock returns the indexes of the begin and end marker lines of the
+// managed block in lines, and the comment that starts the begin marker line.
+// The indexes are -1 if the marker lines are not found.
+func findBlock(lines [][]byte) (int, int, string) {
+	beginIndex, endIndex := -1, -1
+	comment := ""
+	for i, line := range lines {
+		text := strings.TrimSpace(string(line))
+		switch {
+		case beginIndex == -1 && strings.HasSuffix(text, " "+blockBeginMarker):
+			beginIndex = i
+			comment = strings.TrimSuffix(text, " "+blockBeginMarker)
+		case beginIndex != -1 && text == comment+" "+blockEndMarker:
+			return beginIndex, i, comment
+		}
+	}
+	return beginIndex, endIndex, comment
+}
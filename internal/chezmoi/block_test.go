@@ -0,0 +1,88 @@
+package chezmoi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockContents(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		source          string
+		currentContents string
+		expected        string
+		expectedErr     bool
+	}{
+		{
+			name:     "missing",
+			source:   "block\n",
+			expected: "# BEGIN chezmoi managed block\nblock\n# END chezmoi managed block\n",
+		},
+		{
+			name:            "append",
+			source:          "block",
+			currentContents: "before",
+			expected:        "before\n# BEGIN chezmoi managed block\nblock\n# END chezmoi managed block\n",
+		},
+		{
+			name:            "replace",
+			source:          "new\n",
+			currentContents: "before\n# BEGIN chezmoi managed block\nold\nold\n# END chezmoi managed block\nafter\n",
+			expected:        "before\n# BEGIN chezmoi managed block\nnew\n# END chezmoi managed block\nafter\n",
+		},
+		{
+			name:            "remove",
+			source:          "",
+			currentContents: "before\n# BEGIN chezmoi managed block\nold\n# END chezmoi managed block\nafter\n",
+			expected:        "before\nafter\n",
+		},
+		{
+			name:            "remove_missing",
+			source:          "\n",
+			currentContents: "before\n",
+			expected:        "before\n",
+		},
+		{
+			name:            "header",
+			source:          "// chezmoi:block\nblock\n",
+			currentContents: "before\n",
+			expected:        "before\n// BEGIN chezmoi managed block\nblock\n// END chezmoi managed block\n",
+		},
+		{
+			name:            "existing_comment",
+			source:          "new\n",
+			currentContents: "; BEGIN chezmoi managed block\nold\n; END chezmoi managed block\n",
+			expected:        "; BEGIN chezmoi managed block\nnew\n; END chezmoi managed block\n",
+		},
+		{
+			name:            "unterminated",
+			source:          "new\n",
+			currentContents: "# BEGIN chezmoi managed block\nold\n",
+			expectedErr:     true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := blockContents([]byte(tc.source), []byte(tc.currentContents))
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, string(actual))
+		})
+	}
+}
+
+func TestManagedBlock(t *testing.T) {
+	block, comment, err := ManagedBlock([]byte("before\n  // BEGIN chezmoi managed block\nblock\n  // END chezmoi managed block\nafter\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "block\n", string(block))
+	assert.Equal(t, "//", comment)
+
+	block, comment, err = ManagedBlock([]byte("no block\n"))
+	require.NoError(t, err)
+	assert.Nil(t, block)
+	assert.Equal(t, "", comment)
+}
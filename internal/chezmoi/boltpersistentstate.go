@@ -1,6 +1,7 @@
 package chezmoi
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 
@@ -36,6 +37,20 @@ func NewBoltPersistentState(fs vfs.FS, path string, options *bolt.Options) (*Bol
 	return b, nil
 }
 
+// Buckets returns the names of the buckets in b, in order.
+func (b *BoltPersistentState) Buckets() ([][]byte, error) {
+	var buckets [][]byte
+	if b.db == nil {
+		return buckets, nil
+	}
+	return buckets, b.db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			buckets = append(buckets, copyBytes(name))
+			return nil
+		})
+	})
+}
+
 // Close closes b.
 func (b *BoltPersistentState) Close() error {
 	if b.db == nil {
@@ -63,6 +78,37 @@ func (b *BoltPersistentState) Delete(bucket, key []byte) error {
 	})
 }
 
+// DeleteBucket deletes bucket and all its keys. If bucket does not exist then
+// DeleteBucket does nothing.
+func (b *BoltPersistentState) DeleteBucket(bucket []byte) error {
+	if b.db == nil {
+		return nil
+	}
+	return b.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(bucket); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+			return err
+		}
+		return nil
+	})
+}
+
+// ForEach calls fn for each key and value in bucket, in key order. If bucket
+// does not exist then ForEach does nothing.
+func (b *BoltPersistentState) ForEach(bucket []byte, fn func(k, v []byte) error) error {
+	if b.db == nil {
+		return nil
+	}
+	return b.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			return fn(copyBytes(k), copyBytes(v))
+		})
+	})
+}
+
 // Get returns the value associated with key in bucket.
 func (b *BoltPersistentState) Get(bucket, key []byte) ([]byte, error) {
 	var value []byte
@@ -116,3 +162,11 @@ func (b *BoltPersistentState) openDB() error {
 	b.db = db
 	return err
 }
+
+// copyBytes returns a copy of b, which is only valid for the lifetime of a bolt
+// transaction.
+func copyBytes(b []byte) []byte {
+	result := make([]byte, len(b))
+	copy(result, b)
+	return result
+}
@@ -73,6 +73,43 @@ func TestBoltPersistentState(t *testing.T) {
 	assert.Equal(t, []byte(nil), actualValue)
 }
 
+func TestBoltPersistentStateForEachAndDeleteBucket(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.config/chezmoi": &vfst.Dir{Perm: 0o755},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	path := "/home/user/.config/chezmoi/chezmoistate.boltdb"
+	b, err := NewBoltPersistentState(fs, path, nil)
+	require.NoError(t, err)
+	defer b.Close()
+
+	bucket := []byte("bucket")
+	forEach := func() map[string]string {
+		result := make(map[string]string)
+		require.NoError(t, b.ForEach(bucket, func(k, v []byte) error {
+			result[string(k)] = string(v)
+			return nil
+		}))
+		return result
+	}
+
+	assert.Equal(t, map[string]string{}, forEach())
+	require.NoError(t, b.DeleteBucket(bucket))
+
+	require.NoError(t, b.Set(bucket, []byte("key1"), []byte("value1")))
+	require.NoError(t, b.Set(bucket, []byte("key2"), []byte("value2")))
+	assert.Equal(t, map[string]string{
+		"key1": "value1",
+		"key2": "value2",
+	}, forEach())
+
+	require.NoError(t, b.DeleteBucket(bucket))
+	assert.Equal(t, map[string]string{}, forEach())
+	require.NoError(t, b.DeleteBucket(bucket))
+}
+
 func TestBoltPersistentStateReadOnly(t *testing.T) {
 	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
 		"/home/user/.config/chezmoi": &vfst.Dir{Perm: 0o755},
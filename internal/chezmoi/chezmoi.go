@@ -14,13 +14,21 @@ import (
 
 // Suffixes and prefixes.
 const (
+	afterPrefix      = "after_"
+	beforePrefix     = "before_"
+	blockPrefix      = "block_"
+	createPrefix     = "create_"
 	dotPrefix        = "dot_"
 	emptyPrefix      = "empty_"
 	encryptedPrefix  = "encrypted_"
 	exactPrefix      = "exact_"
 	executablePrefix = "executable_"
+	mergePrefix      = "merge_"
+	modePrefix       = "mode_"
+	modifyPrefix     = "modify_"
 	oncePrefix       = "once_"
 	privatePrefix    = "private_"
+	readOnlyPrefix   = "readonly_"
 	runPrefix        = "run_"
 	symlinkPrefix    = "symlink_"
 	TemplateSuffix   = ".tmpl"
@@ -28,20 +36,30 @@ const (
 
 // A PersistentState is an interface to a persistent state.
 type PersistentState interface {
+	Buckets() ([][]byte, error)
 	Close() error
 	Delete(bucket, key []byte) error
+	DeleteBucket(bucket []byte) error
+	ForEach(bucket []byte, fn func(k, v []byte) error) error
 	Get(bucket, key []byte) ([]byte, error)
 	Set(bucket, key, value []byte) error
 }
 
 // An ApplyOptions is a big ball of mud for things that affect Entry.Apply.
 type ApplyOptions struct {
+	Conflict          func(targetPath string) (bool, error)
 	DestDir           string
 	DryRun            bool
+	EntryStateBucket  []byte
+	Filter            *EntryTypeFilter
 	Ignore            func(string) bool
+	KeepGoing         bool
 	PersistentState   PersistentState
 	Remove            bool
+	ReportDrift       func(targetPath string, drift Drift)
+	ReportScript      func(targetPath string, contents []byte) error
 	ScriptStateBucket []byte
+	scriptPhase       ScriptPhase
 	Stdout            io.Writer
 	Umask             os.FileMode
 	Verbose           bool
@@ -51,11 +69,11 @@ type ApplyOptions struct {
 type Entry interface {
 	AppendAllEntries(allEntries []Entry) []Entry
 	Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions) error
-	ConcreteValue(ignore func(string) bool, sourceDir string, umask os.FileMode, recursive bool) (interface{}, error)
+	ConcreteValue(ignore func(string) bool, filter *EntryTypeFilter, sourceDir func(Entry) string, umask os.FileMode, recursive bool) (interface{}, error)
 	Evaluate(ignore func(string) bool) error
 	SourceName() string
 	TargetName() string
-	archive(w *tar.Writer, ignore func(string) bool, headerTemplate *tar.Header, umask os.FileMode) error
+	archive(w *tar.Writer, ignore func(string) bool, filter *EntryTypeFilter, headerTemplate *tar.Header, umask os.FileMode) error
 }
 
 type parsedSourceFilePath struct {
@@ -64,20 +82,17 @@ type parsedSourceFilePath struct {
 	scriptAttributes *ScriptAttributes
 }
 
-// dirNames returns the dir names from dirAttributes.
-func dirNames(dirAttributes []DirAttributes) []string {
-	dns := make([]string, len(dirAttributes))
-	for i, da := range dirAttributes {
-		dns[i] = da.Name
-	}
-	return dns
-}
-
 // isEmpty returns true if b should be considered empty.
 func isEmpty(b []byte) bool {
 	return len(bytes.TrimSpace(b)) == 0
 }
 
+// isTemplateName returns true if the source name component name is a template
+// that must be executed to give its target name.
+func isTemplateName(name string) bool {
+	return strings.Contains(name, "{{")
+}
+
 // parseDirNameComponents parses multiple directory name components.
 func parseDirNameComponents(components []string) []DirAttributes {
 	das := []DirAttributes{}
@@ -88,23 +103,35 @@ func parseDirNameComponents(components []string) []DirAttributes {
 	return das
 }
 
-// parseSourceFilePath parses a single source file path.
-func parseSourceFilePath(path string) parsedSourceFilePath {
+// parseSourceFilePath parses a single source file path, combining the
+// attributes of the file with declared.
+func parseSourceFilePath(path string, declared *DeclaredAttributes) (parsedSourceFilePath, error) {
 	components := splitPathList(path)
 	das := parseDirNameComponents(components[0 : len(components)-1])
 	sourceName := components[len(components)-1]
-	if strings.HasPrefix(sourceName, runPrefix) {
-		sa := ParseScriptAttributes(sourceName)
+	if strings.HasPrefix(sourceName, runPrefix) || declared != nil && declared.Run {
+		sa, err := declared.ParseScriptAttributes(sourceName)
+		if err != nil {
+			return parsedSourceFilePath{}, err
+		}
 		return parsedSourceFilePath{
 			dirAttributes:    das,
 			scriptAttributes: &sa,
-		}
+		}, nil
+	}
+	// Unlike run_ scripts, modify_ scripts produce the contents of a File, so
+	// the modify_ prefix is parsed by ParseFileAttributes along with the other
+	// file attributes. This keeps the source names of modify_ files
+	// round-tripping through FileAttributes.SourceName, which chattr and
+	// target name lookups rely on.
+	fa, err := declared.ParseFileAttributes(sourceName)
+	if err != nil {
+		return parsedSourceFilePath{}, err
 	}
-	fa := ParseFileAttributes(components[len(components)-1])
 	return parsedSourceFilePath{
 		dirAttributes:  das,
 		fileAttributes: &fa,
-	}
+	}, nil
 }
 
 // sortedEntryNames returns a sorted slice of all entry names.
@@ -123,3 +150,61 @@ func splitPathList(path string) []string {
 	}
 	return strings.Split(path, string(filepath.Separator))
 }
+
+// walk is like vfs.Walk except that it does not discard errors returned by
+// walkFn for directories.
+func walk(fs vfs.FS, root string, walkFn filepath.WalkFunc) error {
+	var dirErr error
+	err := vfs.Walk(fs, root, func(path string, info os.FileInfo, err error) error {
+		if dirErr == nil {
+			err = walkFn(path, info, err)
+			if err == nil || err == filepath.SkipDir || info == nil || !info.IsDir() {
+				return err
+			}
+			dirErr = err
+		}
+		if info != nil && info.IsDir() {
+			return filepath.SkipDir
+		}
+		return dirErr
+	})
+	if dirErr != nil {
+		return dirErr
+	}
+	return err
+}
+
+// PersistentStateData returns the keys and values of all buckets in
+// persistentState. Values are returned as strings.
+func PersistentStateData(persistentState PersistentState) (map[string]map[string]string, error) {
+	buckets, err := persistentState.Buckets()
+	if err != nil {
+		return nil, err
+	}
+	stateData := make(map[string]map[string]string)
+	for _, bucket := range buckets {
+		bucketData := make(map[string]string)
+		if err := persistentState.ForEach(bucket, func(k, v []byte) error {
+			bucketData[string(k)] = string(v)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		stateData[string(bucket)] = bucketData
+	}
+	return stateData, nil
+}
+
+// SetPersistentStateData sets the keys and values in stateData, as returned by
+// PersistentStateData, in persistentState. Existing keys that are not in
+// stateData are kept.
+func SetPersistentStateData(persistentState PersistentState, stateData map[string]map[string]string) error {
+	for bucket, bucketData := range stateData {
+		for key, value := range bucketData {
+			if err := persistentState.Set([]byte(bucket), []byte(key), []byte(value)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package chezmoi
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// PopulateTemplateData reads the .chezmoidata files and the files in the
+// .chezmoidata directory in each of ts's source directories and merges them
+// into ts.TemplateData. Values from later source directories take precedence
+// over values from earlier ones, and values already in ts.TemplateData take
+// precedence over values from all source directories.
+func (ts *TargetState) PopulateTemplateData(fs vfs.FS) error {
+	var paths []string
+	for _, sourceDir := range ts.sourceDirs() {
+		sourceDirPaths, err := dataPaths(fs, sourceDir)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, sourceDirPaths...)
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+
+	sourceData := make(map[string]interface{})
+	for _, path := range paths {
+		contents, err := fs.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var data map[string]interface{}
+		if err := formats[filepath.Ext(path)](contents, &data); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if err := mergeData(sourceData, normalizeData(data).(map[string]interface{}), nil); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if err := mergeData(sourceData, ts.TemplateData, nil); err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	ts.TemplateData = sourceData
+	return nil
+}
+
+// dataPaths returns the paths of the .chezmoidata files and the files in the
+// .chezmoidata directory in sourceDir.
+func dataPaths(fs vfs.FS, sourceDir string) ([]string, error) {
+	var paths []string
+	for _, ext := range sortedDataFormatExts() {
+		path := filepath.Join(sourceDir, dataName+ext)
+		switch _, err := fs.Stat(path); {
+		case err == nil:
+			paths = append(paths, path)
+		case !os.IsNotExist(err):
+			return nil, err
+		}
+	}
+	dataDir := filepath.Join(sourceDir, dataName)
+	switch info, err := fs.Stat(dataDir); {
+	case err == nil && info.IsDir():
+		if err := vfs.Walk(fs, dataDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.Mode().IsRegular() {
+				if _, ok := formats[filepath.Ext(path)]; ok {
+					paths = append(paths, path)
+				}
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	case err == nil:
+		return nil, fmt.Errorf("%s: not a directory", dataDir)
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+	return paths, nil
+}
+
+// mergeData merges src into dst, recursing into maps. Values in src take
+// precedence over values in dst. It is an error if a key is a map in one of
+// dst or src and not a map in the other, or a list in one and not a list in the
+// other. keys is the path to dst and is used for error messages.
+func mergeData(dst, src map[string]interface{}, keys []string) error {
+	for key, srcValue := range src {
+		dstValue, ok := dst[key]
+		if !ok {
+			dst[key] = srcValue
+			continue
+		}
+		keyPath := append(keys[:len(keys):len(keys)], key)
+		dstKind, srcKind := dataKind(dstValue), dataKind(srcValue)
+		if dstKind != srcKind {
+			return fmt.Errorf("%s: cannot merge %s with %s", strings.Join(keyPath, "."), srcKind, dstKind)
+		}
+		srcMap, ok := srcValue.(map[string]interface{})
+		if !ok {
+			dst[key] = srcValue
+			continue
+		}
+		// Copy dstMap so that maps in src are never modified.
+		dstMap := make(map[string]interface{})
+		for k, v := range dstValue.(map[string]interface{}) {
+			dstMap[k] = v
+		}
+		if err := mergeData(dstMap, srcMap, keyPath); err != nil {
+			return err
+		}
+		dst[key] = dstMap
+	}
+	return nil
+}
+
+// dataKind returns a description of the kind of value.
+func dataKind(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "map"
+	case []interface{}:
+		return "list"
+	default:
+		return "value"
+	}
+}
+
+// normalizeData converts all maps in value, which may have non-string keys if
+// they were decoded from YAML, to map[string]interface{}.
+func normalizeData(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			result[fmt.Sprint(k)] = normalizeData(v)
+		}
+		return result
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			result[k] = normalizeData(v)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, v := range value {
+			result[i] = normalizeData(v)
+		}
+		return result
+	case []map[string]interface{}:
+		result := make([]interface{}, len(value))
+		for i, v := range value {
+			result[i] = normalizeData(v)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// sortedDataFormatExts returns the sorted extensions of all data formats.
+func sortedDataFormatExts() []string {
+	exts := make([]string, 0, len(formats))
+	for ext := range formats {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return exts
+}
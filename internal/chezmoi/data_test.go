@@ -0,0 +1,94 @@
+package chezmoi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeData(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		dst         map[string]interface{}
+		src         map[string]interface{}
+		expected    map[string]interface{}
+		expectedErr string
+	}{
+		{
+			name:     "empty",
+			dst:      map[string]interface{}{},
+			src:      map[string]interface{}{},
+			expected: map[string]interface{}{},
+		},
+		{
+			name: "override",
+			dst: map[string]interface{}{
+				"a": 1,
+				"b": 2,
+			},
+			src: map[string]interface{}{
+				"b": 3,
+			},
+			expected: map[string]interface{}{
+				"a": 1,
+				"b": 3,
+			},
+		},
+		{
+			name: "nested",
+			dst: map[string]interface{}{
+				"a": map[string]interface{}{
+					"b": 1,
+					"c": 2,
+				},
+			},
+			src: map[string]interface{}{
+				"a": map[string]interface{}{
+					"c": 3,
+					"d": []interface{}{4},
+				},
+			},
+			expected: map[string]interface{}{
+				"a": map[string]interface{}{
+					"b": 1,
+					"c": 3,
+					"d": []interface{}{4},
+				},
+			},
+		},
+		{
+			name: "map_conflict",
+			dst: map[string]interface{}{
+				"a": map[string]interface{}{
+					"b": map[string]interface{}{},
+				},
+			},
+			src: map[string]interface{}{
+				"a": map[string]interface{}{
+					"b": "c",
+				},
+			},
+			expectedErr: "a.b: cannot merge value with map",
+		},
+		{
+			name: "list_conflict",
+			dst: map[string]interface{}{
+				"a": []interface{}{},
+			},
+			src: map[string]interface{}{
+				"a": "b",
+			},
+			expectedErr: "a: cannot merge value with list",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := mergeData(tc.dst, tc.src, nil)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, tc.dst)
+		})
+	}
+}
@@ -67,6 +67,13 @@ func (m *DebugMutator) RunCmd(cmd *exec.Cmd) error {
 	})
 }
 
+// RunScript implements Mutator.RunScript.
+func (m *DebugMutator) RunScript(name, dir string, data []byte) error {
+	return Debugf("RunScript(%q, %q)", []interface{}{name, dir}, func() error {
+		return m.m.RunScript(name, dir, data)
+	})
+}
+
 // Stat implements Mutator.Stat.
 func (m *DebugMutator) Stat(name string) (os.FileInfo, error) {
 	var fi os.FileInfo
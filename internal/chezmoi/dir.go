@@ -9,20 +9,26 @@ import (
 	vfs "github.com/twpayne/go-vfs"
 )
 
-// DirAttributes holds attributes parsed from a source directory name.
+// DirAttributes holds attributes parsed from a source directory name. If
+// ExplicitPerm is true then Perm is exact and is not modified by the umask.
 type DirAttributes struct {
-	Name  string
-	Exact bool
-	Perm  os.FileMode
+	Name         string
+	Exact        bool
+	ExplicitPerm bool
+	Perm         os.FileMode
 }
 
 // A Dir represents the target state of a directory.
 type Dir struct {
-	sourceName string
-	targetName string
-	Exact      bool
-	Perm       os.FileMode
-	Entries    map[string]Entry
+	sourceName      string
+	targetName      string
+	Exact           bool
+	ExplicitPerm    bool
+	External        bool
+	Perm            os.FileMode
+	Entries         map[string]Entry
+	entriesErr      error
+	evaluateEntries func() error
 }
 
 type dirConcreteValue struct {
@@ -43,17 +49,26 @@ func ParseDirAttributes(sourceName string) DirAttributes {
 		name = strings.TrimPrefix(name, exactPrefix)
 		exact = true
 	}
+	name, explicitPerm, explicit := parseModePrefix(name)
 	if strings.HasPrefix(name, privatePrefix) {
 		name = strings.TrimPrefix(name, privatePrefix)
 		perm &= 0o700
 	}
+	if strings.HasPrefix(name, readOnlyPrefix) {
+		name = strings.TrimPrefix(name, readOnlyPrefix)
+		perm &^= 0o222
+	}
+	if explicit {
+		perm = explicitPerm
+	}
 	if strings.HasPrefix(name, dotPrefix) {
 		name = "." + strings.TrimPrefix(name, dotPrefix)
 	}
 	return DirAttributes{
-		Name:  name,
-		Exact: exact,
-		Perm:  perm,
+		Name:         name,
+		Exact:        exact,
+		ExplicitPerm: explicit,
+		Perm:         perm,
 	}
 }
 
@@ -63,8 +78,16 @@ func (da DirAttributes) SourceName() string {
 	if da.Exact {
 		sourceName += exactPrefix
 	}
-	if da.Perm&os.FileMode(0o77) == os.FileMode(0) {
-		sourceName += privatePrefix
+	switch {
+	case da.ExplicitPerm:
+		sourceName += modePrefixString(da.Perm)
+	default:
+		if da.Perm&os.FileMode(0o77) == os.FileMode(0) {
+			sourceName += privatePrefix
+		}
+		if da.Perm&os.FileMode(0o222) == os.FileMode(0) {
+			sourceName += readOnlyPrefix
+		}
 	}
 	if strings.HasPrefix(da.Name, ".") {
 		sourceName += dotPrefix + strings.TrimPrefix(da.Name, ".")
@@ -85,7 +108,8 @@ func newDir(sourceName, targetName string, exact bool, perm os.FileMode) *Dir {
 	}
 }
 
-// AppendAllEntries appends all Entries in d to allEntries.
+// AppendAllEntries appends all Entries in d to allEntries. Entries that are
+// evaluated lazily and have not yet been evaluated are not appended.
 func (d *Dir) AppendAllEntries(allEntries []Entry) []Entry {
 	allEntries = append(allEntries, d)
 	for _, entry := range d.Entries {
@@ -99,6 +123,9 @@ func (d *Dir) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Apply
 	if applyOptions.Ignore(d.targetName) {
 		return nil
 	}
+	if err := d.loadEntries(); err != nil {
+		return err
+	}
 	targetPath := filepath.Join(applyOptions.DestDir, d.targetName)
 	var info os.FileInfo
 	var err error
@@ -108,9 +135,19 @@ func (d *Dir) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Apply
 		info, err = fs.Lstat(targetPath)
 	}
 	switch {
+	case !applyOptions.Filter.IncludeEntry(d):
+		// d is excluded, but its entries might not be. They can only be
+		// applied if the directory already exists.
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		return d.applyEntries(fs, mutator, follow, applyOptions)
 	case err == nil && info.IsDir():
-		if info.Mode().Perm() != d.Perm&^applyOptions.Umask {
-			if err := mutator.Chmod(targetPath, d.Perm&^applyOptions.Umask); err != nil {
+		if info.Mode().Perm() != d.perm(applyOptions.Umask) {
+			if err := mutator.Chmod(targetPath, d.perm(applyOptions.Umask)); err != nil {
 				return err
 			}
 		}
@@ -120,18 +157,44 @@ func (d *Dir) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Apply
 		}
 		fallthrough
 	case os.IsNotExist(err):
-		if err := mutator.Mkdir(targetPath, d.Perm&^applyOptions.Umask); err != nil {
+		if err := mutator.Mkdir(targetPath, d.perm(applyOptions.Umask)); err != nil {
 			return err
 		}
+		// Directories are created subject to the process's umask, so
+		// explicit permissions might need to be set separately.
+		if d.ExplicitPerm && d.Perm&^applyOptions.Umask != d.Perm {
+			if err := mutator.Chmod(targetPath, d.Perm); err != nil {
+				return err
+			}
+		}
 	default:
 		return err
 	}
+	if perm := d.perm(applyOptions.Umask); perm&0o200 == 0 {
+		// The directory is read-only, so make it writable while its entries
+		// are changed.
+		readOnlyDirMutator := newReadOnlyDirMutator(mutator, targetPath, perm)
+		err := d.applyEntries(fs, readOnlyDirMutator, follow, applyOptions)
+		if restoreErr := readOnlyDirMutator.restore(); err == nil {
+			err = restoreErr
+		}
+		return err
+	}
+	return d.applyEntries(fs, mutator, follow, applyOptions)
+}
+
+// applyEntries ensures that the entries of targetPath in fs match d's entries.
+func (d *Dir) applyEntries(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions) error {
+	targetPath := filepath.Join(applyOptions.DestDir, d.targetName)
+	var errs TargetErrors
 	for _, entryName := range sortedEntryNames(d.Entries) {
 		if err := d.Entries[entryName].Apply(fs, mutator, follow, applyOptions); err != nil {
-			return err
+			if errs, err = applyOptions.appendError(errs, filepath.Join(targetPath, entryName), err); err != nil {
+				return err
+			}
 		}
 	}
-	if d.Exact {
+	if d.Exact && applyOptions.Filter.IncludeEntry(d) {
 		infos, err := fs.ReadDir(targetPath)
 		if err != nil {
 			return err
@@ -143,23 +206,28 @@ func (d *Dir) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Apply
 					continue
 				}
 				if err := mutator.RemoveAll(filepath.Join(targetPath, name)); err != nil {
-					return err
+					if errs, err = applyOptions.appendError(errs, filepath.Join(targetPath, name), err); err != nil {
+						return err
+					}
 				}
 			}
 		}
 	}
-	return nil
+	return errs.errorOrNil()
 }
 
 // ConcreteValue implements Entry.ConcreteValue.
-func (d *Dir) ConcreteValue(ignore func(string) bool, sourceDir string, umask os.FileMode, recursive bool) (interface{}, error) {
+func (d *Dir) ConcreteValue(ignore func(string) bool, filter *EntryTypeFilter, sourceDir func(Entry) string, umask os.FileMode, recursive bool) (interface{}, error) {
 	if ignore(d.targetName) {
 		return nil, nil
 	}
 	var entryConcreteValues []interface{}
 	if recursive {
+		if err := d.loadEntries(); err != nil {
+			return nil, err
+		}
 		for _, entryName := range sortedEntryNames(d.Entries) {
-			entryConcreteValue, err := d.Entries[entryName].ConcreteValue(ignore, sourceDir, umask, recursive)
+			entryConcreteValue, err := d.Entries[entryName].ConcreteValue(ignore, filter, sourceDir, umask, recursive)
 			if err != nil {
 				return nil, err
 			}
@@ -168,12 +236,17 @@ func (d *Dir) ConcreteValue(ignore func(string) bool, sourceDir string, umask os
 			}
 		}
 	}
+	// If d is excluded then it is only included as the container of its
+	// included entries.
+	if !filter.IncludeEntry(d) && len(entryConcreteValues) == 0 {
+		return nil, nil
+	}
 	return &dirConcreteValue{
 		Type:       "dir",
-		SourcePath: filepath.Join(sourceDir, d.SourceName()),
+		SourcePath: filepath.Join(sourceDir(d), d.SourceName()),
 		TargetPath: d.TargetName(),
 		Exact:      d.Exact,
-		Perm:       int(d.Perm &^ umask),
+		Perm:       int(d.perm(umask)),
 		Entries:    entryConcreteValues,
 	}, nil
 }
@@ -183,6 +256,9 @@ func (d *Dir) Evaluate(ignore func(string) bool) error {
 	if ignore(d.targetName) {
 		return nil
 	}
+	if err := d.loadEntries(); err != nil {
+		return err
+	}
 	for _, entryName := range sortedEntryNames(d.Entries) {
 		if err := d.Entries[entryName].Evaluate(ignore); err != nil {
 			return err
@@ -196,6 +272,11 @@ func (d *Dir) Private() bool {
 	return d.Perm&0o77 == 0
 }
 
+// ReadOnly returns true if d is read-only.
+func (d *Dir) ReadOnly() bool {
+	return d.Perm&0o222 == 0
+}
+
 // SourceName implements Entry.SourceName.
 func (d *Dir) SourceName() string {
 	return d.sourceName
@@ -206,20 +287,41 @@ func (d *Dir) TargetName() string {
 	return d.targetName
 }
 
+// loadEntries evaluates d's entries if they are evaluated lazily, for example
+// the contents of an external archive.
+func (d *Dir) loadEntries() error {
+	if d.evaluateEntries != nil {
+		d.entriesErr = d.evaluateEntries()
+		d.evaluateEntries = nil
+	}
+	return d.entriesErr
+}
+
+// perm returns d's permissions with umask applied, unless d's permissions are
+// explicit.
+func (d *Dir) perm(umask os.FileMode) os.FileMode {
+	return targetPerm(d.Perm, d.ExplicitPerm, umask)
+}
+
 // archive writes d to w.
-func (d *Dir) archive(w *tar.Writer, ignore func(string) bool, headerTemplate *tar.Header, umask os.FileMode) error {
+func (d *Dir) archive(w *tar.Writer, ignore func(string) bool, filter *EntryTypeFilter, headerTemplate *tar.Header, umask os.FileMode) error {
 	if ignore(d.targetName) {
 		return nil
 	}
-	header := *headerTemplate
-	header.Typeflag = tar.TypeDir
-	header.Name = d.targetName + "/"
-	header.Mode = int64(d.Perm &^ umask)
-	if err := w.WriteHeader(&header); err != nil {
+	if err := d.loadEntries(); err != nil {
 		return err
 	}
+	if filter.IncludeEntry(d) {
+		header := *headerTemplate
+		header.Typeflag = tar.TypeDir
+		header.Name = d.targetName + "/"
+		header.Mode = int64(d.perm(umask))
+		if err := w.WriteHeader(&header); err != nil {
+			return err
+		}
+	}
 	for _, entryName := range sortedEntryNames(d.Entries) {
-		if err := d.Entries[entryName].archive(w, ignore, headerTemplate, umask); err != nil {
+		if err := d.Entries[entryName].archive(w, ignore, filter, headerTemplate, umask); err != nil {
 			return err
 		}
 	}
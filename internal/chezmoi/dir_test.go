@@ -55,6 +55,22 @@ func TestDirAttributes(t *testing.T) {
 				Perm:  0o700,
 			},
 		},
+		{
+			sourceName: "readonly_foo",
+			da: DirAttributes{
+				Name: "foo",
+				Perm: 0o555,
+			},
+		},
+		{
+			sourceName: "exact_mode_0750_dot_foo",
+			da: DirAttributes{
+				Name:         ".foo",
+				Exact:        true,
+				ExplicitPerm: true,
+				Perm:         0o750,
+			},
+		},
 	} {
 		t.Run(tc.sourceName, func(t *testing.T) {
 			assert.Equal(t, tc.da, ParseDirAttributes(tc.sourceName))
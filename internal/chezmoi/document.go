@@ -0,0 +1,184 @@
+package chezmoi
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// mergeOptionsKey is the key in a partial document that holds its merge
+// options.
+const mergeOptionsKey = "chezmoi:merge"
+
+// List merge modes.
+const (
+	listsAppend  = "append"
+	listsReplace = "replace"
+)
+
+// documentMergeOptions are the options for merging a partial document.
+type documentMergeOptions struct {
+	lists  string
+	delete [][]string
+}
+
+// mergeDocumentContents returns currentContents, a structured document in the
+// format given by name's extension, with the partial document source merged
+// into it. If merging does not change the document then currentContents is
+// returned unchanged, preserving its formatting.
+func mergeDocumentContents(name string, source, currentContents []byte) ([]byte, error) {
+	ext := strings.ToLower(filepath.Ext(name))
+	format, ok := documentFormats[ext]
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported format", ext)
+	}
+
+	partial, err := decodeDocument(format, source)
+	if err != nil {
+		return nil, fmt.Errorf("source: %w", err)
+	}
+	options, err := parseDocumentMergeOptions(partial[mergeOptionsKey])
+	if err != nil {
+		return nil, err
+	}
+	delete(partial, mergeOptionsKey)
+
+	current, err := decodeDocument(format, currentContents)
+	if err != nil {
+		return nil, fmt.Errorf("destination: %w", err)
+	}
+	merged, err := decodeDocument(format, currentContents)
+	if err != nil {
+		return nil, err
+	}
+	mergeDocument(merged, partial, options)
+	for _, keys := range options.delete {
+		deleteDocumentKey(merged, keys)
+	}
+	if len(currentContents) != 0 && reflect.DeepEqual(merged, current) {
+		return currentContents, nil
+	}
+
+	b := &bytes.Buffer{}
+	if err := format.encode(b, merged); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// decodeDocument decodes data in format. Empty data is an empty document.
+func decodeDocument(format documentFormat, data []byte) (map[string]interface{}, error) {
+	if isEmpty(data) {
+		return make(map[string]interface{}), nil
+	}
+	var document map[string]interface{}
+	if err := format.decode(data, &document); err != nil {
+		return nil, err
+	}
+	if document == nil {
+		return make(map[string]interface{}), nil
+	}
+	return normalizeData(document).(map[string]interface{}), nil
+}
+
+// parseDocumentMergeOptions parses the merge options in value.
+func parseDocumentMergeOptions(value interface{}) (*documentMergeOptions, error) {
+	options := &documentMergeOptions{
+		lists: listsReplace,
+	}
+	if value == nil {
+		return options, nil
+	}
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: not a map", mergeOptionsKey)
+	}
+	for key, value := range m {
+		switch key {
+		case "delete":
+			var paths []string
+			switch value := value.(type) {
+			case string:
+				// INI documents can only contain strings, so allow a comma
+				// separated list of key paths.
+				paths = strings.Split(value, ",")
+			case []interface{}:
+				for _, element := range value {
+					path, ok := element.(string)
+					if !ok {
+						return nil, fmt.Errorf("%s.delete: %v: not a string", mergeOptionsKey, element)
+					}
+					paths = append(paths, path)
+				}
+			default:
+				return nil, fmt.Errorf("%s.delete: not a list", mergeOptionsKey)
+			}
+			for _, path := range paths {
+				if path = strings.TrimSpace(path); path != "" {
+					options.delete = append(options.delete, strings.Split(path, "."))
+				}
+			}
+		case "lists":
+			switch value {
+			case listsAppend, listsReplace:
+				options.lists = value.(string)
+			default:
+				return nil, fmt.Errorf("%s.lists: %v: unknown mode", mergeOptionsKey, value)
+			}
+		default:
+			return nil, fmt.Errorf("%s.%s: unknown option", mergeOptionsKey, key)
+		}
+	}
+	return options, nil
+}
+
+// mergeDocument merges src into dst, recursing into maps. Values in src
+// replace values in dst, except for lists when options.lists is append, in
+// which case the elements of the list in src that are not already in the list
+// in dst are appended to it.
+func mergeDocument(dst, src map[string]interface{}, options *documentMergeOptions) {
+	for key, srcValue := range src {
+		switch srcValue := srcValue.(type) {
+		case map[string]interface{}:
+			if dstMap, ok := dst[key].(map[string]interface{}); ok {
+				mergeDocument(dstMap, srcValue, options)
+				continue
+			}
+		case []interface{}:
+			if dstList, ok := dst[key].([]interface{}); ok && options.lists == listsAppend {
+				for _, srcElement := range srcValue {
+					if !containsDocumentValue(dstList, srcElement) {
+						dstList = append(dstList, srcElement)
+					}
+				}
+				dst[key] = dstList
+				continue
+			}
+		}
+		dst[key] = srcValue
+	}
+}
+
+// containsDocumentValue returns true if list contains value.
+func containsDocumentValue(list []interface{}, value interface{}) bool {
+	for _, element := range list {
+		if reflect.DeepEqual(element, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteDocumentKey deletes the key at keys from document, if it exists.
+func deleteDocumentKey(document map[string]interface{}, keys []string) {
+	for _, key := range keys[:len(keys)-1] {
+		m, ok := document[key].(map[string]interface{})
+		if !ok {
+			return
+		}
+		document = m
+	}
+	delete(document, keys[len(keys)-1])
+}
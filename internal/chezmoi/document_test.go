@@ -0,0 +1,140 @@
+package chezmoi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeDocumentContents(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		fileName        string
+		source          string
+		currentContents string
+		expected        string
+		expectedErr     bool
+	}{
+		{
+			name:     "json_missing",
+			fileName: "file.json",
+			source:   `{"a":1}`,
+			expected: "{\n  \"a\": 1\n}\n",
+		},
+		{
+			name:            "json_merge",
+			fileName:        "file.json",
+			source:          `{"a":{"b":2},"c":3}`,
+			currentContents: `{"a":{"b":1,"d":4},"e":5}`,
+			expected:        "{\n  \"a\": {\n    \"b\": 2,\n    \"d\": 4\n  },\n  \"c\": 3,\n  \"e\": 5\n}\n",
+		},
+		{
+			name:            "json_unchanged",
+			fileName:        "file.json",
+			source:          `{"a":1}`,
+			currentContents: `{"a": 1, "b": 2}`,
+			expected:        `{"a": 1, "b": 2}`,
+		},
+		{
+			name:            "json_large_integers_and_html",
+			fileName:        "file.json",
+			source:          `{"a":"<b>&</b>"}`,
+			currentContents: `{"b":9007199254740993}`,
+			expected:        "{\n  \"a\": \"<b>&</b>\",\n  \"b\": 9007199254740993\n}\n",
+		},
+		{
+			name:            "json_trailing_data",
+			fileName:        "file.json",
+			source:          `{"a":1}`,
+			currentContents: `{"b":2} {"c":3}`,
+			expectedErr:     true,
+		},
+		{
+			name:            "json_lists_replace",
+			fileName:        "file.json",
+			source:          `{"a":[2,3]}`,
+			currentContents: `{"a":[1,2]}`,
+			expected:        "{\n  \"a\": [\n    2,\n    3\n  ]\n}\n",
+		},
+		{
+			name:            "json_lists_append",
+			fileName:        "file.json",
+			source:          `{"chezmoi:merge":{"lists":"append"},"a":[2,3]}`,
+			currentContents: `{"a":[1,2]}`,
+			expected:        "{\n  \"a\": [\n    1,\n    2,\n    3\n  ]\n}\n",
+		},
+		{
+			name:            "json_delete",
+			fileName:        "file.json",
+			source:          `{"chezmoi:merge":{"delete":["a.b","c"]}}`,
+			currentContents: `{"a":{"b":1,"d":4},"c":3}`,
+			expected:        "{\n  \"a\": {\n    \"d\": 4\n  }\n}\n",
+		},
+		{
+			name:            "yaml_merge",
+			fileName:        "file.yaml",
+			source:          "a:\n  b: 2\n",
+			currentContents: "a:\n  b: 1\n  c: 3\n",
+			expected:        "a:\n  b: 2\n  c: 3\n",
+		},
+		{
+			name:            "toml_merge",
+			fileName:        "file.toml",
+			source:          "[a]\nb = 2\n",
+			currentContents: "c = 3\n\n[a]\nb = 1\n",
+			expected:        "c = 3\n\n[a]\n  b = 2\n",
+		},
+		{
+			name:            "ini_merge",
+			fileName:        "file.ini",
+			source:          "[core]\neditor = vim\n",
+			currentContents: "[core]\neditor = vi\npager = less\n",
+			expected:        "[core]\neditor = vim\npager  = less\n",
+		},
+		{
+			name:            "ini_delete",
+			fileName:        "file.ini",
+			source:          "[chezmoi:merge]\ndelete = core.pager\n",
+			currentContents: "[core]\neditor = vi\npager = less\n",
+			expected:        "[core]\neditor = vi\n",
+		},
+		{
+			name:            "unknown_option",
+			fileName:        "file.json",
+			source:          `{"chezmoi:merge":{"unknown":true}}`,
+			currentContents: `{}`,
+			expectedErr:     true,
+		},
+		{
+			name:            "unknown_lists_mode",
+			fileName:        "file.json",
+			source:          `{"chezmoi:merge":{"lists":"merge"}}`,
+			currentContents: `{}`,
+			expectedErr:     true,
+		},
+		{
+			name:        "unsupported_format",
+			fileName:    "file.txt",
+			source:      "a\n",
+			expectedErr: true,
+		},
+		{
+			name:            "invalid_destination",
+			fileName:        "file.json",
+			source:          `{"a":1}`,
+			currentContents: `{`,
+			expectedErr:     true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := mergeDocumentContents(tc.fileName, []byte(tc.source), []byte(tc.currentContents))
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, string(actual))
+		})
+	}
+}
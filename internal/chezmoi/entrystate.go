@@ -0,0 +1,193 @@
+package chezmoi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"syscall"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// A Drift describes why a target differs from its target state, relative to
+// the state that chezmoi last wrote to it.
+type Drift int
+
+// Drifts.
+const (
+	// DriftSource indicates that the target state has changed.
+	DriftSource Drift = 1 << iota
+	// DriftLocal indicates that the target has changed in the destination
+	// directory.
+	DriftLocal
+)
+
+// An EntryState represents the state of a target as last written by chezmoi.
+type EntryState struct {
+	Mode           os.FileMode `json:"mode"`
+	ContentsSHA256 string      `json:"contentsSHA256,omitempty"`
+}
+
+// String returns a human-readable description of d.
+func (d Drift) String() string {
+	switch d {
+	case DriftSource:
+		return "changed in source state"
+	case DriftLocal:
+		return "changed locally"
+	case DriftLocal | DriftSource:
+		return "changed locally and in source state"
+	default:
+		return "unchanged"
+	}
+}
+
+// newFileEntryState returns the state of a file with contents and perm.
+func newFileEntryState(contents []byte, perm os.FileMode) *EntryState {
+	return &EntryState{
+		Mode:           perm,
+		ContentsSHA256: sha256Sum(contents),
+	}
+}
+
+// newSymlinkEntryState returns the state of a symlink to linkname.
+func newSymlinkEntryState(linkname string) *EntryState {
+	return &EntryState{
+		Mode:           os.ModeSymlink,
+		ContentsSHA256: sha256Sum([]byte(linkname)),
+	}
+}
+
+// Equal returns true if s is equal to other. A nil *EntryState represents a
+// target that does not exist.
+func (s *EntryState) Equal(other *EntryState) bool {
+	if s == nil || other == nil {
+		return s == nil && other == nil
+	}
+	return *s == *other
+}
+
+// checkDrift reports how the target at targetPath has drifted from the state
+// that chezmoi last wrote to it, given its actual state and its target state,
+// and returns whether the target should be updated. If the target has changed
+// both locally and in the source state then the conflict is resolved by
+// o.Conflict. Differences in targets without a recorded state are attributed
+// to the source state.
+func (o *ApplyOptions) checkDrift(targetPath string, actual, target *EntryState) (bool, error) {
+	if o.PersistentState == nil || o.EntryStateBucket == nil {
+		return true, nil
+	}
+	recorded, err := o.getEntryState(targetPath)
+	if err != nil {
+		return false, err
+	}
+	drift := DriftSource
+	if recorded != nil {
+		drift = 0
+		if !recorded.Equal(actual) {
+			drift |= DriftLocal
+		}
+		if !recorded.Equal(target) {
+			drift |= DriftSource
+		}
+	}
+	if o.ReportDrift != nil {
+		o.ReportDrift(targetPath, drift)
+	}
+	if drift == DriftLocal|DriftSource && o.Conflict != nil {
+		return o.Conflict(targetPath)
+	}
+	return true, nil
+}
+
+// getEntryState returns the state recorded for targetPath, or nil if there is
+// no recorded state.
+func (o *ApplyOptions) getEntryState(targetPath string) (*EntryState, error) {
+	data, err := o.PersistentState.Get(o.EntryStateBucket, []byte(targetPath))
+	if err != nil || data == nil {
+		return nil, err
+	}
+	var entryState EntryState
+	if err := json.Unmarshal(data, &entryState); err != nil {
+		return nil, err
+	}
+	return &entryState, nil
+}
+
+// recordEntryState records that targetPath has state entryState.
+func (o *ApplyOptions) recordEntryState(targetPath string, entryState *EntryState) error {
+	if o.PersistentState == nil || o.EntryStateBucket == nil || o.DryRun {
+		return nil
+	}
+	recorded, err := o.getEntryState(targetPath)
+	if err != nil {
+		return err
+	}
+	if recorded.Equal(entryState) {
+		return nil
+	}
+	data, err := json.Marshal(entryState)
+	if err != nil {
+		return err
+	}
+	return o.PersistentState.Set(o.EntryStateBucket, []byte(targetPath), data)
+}
+
+// recordAppliedEntryState records that targetPath has state entryState if
+// targetPath in fs now has that state. Changes can be skipped by the mutator,
+// for example in interactive mode, in which case the previously recorded state
+// is kept.
+func (o *ApplyOptions) recordAppliedEntryState(fs vfs.FS, targetPath string, follow bool, entryState *EntryState) error {
+	if o.PersistentState == nil || o.EntryStateBucket == nil || o.DryRun {
+		return nil
+	}
+	actual, err := readEntryState(fs, targetPath, follow)
+	if err != nil {
+		return err
+	}
+	if !actual.Equal(entryState) {
+		return nil
+	}
+	return o.recordEntryState(targetPath, entryState)
+}
+
+// readEntryState returns the state of targetPath in fs, or nil if it does not
+// exist. A targetPath whose parent is not a directory, for example because the
+// parent has not yet been replaced by a directory in a dry run, does not exist.
+func readEntryState(fs vfs.FS, targetPath string, follow bool) (*EntryState, error) {
+	var info os.FileInfo
+	var err error
+	if follow {
+		info, err = fs.Stat(targetPath)
+	} else {
+		info, err = fs.Lstat(targetPath)
+	}
+	switch {
+	case os.IsNotExist(err) || errors.Is(err, syscall.ENOTDIR):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	case info.Mode().IsRegular():
+		contents, err := fs.ReadFile(targetPath)
+		if err != nil {
+			return nil, err
+		}
+		return newFileEntryState(contents, info.Mode().Perm()), nil
+	case info.Mode()&os.ModeType == os.ModeSymlink:
+		linkname, err := fs.Readlink(targetPath)
+		if err != nil {
+			return nil, err
+		}
+		return newSymlinkEntryState(linkname), nil
+	default:
+		return &EntryState{Mode: info.Mode()}, nil
+	}
+}
+
+// sha256Sum returns the hex-encoded SHA256 sum of data.
+func sha256Sum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
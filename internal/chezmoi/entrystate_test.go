@@ -0,0 +1,106 @@
+package chezmoi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestApplyEntryState(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		destContents     string
+		sourceContents   string
+		expectedDrift    Drift
+		expectedConflict bool
+		expectedContents string
+	}{
+		{
+			name:             "unchanged",
+			destContents:     "applied\n",
+			sourceContents:   "applied\n",
+			expectedContents: "applied\n",
+		},
+		{
+			name:             "changed_locally",
+			destContents:     "local\n",
+			sourceContents:   "applied\n",
+			expectedDrift:    DriftLocal,
+			expectedContents: "applied\n",
+		},
+		{
+			name:             "changed_in_source",
+			destContents:     "applied\n",
+			sourceContents:   "source\n",
+			expectedDrift:    DriftSource,
+			expectedContents: "source\n",
+		},
+		{
+			name:             "changed_locally_and_in_source",
+			destContents:     "local\n",
+			sourceContents:   "source\n",
+			expectedDrift:    DriftLocal | DriftSource,
+			expectedConflict: true,
+			expectedContents: "local\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user/.config/chezmoi": &vfst.Dir{Perm: 0o755},
+				"/home/user/.foo":            "applied\n",
+			})
+			require.NoError(t, err)
+			defer cleanup()
+
+			persistentState, err := NewBoltPersistentState(fs, "/home/user/.config/chezmoi/chezmoistate.boltdb", nil)
+			require.NoError(t, err)
+			defer persistentState.Close()
+
+			var drift Drift
+			conflict := false
+			applyOptions := &ApplyOptions{
+				Conflict: func(targetPath string) (bool, error) {
+					assert.Equal(t, "/home/user/.foo", targetPath)
+					conflict = true
+					return false, nil
+				},
+				DestDir:          "/home/user",
+				EntryStateBucket: []byte("entryState"),
+				Ignore:           func(string) bool { return false },
+				PersistentState:  persistentState,
+				ReportDrift: func(targetPath string, d Drift) {
+					drift = d
+				},
+				Umask: 0o22,
+			}
+			mutator := NewFSMutator(fs)
+
+			applied := &File{
+				sourceName: "dot_foo",
+				targetName: ".foo",
+				Perm:       0o644,
+				contents:   []byte("applied\n"),
+			}
+			require.NoError(t, applied.Apply(fs, mutator, false, applyOptions))
+			assert.Equal(t, Drift(0), drift)
+
+			require.NoError(t, fs.WriteFile("/home/user/.foo", []byte(tc.destContents), 0o644))
+			source := &File{
+				sourceName: "dot_foo",
+				targetName: ".foo",
+				Perm:       0o644,
+				contents:   []byte(tc.sourceContents),
+			}
+			require.NoError(t, source.Apply(fs, mutator, false, applyOptions))
+			assert.Equal(t, tc.expectedDrift, drift)
+			assert.Equal(t, tc.expectedConflict, conflict)
+			vfst.RunTests(t, fs, "",
+				vfst.TestPath("/home/user/.foo",
+					vfst.TestContentsString(tc.expectedContents),
+				),
+			)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package chezmoi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// An EntryTypeSet is a set of entry types and attributes.
+type EntryTypeSet int
+
+// Entry types and attributes.
+const (
+	EntryTypeDirs EntryTypeSet = 1 << iota
+	EntryTypeFiles
+	EntryTypeSymlinks
+	EntryTypeScripts
+	EntryTypeEncrypted
+	EntryTypeTemplates
+	EntryTypeOnce
+
+	EntryTypesAll  EntryTypeSet = EntryTypeDirs | EntryTypeFiles | EntryTypeSymlinks | EntryTypeScripts | EntryTypeEncrypted | EntryTypeTemplates | EntryTypeOnce
+	EntryTypesNone EntryTypeSet = 0
+)
+
+// An EntryTypeFilter selects entries by their types and attributes. An entry
+// is included if any of its type and attributes are in Include and none of
+// them are in Exclude. A nil *EntryTypeFilter includes all entries.
+type EntryTypeFilter struct {
+	Include EntryTypeSet
+	Exclude EntryTypeSet
+}
+
+var entryTypeSetNames = map[string]EntryTypeSet{
+	"all":       EntryTypesAll,
+	"d":         EntryTypeDirs,
+	"dirs":      EntryTypeDirs,
+	"encrypted": EntryTypeEncrypted,
+	"f":         EntryTypeFiles,
+	"files":     EntryTypeFiles,
+	"none":      EntryTypesNone,
+	"once":      EntryTypeOnce,
+	"s":         EntryTypeSymlinks,
+	"scripts":   EntryTypeScripts,
+	"symlinks":  EntryTypeSymlinks,
+	"templates": EntryTypeTemplates,
+}
+
+// ParseEntryTypeSet parses the entry types and attributes in names.
+func ParseEntryTypeSet(names []string) (EntryTypeSet, error) {
+	s := EntryTypesNone
+	for _, name := range names {
+		bits, ok := entryTypeSetNames[strings.ToLower(name)]
+		if !ok {
+			return EntryTypesNone, fmt.Errorf("%s: unknown entry type", name)
+		}
+		s |= bits
+	}
+	return s, nil
+}
+
+// IncludeEntry returns true if entry is included by f.
+func (f *EntryTypeFilter) IncludeEntry(entry Entry) bool {
+	if f == nil {
+		return true
+	}
+	bits := entryTypeSet(entry)
+	return bits&f.Include != 0 && bits&f.Exclude == 0
+}
+
+// entryTypeSet returns the type and attributes of entry.
+func entryTypeSet(entry Entry) EntryTypeSet {
+	switch entry := entry.(type) {
+	case *Dir, *GitRepo:
+		return EntryTypeDirs
+	case *File:
+		bits := EntryTypeFiles
+		if entry.Encrypted {
+			bits |= EntryTypeEncrypted
+		}
+		if entry.Template {
+			bits |= EntryTypeTemplates
+		}
+		return bits
+	case *Script:
+		bits := EntryTypeScripts
+		if entry.Encrypted {
+			bits |= EntryTypeEncrypted
+		}
+		if entry.Template {
+			bits |= EntryTypeTemplates
+		}
+		if entry.Once {
+			bits |= EntryTypeOnce
+		}
+		return bits
+	case *Symlink:
+		bits := EntryTypeSymlinks
+		if entry.Template {
+			bits |= EntryTypeTemplates
+		}
+		return bits
+	default:
+		return EntryTypesNone
+	}
+}
@@ -0,0 +1,78 @@
+package chezmoi
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEntryTypeSet(t *testing.T) {
+	for _, tc := range []struct {
+		names       []string
+		expected    EntryTypeSet
+		expectedErr bool
+	}{
+		{
+			expected: EntryTypesNone,
+		},
+		{
+			names:    []string{"all"},
+			expected: EntryTypesAll,
+		},
+		{
+			names:    []string{"d", "files", "Symlinks"},
+			expected: EntryTypeDirs | EntryTypeFiles | EntryTypeSymlinks,
+		},
+		{
+			names:    []string{"scripts", "once"},
+			expected: EntryTypeScripts | EntryTypeOnce,
+		},
+		{
+			names:       []string{"files", "unknown"},
+			expectedErr: true,
+		},
+	} {
+		t.Run(strings.Join(tc.names, "_"), func(t *testing.T) {
+			actual, err := ParseEntryTypeSet(tc.names)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestEntryTypeFilterIncludeEntry(t *testing.T) {
+	dir := &Dir{}
+	file := &File{}
+	encryptedFile := &File{Encrypted: true}
+	templateSymlink := &Symlink{Template: true}
+	onceScript := &Script{Once: true}
+
+	for _, tc := range []struct {
+		name     string
+		filter   *EntryTypeFilter
+		entry    Entry
+		expected bool
+	}{
+		{name: "nil_dir", entry: dir, expected: true},
+		{name: "all_dir", filter: &EntryTypeFilter{Include: EntryTypesAll}, entry: dir, expected: true},
+		{name: "none_file", filter: &EntryTypeFilter{Include: EntryTypesNone}, entry: file, expected: false},
+		{name: "files_file", filter: &EntryTypeFilter{Include: EntryTypeFiles}, entry: file, expected: true},
+		{name: "files_dir", filter: &EntryTypeFilter{Include: EntryTypeFiles}, entry: dir, expected: false},
+		{name: "encrypted_file", filter: &EntryTypeFilter{Include: EntryTypeEncrypted}, entry: file, expected: false},
+		{name: "encrypted_encrypted_file", filter: &EntryTypeFilter{Include: EntryTypeEncrypted}, entry: encryptedFile, expected: true},
+		{name: "all_except_encrypted_encrypted_file", filter: &EntryTypeFilter{Include: EntryTypesAll, Exclude: EntryTypeEncrypted}, entry: encryptedFile, expected: false},
+		{name: "templates_template_symlink", filter: &EntryTypeFilter{Include: EntryTypeTemplates}, entry: templateSymlink, expected: true},
+		{name: "all_except_scripts_once_script", filter: &EntryTypeFilter{Include: EntryTypesAll, Exclude: EntryTypeScripts}, entry: onceScript, expected: false},
+		{name: "once_once_script", filter: &EntryTypeFilter{Include: EntryTypeOnce}, entry: onceScript, expected: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.filter.IncludeEntry(tc.entry))
+		})
+	}
+}
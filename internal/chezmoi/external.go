@@ -0,0 +1,451 @@
+package chezmoi
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml"
+	vfs "github.com/twpayne/go-vfs"
+	"gopkg.in/yaml.v2"
+)
+
+// External types.
+const (
+	externalTypeArchive = "archive"
+	externalTypeFile    = "file"
+	externalTypeGitRepo = "git-repo"
+)
+
+// An external is an entry in a .chezmoiexternal file.
+type external struct {
+	Type            string `json:"type" toml:"type" yaml:"type"`
+	URL             string `json:"url" toml:"url" yaml:"url"`
+	Checksum        string `json:"checksum" toml:"checksum" yaml:"checksum"`
+	Exact           bool   `json:"exact" toml:"exact" yaml:"exact"`
+	Executable      bool   `json:"executable" toml:"executable" yaml:"executable"`
+	Format          string `json:"format" toml:"format" yaml:"format"`
+	Ref             string `json:"ref" toml:"ref" yaml:"ref"`
+	RefreshPeriod   string `json:"refreshPeriod" toml:"refreshPeriod" yaml:"refreshPeriod"`
+	StripComponents int    `json:"stripComponents" toml:"stripComponents" yaml:"stripComponents"`
+}
+
+// formats maps file extensions to decoders.
+var formats = map[string]func([]byte, interface{}) error{
+	".json": json.Unmarshal,
+	".toml": toml.Unmarshal,
+	".yaml": yaml.Unmarshal,
+}
+
+// IsExternal returns true if entry is populated from a .chezmoiexternal file.
+func IsExternal(entry Entry) bool {
+	switch entry := entry.(type) {
+	case *Dir:
+		return entry.External
+	case *File:
+		return entry.External
+	case *Symlink:
+		return entry.External
+	case *GitRepo:
+		return true
+	default:
+		return false
+	}
+}
+
+// isExternalName returns true if name is the name of a .chezmoiexternal file.
+func isExternalName(name string) bool {
+	ext := filepath.Ext(name)
+	if _, ok := formats[ext]; !ok {
+		return false
+	}
+	return strings.TrimSuffix(name, ext) == externalName
+}
+
+// isValidExternalName returns true if name, the name of an entry in a
+// .chezmoiexternal file, is a relative path that does not leave the directory
+// containing the file, so that externals are never written outside the
+// destination directory.
+func isValidExternalName(name string) bool {
+	cleanName := filepath.ToSlash(filepath.Clean(filepath.FromSlash(name)))
+	switch {
+	case filepath.IsAbs(filepath.FromSlash(name)) || strings.HasPrefix(cleanName, "/"):
+		return false
+	case cleanName == "." || cleanName == "..":
+		return false
+	default:
+		return !strings.HasPrefix(cleanName, "../")
+	}
+}
+
+// addExternals reads the .chezmoiexternal file at sourceName in sourceDir and
+// adds its entries to ts.
+func (ts *TargetState) addExternals(fs vfs.FS, sourceDir, sourceName string) error {
+	path := filepath.Join(sourceDir, sourceName)
+	data, err := ts.executeTemplate(fs, path)
+	if err != nil {
+		return err
+	}
+	externals := make(map[string]external)
+	if err := formats[filepath.Ext(path)](data, &externals); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	var dns []string
+	if filepath.Dir(sourceName) != "." {
+		dns, err = ts.dirNames(parseDirNameComponents(splitPathList(filepath.Dir(sourceName))))
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	for _, name := range sortedExternalNames(externals) {
+		if !isValidExternalName(name) {
+			return fmt.Errorf("%s: %s: invalid name", path, name)
+		}
+		e := externals[name]
+		targetName := filepath.Join(append(dns, filepath.FromSlash(name))...)
+		if err := ts.addExternal(sourceDir, sourceName, targetName, &e); err != nil {
+			return fmt.Errorf("%s: %s: %w", path, name, err)
+		}
+	}
+	return nil
+}
+
+// addExternal adds the external e at targetName to ts.
+func (ts *TargetState) addExternal(sourceDir, sourceName, targetName string, e *external) error {
+	components := splitPathList(targetName)
+	entries, err := findOrCreateExternalDirs(ts.Entries, sourceName, "", components[:len(components)-1])
+	if err != nil {
+		return err
+	}
+	name := filepath.Base(targetName)
+	if _, ok := entries[name]; ok {
+		return fmt.Errorf("%s: already in source state", targetName)
+	}
+	var refreshPeriod time.Duration
+	if e.RefreshPeriod != "" {
+		refreshPeriod, err = time.ParseDuration(e.RefreshPeriod)
+		if err != nil {
+			return err
+		}
+	}
+	switch e.Type {
+	case externalTypeArchive:
+		// Like the contents of external files, archives are only read when
+		// their entries are needed.
+		dir := newDir(sourceName, targetName, e.Exact, 0o777)
+		dir.External = true
+		dir.evaluateEntries = func() error {
+			data, err := ts.readExternal(e.URL, e.Checksum, refreshPeriod)
+			if err != nil {
+				return fmt.Errorf("%s: %w", targetName, err)
+			}
+			if err := addExternalArchive(dir, data, externalArchiveFormat(e), e.StripComponents); err != nil {
+				return fmt.Errorf("%s: %w", targetName, err)
+			}
+			for _, entry := range dir.AppendAllEntries(nil) {
+				ts.setEntrySourceDir(entry, sourceDir)
+			}
+			return nil
+		}
+		entries[name] = dir
+	case externalTypeFile, "":
+		perm := os.FileMode(0o666)
+		if e.Executable {
+			perm = 0o777
+		}
+		entries[name] = &File{
+			sourceName: sourceName,
+			targetName: targetName,
+			External:   true,
+			Perm:       perm,
+			evaluateContents: func() ([]byte, error) {
+				return ts.readExternal(e.URL, e.Checksum, refreshPeriod)
+			},
+		}
+	case externalTypeGitRepo:
+		entries[name] = &GitRepo{
+			sourceName: sourceName,
+			targetName: targetName,
+			URL:        e.URL,
+			Ref:        e.Ref,
+			gitCommand: ts.GitCommand,
+		}
+	default:
+		return fmt.Errorf("%s: unknown type", e.Type)
+	}
+
+	// Record the source directory of all the entries that were created.
+	for i := range components[:len(components)-1] {
+		if dir, err := ts.findEntry(filepath.Join(components[:i+1]...)); err == nil && IsExternal(dir) && dir.SourceName() == sourceName {
+			ts.setEntrySourceDir(dir, sourceDir)
+		}
+	}
+	for _, entry := range entries[name].AppendAllEntries(nil) {
+		ts.setEntrySourceDir(entry, sourceDir)
+	}
+	return nil
+}
+
+// readExternal returns the contents of rawURL, using the cache in
+// ts.CacheDir if it is newer than refreshPeriod. A refreshPeriod of zero means
+// that cached contents never expire. If checksum is not empty then the SHA256
+// sum of the contents must match it.
+func (ts *TargetState) readExternal(rawURL, checksum string, refreshPeriod time.Duration) ([]byte, error) {
+	var cachePath string
+	if ts.CacheFS != nil && ts.CacheDir != "" {
+		urlSum := sha256.Sum256([]byte(rawURL))
+		cachePath = filepath.Join(ts.CacheDir, "external", hex.EncodeToString(urlSum[:]))
+		if info, err := ts.CacheFS.Stat(cachePath); err == nil && (refreshPeriod == 0 || time.Since(info.ModTime()) < refreshPeriod) {
+			data, err := ts.CacheFS.ReadFile(cachePath)
+			if err != nil {
+				return nil, err
+			}
+			if err := verifyExternalChecksum(rawURL, checksum, data); err == nil {
+				return data, nil
+			}
+		}
+	}
+
+	data, err := fetchExternal(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyExternalChecksum(rawURL, checksum, data); err != nil {
+		return nil, err
+	}
+
+	if cachePath != "" {
+		if err := vfs.MkdirAll(ts.CacheFS, filepath.Dir(cachePath), 0o777&^ts.Umask); err != nil {
+			return nil, err
+		}
+		if err := ts.CacheFS.WriteFile(cachePath, data, 0o666&^ts.Umask); err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+// addExternalArchive adds the contents of the archive data in format to dir,
+// stripping the first stripComponents components of each path.
+func addExternalArchive(dir *Dir, data []byte, format string, stripComponents int) error {
+	addEntry := func(name string, info os.FileInfo, contents []byte, linkname string) error {
+		cleanName := strings.Trim(path.Clean(filepath.ToSlash(name)), "/")
+		if cleanName == "." || cleanName == "" {
+			return nil
+		}
+		components := strings.Split(cleanName, "/")
+		if len(components) <= stripComponents {
+			return nil
+		}
+		components = components[stripComponents:]
+		for _, component := range components {
+			if component == ".." {
+				return fmt.Errorf("%s: invalid path", name)
+			}
+		}
+		entries, err := findOrCreateExternalDirs(dir.Entries, dir.sourceName, dir.targetName, components[:len(components)-1])
+		if err != nil {
+			return err
+		}
+		targetName := filepath.Join(append([]string{dir.targetName}, components...)...)
+		entryName := components[len(components)-1]
+		switch {
+		case info.IsDir():
+			if entry, ok := entries[entryName]; ok {
+				if subDir, ok := entry.(*Dir); ok {
+					subDir.Perm = info.Mode().Perm()
+					return nil
+				}
+				return fmt.Errorf("%s: not a directory", targetName)
+			}
+			subDir := newDir(dir.sourceName, targetName, false, info.Mode().Perm())
+			subDir.External = true
+			entries[entryName] = subDir
+		case info.Mode().IsRegular():
+			entries[entryName] = &File{
+				sourceName: dir.sourceName,
+				targetName: targetName,
+				External:   true,
+				Empty:      len(contents) == 0,
+				Perm:       info.Mode().Perm(),
+				contents:   contents,
+			}
+		case info.Mode()&os.ModeType == os.ModeSymlink:
+			entries[entryName] = &Symlink{
+				sourceName: dir.sourceName,
+				targetName: targetName,
+				External:   true,
+				linkname:   linkname,
+			}
+		default:
+			return fmt.Errorf("%s: unsupported file type", targetName)
+		}
+		return nil
+	}
+
+	switch format {
+	case "tar", "tar.bz2", "tar.gz", "tbz2", "tgz":
+		var r io.Reader = bytes.NewReader(data)
+		switch format {
+		case "tar.bz2", "tbz2":
+			r = bzip2.NewReader(r)
+		case "tar.gz", "tgz":
+			gzipReader, err := gzip.NewReader(r)
+			if err != nil {
+				return err
+			}
+			defer gzipReader.Close()
+			r = gzipReader
+		}
+		tarReader := tar.NewReader(r)
+		for {
+			header, err := tarReader.Next()
+			if errors.Is(err, io.EOF) {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			switch header.Typeflag {
+			case tar.TypeDir, tar.TypeReg, tar.TypeSymlink:
+				contents, err := ioutil.ReadAll(tarReader)
+				if err != nil {
+					return err
+				}
+				if err := addEntry(header.Name, header.FileInfo(), contents, header.Linkname); err != nil {
+					return err
+				}
+			case tar.TypeXGlobalHeader:
+			default:
+				return fmt.Errorf("%s: unsupported typeflag '%c'", header.Name, header.Typeflag)
+			}
+		}
+	case "zip":
+		zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return err
+		}
+		for _, zipFile := range zipReader.File {
+			r, err := zipFile.Open()
+			if err != nil {
+				return err
+			}
+			contents, err := ioutil.ReadAll(r)
+			r.Close()
+			if err != nil {
+				return err
+			}
+			info := zipFile.FileInfo()
+			var linkname string
+			if info.Mode()&os.ModeType == os.ModeSymlink {
+				linkname = string(contents)
+			}
+			if err := addEntry(zipFile.Name, info, contents, linkname); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("%s: unknown archive format", format)
+	}
+}
+
+// externalArchiveFormat returns the archive format of e, guessing it from the
+// URL if it is not set explicitly.
+func externalArchiveFormat(e *external) string {
+	if e.Format != "" {
+		return e.Format
+	}
+	urlPath := e.URL
+	if u, err := url.Parse(e.URL); err == nil {
+		urlPath = u.Path
+	}
+	for _, format := range []string{"tar.bz2", "tar.gz", "tbz2", "tgz", "tar", "zip"} {
+		if strings.HasSuffix(urlPath, "."+format) {
+			return format
+		}
+	}
+	return ""
+}
+
+// fetchExternal returns the contents of rawURL. http, https, and file URLs are
+// supported.
+func fetchExternal(rawURL string) ([]byte, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.RegisterProtocol("file", http.NewFileTransport(http.Dir("/")))
+	client := &http.Client{
+		Transport: transport,
+	}
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", rawURL, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// findOrCreateExternalDirs returns the entries of the directory dirNames in
+// entries, creating any missing directories with sourceName. The target names
+// of any created directories are relative to targetDirName.
+func findOrCreateExternalDirs(entries map[string]Entry, sourceName, targetDirName string, dirNames []string) (map[string]Entry, error) {
+	for i, dirName := range dirNames {
+		targetName := filepath.Join(append([]string{targetDirName}, dirNames[:i+1]...)...)
+		entry, ok := entries[dirName]
+		if !ok {
+			dir := newDir(sourceName, targetName, false, 0o777)
+			dir.External = true
+			entries[dirName] = dir
+			entries = dir.Entries
+			continue
+		}
+		dir, ok := entry.(*Dir)
+		if !ok {
+			return nil, fmt.Errorf("%s: not a directory", targetName)
+		}
+		entries = dir.Entries
+	}
+	return entries, nil
+}
+
+// sortedExternalNames returns the sorted names of externals.
+func sortedExternalNames(externals map[string]external) []string {
+	names := make([]string, 0, len(externals))
+	for name := range externals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// verifyExternalChecksum returns an error if checksum is not empty and does
+// not match the SHA256 sum of data.
+func verifyExternalChecksum(rawURL, checksum string, data []byte) error {
+	if checksum == "" {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	if actual := hex.EncodeToString(sum[:]); !strings.EqualFold(actual, checksum) {
+		return fmt.Errorf("%s: checksum mismatch: expected %s, got %s", rawURL, checksum, actual)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package chezmoi
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestExternal(t *testing.T) {
+	tarGzData := newTestTarGz(t, map[string]string{
+		"archive/":         "",
+		"archive/dir/":     "",
+		"archive/dir/file": "file",
+		"archive/foo":      "foo",
+	})
+	zipData := newTestZip(t, map[string]string{
+		"bar": "bar",
+	})
+	fileSum := sha256.Sum256([]byte("contents"))
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/file":
+			_, _ = w.Write([]byte("contents"))
+		case "/archive.tar.gz":
+			_, _ = w.Write(tarGzData)
+		case "/archive.zip":
+			_, _ = w.Write(zipData)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".local/share/chezmoi": map[string]interface{}{
+				".chezmoiexternal.toml": `[".file"]` + "\n" +
+					`    url = "{{ .url }}/file"` + "\n" +
+					`    checksum = "` + hex.EncodeToString(fileSum[:]) + `"` + "\n" +
+					`    executable = true` + "\n" +
+					`[".dir"]` + "\n" +
+					`    type = "archive"` + "\n" +
+					`    url = "{{ .url }}/archive.tar.gz"` + "\n" +
+					`    exact = true` + "\n" +
+					`    stripComponents = 1` + "\n",
+				"dot_config": map[string]interface{}{
+					".chezmoiexternal.yaml": "" +
+						"zip/sub:\n" +
+						"  type: archive\n" +
+						"  url: \"{{ .url }}/archive.zip\"\n",
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	newTargetState := func() *TargetState {
+		ts := NewTargetState(
+			WithCache(fs, "/home/user/.cache/chezmoi"),
+			WithDestDir("/home/user"),
+			WithSourceDir("/home/user/.local/share/chezmoi"),
+			WithTemplateData(map[string]interface{}{
+				"url": server.URL,
+			}),
+		)
+		require.NoError(t, ts.Populate(fs, nil))
+		require.NoError(t, ts.Evaluate())
+		return ts
+	}
+
+	ts := newTargetState()
+	assert.Equal(t, 3, requests)
+
+	file, ok := ts.Entries[".file"].(*File)
+	require.True(t, ok)
+	assert.True(t, file.External)
+	assert.Equal(t, ".chezmoiexternal.toml", file.SourceName())
+	assert.Equal(t, os.FileMode(0o777), file.Perm)
+	contents, err := file.Contents()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("contents"), contents)
+
+	dir, ok := ts.Entries[".dir"].(*Dir)
+	require.True(t, ok)
+	assert.True(t, dir.Exact)
+	assert.True(t, IsExternal(dir))
+	foo, err := ts.findEntry(".dir/foo")
+	require.NoError(t, err)
+	assert.Equal(t, ".dir/foo", foo.TargetName())
+	dirFile, err := ts.findEntry(".dir/dir/file")
+	require.NoError(t, err)
+	contents, err = dirFile.(*File).Contents()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("file"), contents)
+
+	config, ok := ts.Entries[".config"].(*Dir)
+	require.True(t, ok)
+	assert.False(t, config.External)
+	bar, err := ts.findEntry(".config/zip/sub/bar")
+	require.NoError(t, err)
+	assert.Equal(t, "dot_config/.chezmoiexternal.yaml", bar.SourceName())
+
+	// A second target state should be populated from the cache.
+	newTargetState()
+	assert.Equal(t, 3, requests)
+}
+
+func TestExternalChecksumMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("contents"))
+	}))
+	defer server.Close()
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi/.chezmoiexternal.json": `{".file":{"url":"` + server.URL + `","checksum":"0000"}}`,
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	ts := NewTargetState(
+		WithDestDir("/home/user"),
+		WithSourceDir("/home/user/.local/share/chezmoi"),
+	)
+	require.NoError(t, ts.Populate(fs, nil))
+	assert.Error(t, ts.Evaluate())
+}
+
+func TestExternalInvalidName(t *testing.T) {
+	for _, name := range []string{
+		"..",
+		"../../etc/foo",
+		".dir/../../foo",
+		"/etc/foo",
+	} {
+		t.Run(name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user/.local/share/chezmoi/.chezmoiexternal.json": `{"` + name + `":{"type":"file","url":"https://example.com/file"}}`,
+			})
+			require.NoError(t, err)
+			defer cleanup()
+
+			ts := NewTargetState(
+				WithDestDir("/home/user"),
+				WithSourceDir("/home/user/.local/share/chezmoi"),
+			)
+			err = ts.Populate(fs, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), ".chezmoiexternal.json")
+			assert.Contains(t, err.Error(), "invalid name")
+		})
+	}
+}
+
+func newTestTarGz(t *testing.T, files map[string]string) []byte {
+	b := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(b)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for _, name := range sortedTestNames(files) {
+		header := &tar.Header{
+			Name: name,
+			Mode: 0o644,
+		}
+		if name[len(name)-1] == '/' {
+			header.Typeflag = tar.TypeDir
+			header.Mode = 0o755
+		} else {
+			header.Typeflag = tar.TypeReg
+			header.Size = int64(len(files[name]))
+		}
+		require.NoError(t, tarWriter.WriteHeader(header))
+		_, err := tarWriter.Write([]byte(files[name]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tarWriter.Close())
+	require.NoError(t, gzipWriter.Close())
+	return b.Bytes()
+}
+
+func newTestZip(t *testing.T, files map[string]string) []byte {
+	b := &bytes.Buffer{}
+	zipWriter := zip.NewWriter(b)
+	for _, name := range sortedTestNames(files) {
+		w, err := zipWriter.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(files[name]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zipWriter.Close())
+	return b.Bytes()
+}
+
+func sortedTestNames(files map[string]string) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
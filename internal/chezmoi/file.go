@@ -5,27 +5,41 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 
 	vfs "github.com/twpayne/go-vfs"
 )
 
-// A FileAttributes holds attributes parsed from a source file name.
+// A FileAttributes holds attributes parsed from a source file name. If
+// ExplicitPerm is true then the permissions in Mode are exact and are not
+// modified by the umask.
 type FileAttributes struct {
-	Name      string
-	Mode      os.FileMode
-	Empty     bool
-	Encrypted bool
-	Template  bool
+	Name         string
+	Mode         os.FileMode
+	Block        bool
+	Create       bool
+	Empty        bool
+	Encrypted    bool
+	ExplicitPerm bool
+	Merge        bool
+	Modify       bool
+	Template     bool
 }
 
 // A File represents the target state of a file.
 type File struct {
 	sourceName       string
 	targetName       string
+	Block            bool
+	Create           bool
 	Empty            bool
 	Encrypted        bool
+	ExplicitPerm     bool
+	External         bool
+	Merge            bool
+	Modify           bool
 	Perm             os.FileMode
 	Template         bool
 	contents         []byte
@@ -37,8 +51,12 @@ type fileConcreteValue struct {
 	Type       string `json:"type" yaml:"type"`
 	SourcePath string `json:"sourcePath" yaml:"sourcePath"`
 	TargetPath string `json:"targetPath" yaml:"targetPath"`
+	Block      bool   `json:"block" yaml:"block"`
+	Create     bool   `json:"create" yaml:"create"`
 	Empty      bool   `json:"empty" yaml:"empty"`
 	Encrypted  bool   `json:"encrypted" yaml:"encrypted"`
+	Merge      bool   `json:"merge" yaml:"merge"`
+	Modify     bool   `json:"modify" yaml:"modify"`
 	Perm       int    `json:"perm" yaml:"perm"`
 	Template   bool   `json:"template" yaml:"template"`
 	Contents   string `json:"contents" yaml:"contents"`
@@ -48,22 +66,48 @@ type fileConcreteValue struct {
 func ParseFileAttributes(sourceName string) FileAttributes {
 	name := sourceName
 	mode := os.FileMode(0o666)
+	block := false
+	create := false
 	empty := false
 	encrypted := false
+	explicitPerm := false
+	merge := false
+	modify := false
 	template := false
 	if strings.HasPrefix(name, symlinkPrefix) {
 		name = strings.TrimPrefix(name, symlinkPrefix)
 		mode |= os.ModeSymlink
 	} else {
+		if strings.HasPrefix(name, createPrefix) {
+			name = strings.TrimPrefix(name, createPrefix)
+			create = true
+		}
+		if strings.HasPrefix(name, modifyPrefix) {
+			name = strings.TrimPrefix(name, modifyPrefix)
+			modify = true
+		} else if strings.HasPrefix(name, blockPrefix) {
+			name = strings.TrimPrefix(name, blockPrefix)
+			block = true
+		} else if strings.HasPrefix(name, mergePrefix) {
+			name = strings.TrimPrefix(name, mergePrefix)
+			merge = true
+		}
 		private := false
 		if strings.HasPrefix(name, encryptedPrefix) {
 			name = strings.TrimPrefix(name, encryptedPrefix)
 			encrypted = true
 		}
+		var perm os.FileMode
+		name, perm, explicitPerm = parseModePrefix(name)
 		if strings.HasPrefix(name, privatePrefix) {
 			name = strings.TrimPrefix(name, privatePrefix)
 			private = true
 		}
+		readOnly := false
+		if strings.HasPrefix(name, readOnlyPrefix) {
+			name = strings.TrimPrefix(name, readOnlyPrefix)
+			readOnly = true
+		}
 		if strings.HasPrefix(name, emptyPrefix) {
 			name = strings.TrimPrefix(name, emptyPrefix)
 			empty = true
@@ -75,6 +119,12 @@ func ParseFileAttributes(sourceName string) FileAttributes {
 		if private {
 			mode &= 0o700
 		}
+		if readOnly {
+			mode &^= 0o222
+		}
+		if explicitPerm {
+			mode = perm
+		}
 	}
 	if strings.HasPrefix(name, dotPrefix) {
 		name = "." + strings.TrimPrefix(name, dotPrefix)
@@ -84,11 +134,16 @@ func ParseFileAttributes(sourceName string) FileAttributes {
 		template = true
 	}
 	return FileAttributes{
-		Name:      name,
-		Mode:      mode,
-		Empty:     empty,
-		Encrypted: encrypted,
-		Template:  template,
+		Name:         name,
+		Mode:         mode,
+		Block:        block,
+		Create:       create,
+		Empty:        empty,
+		Encrypted:    encrypted,
+		ExplicitPerm: explicitPerm,
+		Merge:        merge,
+		Modify:       modify,
+		Template:     template,
 	}
 }
 
@@ -98,16 +153,35 @@ func (fa FileAttributes) SourceName() string {
 	//nolint:exhaustive
 	switch fa.Mode & os.ModeType {
 	case 0:
+		if fa.Create {
+			sourceName += createPrefix
+		}
+		if fa.Modify {
+			sourceName += modifyPrefix
+		}
+		if fa.Block {
+			sourceName += blockPrefix
+		}
+		if fa.Merge {
+			sourceName += mergePrefix
+		}
 		if fa.Encrypted {
 			sourceName += encryptedPrefix
 		}
-		if fa.Mode.Perm()&os.FileMode(0o77) == os.FileMode(0) {
-			sourceName += privatePrefix
+		if fa.ExplicitPerm {
+			sourceName += modePrefixString(fa.Mode.Perm())
+		} else {
+			if fa.Mode.Perm()&os.FileMode(0o77) == os.FileMode(0) {
+				sourceName += privatePrefix
+			}
+			if fa.Mode.Perm()&os.FileMode(0o222) == os.FileMode(0) {
+				sourceName += readOnlyPrefix
+			}
 		}
 		if fa.Empty {
 			sourceName += emptyPrefix
 		}
-		if fa.Mode.Perm()&os.FileMode(0o111) != os.FileMode(0) {
+		if !fa.ExplicitPerm && fa.Mode.Perm()&os.FileMode(0o111) != os.FileMode(0) {
 			sourceName += executablePrefix
 		}
 	case os.ModeSymlink:
@@ -133,56 +207,102 @@ func (f *File) AppendAllEntries(allEntries []Entry) []Entry {
 
 // Apply ensures that the state of targetPath in fs matches f.
 func (f *File) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions) error {
-	if applyOptions.Ignore(f.targetName) {
+	if applyOptions.Ignore(f.targetName) || !applyOptions.Filter.IncludeEntry(f) {
 		return nil
 	}
-	contents, err := f.Contents()
-	if err != nil {
-		return err
-	}
 	targetPath := filepath.Join(applyOptions.DestDir, f.targetName)
 	var info os.FileInfo
+	var err error
 	if follow {
 		info, err = fs.Stat(targetPath)
 	} else {
 		info, err = fs.Lstat(targetPath)
 	}
+	if err == nil && info.Mode().IsRegular() && f.Create {
+		// Files with the create attribute are only written if they do not
+		// already exist, but their permissions are still updated. Their
+		// contents are not needed, so they are not evaluated.
+		if info.Mode().Perm() != f.TargetPerm(applyOptions.Umask) {
+			return mutator.Chmod(targetPath, f.TargetPerm(applyOptions.Umask))
+		}
+		return nil
+	}
+	contents, contentsErr := f.Contents()
+	if contentsErr != nil {
+		return contentsErr
+	}
+	targetState := newFileEntryState(contents, f.TargetPerm(applyOptions.Umask))
 	var currData []byte
+	var actualState *EntryState
 	switch {
 	case err == nil && info.Mode().IsRegular():
-		if isEmpty(contents) && !f.Empty {
+		if isEmpty(contents) && f.removeIfEmpty() {
 			return mutator.RemoveAll(targetPath)
 		}
 		currData, err = fs.ReadFile(targetPath)
 		if err != nil {
 			return err
 		}
-		if !bytes.Equal(currData, contents) {
-			break
+		actualState = newFileEntryState(currData, info.Mode().Perm())
+	case err == nil:
+		actualState = &EntryState{Mode: info.Mode()}
+	case os.IsNotExist(err):
+		if isEmpty(contents) && !f.Empty {
+			return nil
 		}
-		if info.Mode().Perm() != f.Perm&^applyOptions.Umask {
-			if err := mutator.Chmod(targetPath, f.Perm&^applyOptions.Umask); err != nil {
+	default:
+		return err
+	}
+	// Files with the create, modify, block, or merge attributes are expected
+	// to be changed locally, so their state is not tracked.
+	tracked := !f.Create && !f.Modify && !f.Block && !f.Merge
+	if tracked {
+		if actualState.Equal(targetState) {
+			return applyOptions.recordEntryState(targetPath, targetState)
+		}
+		if update, err := applyOptions.checkDrift(targetPath, actualState, targetState); err != nil || !update {
+			return err
+		}
+	}
+	switch {
+	case actualState == nil:
+	case actualState.Mode.IsRegular() && bytes.Equal(currData, contents):
+		if info.Mode().Perm() != f.TargetPerm(applyOptions.Umask) {
+			if err := mutator.Chmod(targetPath, f.TargetPerm(applyOptions.Umask)); err != nil {
 				return err
 			}
 		}
+		if tracked {
+			return applyOptions.recordAppliedEntryState(fs, targetPath, follow, targetState)
+		}
 		return nil
-	case err == nil:
+	case !actualState.Mode.IsRegular():
 		if err := mutator.RemoveAll(targetPath); err != nil {
 			return err
 		}
-	case os.IsNotExist(err):
-	default:
+		if isEmpty(contents) && !f.Empty {
+			return nil
+		}
+	}
+	if err := mutator.WriteFile(targetPath, contents, f.TargetPerm(applyOptions.Umask), currData); err != nil {
 		return err
 	}
-	if isEmpty(contents) && !f.Empty {
-		return nil
+	// Files are created subject to the process's umask, so explicit
+	// permissions might need to be set separately.
+	if f.ExplicitPerm && f.Perm&^applyOptions.Umask != f.Perm {
+		if err := mutator.Chmod(targetPath, f.Perm); err != nil {
+			return err
+		}
+	}
+	if tracked {
+		return applyOptions.recordAppliedEntryState(fs, targetPath, follow, targetState)
 	}
-	return mutator.WriteFile(targetPath, contents, f.Perm&^applyOptions.Umask, currData)
+	return nil
 }
 
 // ConcreteValue implements Entry.ConcreteValue.
-func (f *File) ConcreteValue(ignore func(string) bool, sourceDir string, umask os.FileMode, recursive bool) (interface{}, error) {
-	if ignore(f.targetName) {
+func (f *File) ConcreteValue(ignore func(string) bool, filter *EntryTypeFilter, sourceDir func(Entry) string, umask os.FileMode, recursive bool) (interface{}, error) {
+	if ignore(f.targetName) || !filter.IncludeEntry(f) {
 		return nil, nil
 	}
 	contents, err := f.Contents()
@@ -191,11 +311,15 @@ func (f *File) ConcreteValue(ignore func(string) bool, sourceDir string, umask o
 	}
 	return &fileConcreteValue{
 		Type:       "file",
-		SourcePath: filepath.Join(sourceDir, f.SourceName()),
+		SourcePath: filepath.Join(sourceDir(f), f.SourceName()),
 		TargetPath: f.TargetName(),
+		Block:      f.Block,
+		Create:     f.Create,
 		Empty:      f.Empty,
 		Encrypted:  f.Encrypted,
-		Perm:       int(f.Perm &^ umask),
+		Merge:      f.Merge,
+		Modify:     f.Modify,
+		Perm:       int(f.TargetPerm(umask)),
 		Template:   f.Template,
 		Contents:   string(contents),
 	}, nil
@@ -229,6 +353,11 @@ func (f *File) Private() bool {
 	return f.Perm&0o77 == 0
 }
 
+// ReadOnly returns true if f is read-only.
+func (f *File) ReadOnly() bool {
+	return f.Perm&0o222 == 0
+}
+
 // SourceName implements Entry.SourceName.
 func (f *File) SourceName() string {
 	return f.sourceName
@@ -239,9 +368,56 @@ func (f *File) TargetName() string {
 	return f.targetName
 }
 
+// TargetPerm returns the permissions of f's target, which are f's permissions
+// with umask applied, unless f's permissions are explicit.
+func (f *File) TargetPerm(umask os.FileMode) os.FileMode {
+	return targetPerm(f.Perm, f.ExplicitPerm, umask)
+}
+
+// modifyContents runs the modify script script for targetPath with
+// currentContents on its standard input and returns its standard output. Like
+// run_ scripts, the script is run in targetPath's parent directory. An empty
+// script leaves currentContents unchanged.
+func modifyContents(targetPath string, script, currentContents []byte) ([]byte, error) {
+	if isEmpty(script) {
+		return currentContents, nil
+	}
+	scriptPath, err := writeTempScript(filepath.Base(targetPath), script)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = os.RemoveAll(scriptPath)
+	}()
+	//nolint:gosec
+	c := exec.Command(scriptPath)
+	c.Dir = scriptDir(targetPath)
+	c.Stdin = bytes.NewReader(currentContents)
+	c.Stderr = os.Stderr
+	contents, err := c.Output()
+	if err != nil {
+		return nil, err
+	}
+	// Empty contents would remove the target, so treat a modify script that
+	// produces no output, for example because it failed without an error,
+	// as an error rather than destroying the target's contents.
+	if isEmpty(contents) && !isEmpty(currentContents) {
+		return nil, fmt.Errorf("%s: modify script produced no output", targetPath)
+	}
+	return contents, nil
+}
+
+// removeIfEmpty returns true if f's target is removed when f's contents are
+// empty. Block and merge entries only manage part of their target, so they
+// never remove it, even if nothing is left once their block is removed or their
+// document is merged.
+func (f *File) removeIfEmpty() bool {
+	return !f.Empty && !f.Block && !f.Merge
+}
+
 // archive writes f to w.
-func (f *File) archive(w *tar.Writer, ignore func(string) bool, headerTemplate *tar.Header, umask os.FileMode) error {
-	if ignore(f.targetName) {
+func (f *File) archive(w *tar.Writer, ignore func(string) bool, filter *EntryTypeFilter, headerTemplate *tar.Header, umask os.FileMode) error {
+	if ignore(f.targetName) || !filter.IncludeEntry(f) {
 		return nil
 	}
 	contents, err := f.Contents()
@@ -255,7 +431,7 @@ func (f *File) archive(w *tar.Writer, ignore func(string) bool, headerTemplate *
 	header.Typeflag = tar.TypeReg
 	header.Name = f.targetName
 	header.Size = int64(len(contents))
-	header.Mode = int64(f.Perm &^ umask)
+	header.Mode = int64(f.TargetPerm(umask))
 	if err := w.WriteHeader(&header); err != nil {
 		return nil
 	}
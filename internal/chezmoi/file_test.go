@@ -106,6 +106,107 @@ func TestFileAttributes(t *testing.T) {
 				Template: true,
 			},
 		},
+		{
+			sourceName: "create_dot_foo",
+			fa: FileAttributes{
+				Name:   ".foo",
+				Mode:   0o666,
+				Create: true,
+			},
+		},
+		{
+			sourceName: "create_private_foo.tmpl",
+			fa: FileAttributes{
+				Name:     "foo",
+				Mode:     0o600,
+				Create:   true,
+				Template: true,
+			},
+		},
+		{
+			sourceName: "modify_dot_foo",
+			fa: FileAttributes{
+				Name:   ".foo",
+				Mode:   0o666,
+				Modify: true,
+			},
+		},
+		{
+			sourceName: "modify_private_executable_foo.tmpl",
+			fa: FileAttributes{
+				Name:     "foo",
+				Mode:     0o700,
+				Modify:   true,
+				Template: true,
+			},
+		},
+		{
+			sourceName: "block_dot_bashrc",
+			fa: FileAttributes{
+				Name:  ".bashrc",
+				Mode:  0o666,
+				Block: true,
+			},
+		},
+		{
+			sourceName: "block_private_foo.tmpl",
+			fa: FileAttributes{
+				Name:     "foo",
+				Mode:     0o600,
+				Block:    true,
+				Template: true,
+			},
+		},
+		{
+			sourceName: "merge_dot_settings.json",
+			fa: FileAttributes{
+				Name:  ".settings.json",
+				Mode:  0o666,
+				Merge: true,
+			},
+		},
+		{
+			sourceName: "merge_private_foo.yaml.tmpl",
+			fa: FileAttributes{
+				Name:     "foo.yaml",
+				Mode:     0o600,
+				Merge:    true,
+				Template: true,
+			},
+		},
+		{
+			sourceName: "readonly_foo",
+			fa: FileAttributes{
+				Name: "foo",
+				Mode: 0o444,
+			},
+		},
+		{
+			sourceName: "private_readonly_executable_foo",
+			fa: FileAttributes{
+				Name: "foo",
+				Mode: 0o500,
+			},
+		},
+		{
+			sourceName: "encrypted_mode_0640_dot_foo.tmpl",
+			fa: FileAttributes{
+				Name:         ".foo",
+				Mode:         0o640,
+				Encrypted:    true,
+				ExplicitPerm: true,
+				Template:     true,
+			},
+		},
+		{
+			sourceName: "mode_0664_empty_foo",
+			fa: FileAttributes{
+				Name:         "foo",
+				Mode:         0o664,
+				Empty:        true,
+				ExplicitPerm: true,
+			},
+		},
 		{
 			sourceName: "encrypted_private_dot_secret_file",
 			fa: FileAttributes{
@@ -0,0 +1,144 @@
+package chezmoi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/pelletier/go-toml"
+	"gopkg.in/ini.v1"
+	"gopkg.in/yaml.v2"
+)
+
+// A documentFormat is a format for structured documents.
+type documentFormat struct {
+	decode func([]byte, interface{}) error
+	encode func(io.Writer, interface{}) error
+}
+
+// documentFormats maps file extensions to formats for structured documents.
+var documentFormats = map[string]documentFormat{
+	".ini":  {decode: decodeINI, encode: encodeINI},
+	".json": {decode: decodeJSON, encode: EncodeJSON},
+	".toml": {decode: toml.Unmarshal, encode: EncodeTOML},
+	".yaml": {decode: yaml.Unmarshal, encode: EncodeYAML},
+	".yml":  {decode: yaml.Unmarshal, encode: EncodeYAML},
+}
+
+// EncodeJSON writes value to w as indented JSON. Characters that are special
+// in HTML are not escaped.
+func EncodeJSON(w io.Writer, value interface{}) error {
+	e := json.NewEncoder(w)
+	e.SetEscapeHTML(false)
+	e.SetIndent("", "  ")
+	return e.Encode(value)
+}
+
+// EncodeTOML writes value to w as TOML.
+func EncodeTOML(w io.Writer, value interface{}) error {
+	return toml.NewEncoder(w).Encode(value)
+}
+
+// EncodeYAML writes value to w as YAML.
+func EncodeYAML(w io.Writer, value interface{}) error {
+	return yaml.NewEncoder(w).Encode(value)
+}
+
+// decodeJSON decodes the JSON document data into value. Numbers are decoded as
+// json.Numbers so that integers that cannot be represented exactly as float64s
+// are preserved.
+func decodeJSON(data []byte, value interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(value); err != nil {
+		return err
+	}
+	if _, err := d.Token(); err != io.EOF {
+		return errors.New("invalid data after top-level value")
+	}
+	return nil
+}
+
+// decodeINI decodes the INI document data into value, which must be a
+// *map[string]interface{}. Keys in the default section are top level keys and
+// other sections are maps of keys to string values.
+func decodeINI(data []byte, value interface{}) error {
+	m, ok := value.(*map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%T: unsupported type", value)
+	}
+	f, err := ini.Load(data)
+	if err != nil {
+		return err
+	}
+	result := make(map[string]interface{})
+	for _, section := range f.Sections() {
+		keys := result
+		if name := section.Name(); name != ini.DefaultSection {
+			keys = make(map[string]interface{})
+			result[name] = keys
+		}
+		for _, key := range section.Keys() {
+			keys[key.Name()] = key.Value()
+		}
+	}
+	*m = result
+	return nil
+}
+
+// encodeINI writes value, which must be a map[string]interface{} as returned
+// by decodeINI, to w as an INI document.
+func encodeINI(w io.Writer, value interface{}) error {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%T: unsupported type", value)
+	}
+	f := ini.Empty()
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// Keys in the default section must be written before any other section.
+	for _, name := range names {
+		if _, ok := m[name].(map[string]interface{}); ok {
+			continue
+		}
+		if _, err := f.Section(ini.DefaultSection).NewKey(name, fmt.Sprint(m[name])); err != nil {
+			return err
+		}
+	}
+	for _, name := range names {
+		keys, ok := m[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		section, err := f.NewSection(name)
+		if err != nil {
+			return err
+		}
+		keyNames := make([]string, 0, len(keys))
+		for keyName := range keys {
+			keyNames = append(keyNames, keyName)
+		}
+		sort.Strings(keyNames)
+		for _, keyName := range keyNames {
+			if _, ok := keys[keyName].(map[string]interface{}); ok {
+				return fmt.Errorf("%s.%s: nested sections are not supported", name, keyName)
+			}
+			if _, err := section.NewKey(keyName, fmt.Sprint(keys[keyName])); err != nil {
+				return err
+			}
+		}
+	}
+	b := &bytes.Buffer{}
+	if _, err := f.WriteTo(b); err != nil {
+		return err
+	}
+	// Remove the blank line that follows the last section.
+	_, err := w.Write(append(bytes.TrimRight(b.Bytes(), "\n"), '\n'))
+	return err
+}
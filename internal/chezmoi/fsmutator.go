@@ -1,14 +1,19 @@
 package chezmoi
 
 import (
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
 
 	vfs "github.com/twpayne/go-vfs"
 )
 
-// An FSMutator makes changes to a vfs.FS.
+// An FSMutator makes changes to a vfs.FS. Scripts' standard output is written
+// to ScriptStdout, or to os.Stdout if ScriptStdout is nil.
 type FSMutator struct {
 	vfs.FS
+	ScriptStdout io.Writer
 	devCache     map[string]uint // devCache maps directories to device numbers.
 	tempDirCache map[uint]string // tempDir maps device numbers to renameio temporary directories.
 }
@@ -31,3 +36,26 @@ func (m *FSMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
 func (m *FSMutator) RunCmd(cmd *exec.Cmd) error {
 	return cmd.Run()
 }
+
+// RunScript implements Mutator.RunScript.
+func (m *FSMutator) RunScript(name, dir string, data []byte) error {
+	scriptPath, err := writeTempScript(filepath.Base(name), data)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.RemoveAll(scriptPath)
+	}()
+
+	// Run the temporary script file.
+	//nolint:gosec
+	cmd := exec.Command(scriptPath)
+	cmd.Dir = dir
+	cmd.Stdout = m.ScriptStdout
+	if cmd.Stdout == nil {
+		cmd.Stdout = os.Stdout
+	}
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+	return cmd.Run()
+}
@@ -108,6 +108,35 @@ func (m *GitDiffMutator) RunCmd(cmd *exec.Cmd) error {
 	return nil
 }
 
+// ReportScript writes the script name with contents data to the diff as a new
+// executable file, without running it. Scripts are not run in dry runs, so
+// they can only be written to the diff with ReportScript.
+func (m *GitDiffMutator) ReportScript(name string, data []byte) error {
+	isBinary := isBinary(data)
+	var chunks []diff.Chunk
+	if !isBinary {
+		chunks = diffChunks("", string(data))
+	}
+	return m.unifiedEncoder.Encode(&gitDiffPatch{
+		filePatches: []diff.FilePatch{
+			&gitDiffFilePatch{
+				isBinary: isBinary,
+				to: &gitDiffFile{
+					fileMode: filemode.Executable,
+					path:     m.trimPrefix(name),
+					hash:     plumbing.ComputeHash(plumbing.BlobObject, data),
+				},
+				chunks: chunks,
+			},
+		},
+	})
+}
+
+// RunScript implements Mutator.RunScript.
+func (m *GitDiffMutator) RunScript(name, dir string, data []byte) error {
+	return m.ReportScript(name, data)
+}
+
 // Stat implements Mutator.Stat.
 func (m *GitDiffMutator) Stat(name string) (os.FileInfo, error) {
 	return m.m.Stat(name)
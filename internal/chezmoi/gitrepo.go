@@ -0,0 +1,229 @@
+package chezmoi
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+var (
+	gitAbbreviatedCommitRegexp = regexp.MustCompile(`\A[0-9a-f]{4,39}\z`)
+	gitCommitRegexp            = regexp.MustCompile(`\A[0-9a-f]{40}\z`)
+)
+
+// A GitRepo represents the target state of a git repository that is cloned
+// from a URL.
+type GitRepo struct {
+	sourceName string
+	targetName string
+	URL        string
+	Ref        string
+	gitCommand string
+}
+
+type gitRepoConcreteValue struct {
+	Type       string `json:"type" yaml:"type"`
+	SourcePath string `json:"sourcePath" yaml:"sourcePath"`
+	TargetPath string `json:"targetPath" yaml:"targetPath"`
+	URL        string `json:"url" yaml:"url"`
+	Ref        string `json:"ref" yaml:"ref"`
+}
+
+// AppendAllEntries appends r to allEntries.
+func (r *GitRepo) AppendAllEntries(allEntries []Entry) []Entry {
+	return append(allEntries, r)
+}
+
+// Apply ensures that the git repository at r's target in fs is cloned from r's
+// URL and is at r's ref. If r has no ref then the repository is fast-forwarded
+// to the remote's default branch.
+func (r *GitRepo) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions) error {
+	if applyOptions.Ignore(r.targetName) || !applyOptions.Filter.IncludeEntry(r) {
+		return nil
+	}
+	targetPath := filepath.Join(applyOptions.DestDir, r.targetName)
+	rawTargetPath, err := fs.RawPath(targetPath)
+	if err != nil {
+		return err
+	}
+
+	switch _, err := fs.Lstat(filepath.Join(targetPath, ".git")); {
+	case err == nil:
+	case os.IsNotExist(err):
+		info, err := fs.Lstat(targetPath)
+		switch {
+		case err == nil && info.IsDir():
+			infos, err := fs.ReadDir(targetPath)
+			if err != nil {
+				return err
+			}
+			if len(infos) != 0 {
+				return fmt.Errorf("%s: not a git repository", targetPath)
+			}
+		case err == nil:
+			if err := mutator.RemoveAll(targetPath); err != nil {
+				return err
+			}
+		case !os.IsNotExist(err):
+			return err
+		}
+		if err := mutator.RunCmd(r.gitCmd("", "clone", "--quiet", r.URL, rawTargetPath)); err != nil {
+			return err
+		}
+		if r.Ref == "" {
+			return nil
+		}
+		wantCommit, refName, err := r.remoteCommit(mutator)
+		if err != nil {
+			return err
+		}
+		if refName == "" {
+			// A full commit hash might not be reachable from any of the refs
+			// that were cloned, so fetch it explicitly.
+			if err := mutator.RunCmd(r.gitCmd(rawTargetPath, "fetch", "--quiet", r.URL, wantCommit)); err != nil {
+				return err
+			}
+		}
+		return mutator.RunCmd(r.gitCmd(rawTargetPath, "checkout", "--quiet", wantCommit))
+	default:
+		return err
+	}
+
+	wantCommit, refName, err := r.remoteCommit(mutator)
+	if err != nil {
+		return err
+	}
+	haveCommit, err := mutator.IdempotentCmdOutput(r.gitCmd(rawTargetPath, "rev-parse", "HEAD"))
+	if err != nil {
+		return err
+	}
+	if string(bytes.TrimSpace(haveCommit)) == wantCommit {
+		return nil
+	}
+
+	fetchArgs := []string{"fetch", "--quiet", "--tags", r.URL}
+	switch {
+	case r.Ref == "":
+	case refName == "":
+		// r.Ref is a full commit hash, which might not be reachable from any
+		// ref, so fetch it explicitly.
+		fetchArgs = append(fetchArgs, wantCommit)
+	default:
+		fetchArgs = append(fetchArgs, refName)
+	}
+	if err := mutator.RunCmd(r.gitCmd(rawTargetPath, fetchArgs...)); err != nil {
+		return err
+	}
+	if r.Ref == "" {
+		return mutator.RunCmd(r.gitCmd(rawTargetPath, "merge", "--ff-only", "--quiet", wantCommit))
+	}
+	return mutator.RunCmd(r.gitCmd(rawTargetPath, "checkout", "--quiet", wantCommit))
+}
+
+// ConcreteValue implements Entry.ConcreteValue.
+func (r *GitRepo) ConcreteValue(ignore func(string) bool, filter *EntryTypeFilter, sourceDir func(Entry) string, umask os.FileMode, recursive bool) (interface{}, error) {
+	if ignore(r.targetName) || !filter.IncludeEntry(r) {
+		return nil, nil
+	}
+	return &gitRepoConcreteValue{
+		Type:       "gitRepo",
+		SourcePath: filepath.Join(sourceDir(r), r.SourceName()),
+		TargetPath: r.TargetName(),
+		URL:        r.URL,
+		Ref:        r.Ref,
+	}, nil
+}
+
+// Evaluate evaluates r.
+func (r *GitRepo) Evaluate(ignore func(string) bool) error {
+	return nil
+}
+
+// SourceName implements Entry.SourceName.
+func (r *GitRepo) SourceName() string {
+	return r.sourceName
+}
+
+// TargetName implements Entry.TargetName.
+func (r *GitRepo) TargetName() string {
+	return r.targetName
+}
+
+// archive writes nothing to w, as the contents of git repositories are not
+// part of the target state.
+func (r *GitRepo) archive(w *tar.Writer, ignore func(string) bool, filter *EntryTypeFilter, headerTemplate *tar.Header, umask os.FileMode) error {
+	return nil
+}
+
+// gitCmd returns a command that runs r's git command with args in dir. If dir
+// is empty then the command is run in the current directory.
+func (r *GitRepo) gitCmd(dir string, args ...string) *exec.Cmd {
+	gitCommand := r.gitCommand
+	if gitCommand == "" {
+		gitCommand = "git"
+	}
+	//nolint:gosec
+	cmd := exec.Command(gitCommand, args...)
+	cmd.Dir = dir
+	return cmd
+}
+
+// remoteCommit returns the commit that r's ref refers to in r's remote
+// repository, and the full name of the ref. If r has no ref then the remote's
+// HEAD is used. Like git rev-parse, refs that are not full names are looked up
+// as tags and then as branches, so a ref never matches a branch whose name
+// merely ends with it. Full commit hashes are returned as-is, but abbreviated
+// commit hashes cannot be resolved in a remote repository.
+func (r *GitRepo) remoteCommit(mutator Mutator) (string, string, error) {
+	if gitCommitRegexp.MatchString(r.Ref) {
+		return r.Ref, "", nil
+	}
+	var refNames []string
+	switch {
+	case r.Ref == "":
+		refNames = []string{"HEAD"}
+	case strings.HasPrefix(r.Ref, "refs/"):
+		refNames = []string{r.Ref}
+	default:
+		refNames = []string{"refs/tags/" + r.Ref, "refs/heads/" + r.Ref}
+	}
+	args := []string{"ls-remote", r.URL}
+	for _, refName := range refNames {
+		args = append(args, refName, refName+"^{}")
+	}
+	output, err := mutator.IdempotentCmdOutput(r.gitCmd("", args...))
+	if err != nil {
+		return "", "", err
+	}
+	commits := make(map[string]string)
+	for _, line := range strings.Split(string(output), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 {
+			commits[fields[1]] = fields[0]
+		}
+	}
+	for _, refName := range refNames {
+		// Prefer peeled tags, which refer to commits rather than to tag
+		// objects.
+		if commit, ok := commits[refName+"^{}"]; ok {
+			return commit, refName, nil
+		}
+		if commit, ok := commits[refName]; ok {
+			return commit, refName, nil
+		}
+	}
+	if gitAbbreviatedCommitRegexp.MatchString(r.Ref) {
+		return "", "", fmt.Errorf("%s: %s: abbreviated commit hashes are not supported, use the full commit hash", r.URL, r.Ref)
+	}
+	ref := r.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	return "", "", fmt.Errorf("%s: %s: ref not found", r.URL, ref)
+}
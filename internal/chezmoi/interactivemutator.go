@@ -0,0 +1,208 @@
+package chezmoi
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// An InteractiveChoice is what to do with a change.
+type InteractiveChoice int
+
+// Interactive choices.
+const (
+	// InteractiveOverwrite makes the change.
+	InteractiveOverwrite InteractiveChoice = iota
+	// InteractiveSkip skips the change.
+	InteractiveSkip
+	// InteractiveMerge merges the change with the existing file.
+	InteractiveMerge
+	// InteractiveAll makes the change and all further changes.
+	InteractiveAll
+	// InteractiveNone skips the change and all further changes.
+	InteractiveNone
+)
+
+// An InteractivePromptFunc is called to choose what to do with a change to
+// name. canMerge is true if InteractiveMerge is a valid choice.
+type InteractivePromptFunc func(name string, canMerge bool) (InteractiveChoice, error)
+
+// An InteractiveMergeFunc is called to merge data into the file name, which
+// currently contains currData, and returns the merged contents.
+type InteractiveMergeFunc func(name string, data, currData []byte) ([]byte, error)
+
+// An InteractiveMutator wraps a Mutator and asks before making each change.
+// Each change is first shown with a preview Mutator, normally a VerboseMutator
+// or GitDiffMutator that does not make any changes. Once a change to a path is
+// chosen, further changes to the same path are made or skipped without asking
+// again, and changes to paths in a directory that was not created are skipped.
+type InteractiveMutator struct {
+	m           Mutator
+	preview     Mutator
+	prompt      InteractivePromptFunc
+	merge       InteractiveMergeFunc
+	all         bool
+	none        bool
+	choices     map[string]bool
+	skippedDirs map[string]struct{}
+}
+
+// NewInteractiveMutator returns a new InteractiveMutator. If merge is nil then
+// changes cannot be merged.
+func NewInteractiveMutator(m, preview Mutator, prompt InteractivePromptFunc, merge InteractiveMergeFunc) *InteractiveMutator {
+	return &InteractiveMutator{
+		m:           m,
+		preview:     preview,
+		prompt:      prompt,
+		merge:       merge,
+		choices:     make(map[string]bool),
+		skippedDirs: make(map[string]struct{}),
+	}
+}
+
+// Chmod implements Mutator.Chmod.
+func (m *InteractiveMutator) Chmod(name string, mode os.FileMode) error {
+	choice, err := m.choose(name, false, func() error {
+		return m.preview.Chmod(name, mode)
+	})
+	if err != nil || choice != InteractiveOverwrite {
+		return err
+	}
+	return m.m.Chmod(name, mode)
+}
+
+// IdempotentCmdOutput implements Mutator.IdempotentCmdOutput.
+func (m *InteractiveMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
+	return m.m.IdempotentCmdOutput(cmd)
+}
+
+// Mkdir implements Mutator.Mkdir.
+func (m *InteractiveMutator) Mkdir(name string, perm os.FileMode) error {
+	choice, err := m.choose(name, false, func() error {
+		return m.preview.Mkdir(name, perm)
+	})
+	if err != nil {
+		return err
+	}
+	if choice != InteractiveOverwrite {
+		m.skippedDirs[name] = struct{}{}
+		return nil
+	}
+	return m.m.Mkdir(name, perm)
+}
+
+// RemoveAll implements Mutator.RemoveAll.
+func (m *InteractiveMutator) RemoveAll(name string) error {
+	choice, err := m.choose(name, false, func() error {
+		return m.preview.RemoveAll(name)
+	})
+	if err != nil || choice != InteractiveOverwrite {
+		return err
+	}
+	return m.m.RemoveAll(name)
+}
+
+// Rename implements Mutator.Rename.
+func (m *InteractiveMutator) Rename(oldpath, newpath string) error {
+	choice, err := m.choose(newpath, false, func() error {
+		return m.preview.Rename(oldpath, newpath)
+	})
+	if err != nil || choice != InteractiveOverwrite {
+		return err
+	}
+	return m.m.Rename(oldpath, newpath)
+}
+
+// RunCmd implements Mutator.RunCmd.
+func (m *InteractiveMutator) RunCmd(cmd *exec.Cmd) error {
+	return m.m.RunCmd(cmd)
+}
+
+// RunScript implements Mutator.RunScript.
+func (m *InteractiveMutator) RunScript(name, dir string, data []byte) error {
+	return m.m.RunScript(name, dir, data)
+}
+
+// Stat implements Mutator.Stat.
+func (m *InteractiveMutator) Stat(name string) (os.FileInfo, error) {
+	return m.m.Stat(name)
+}
+
+// WriteFile implements Mutator.WriteFile.
+func (m *InteractiveMutator) WriteFile(name string, data []byte, perm os.FileMode, currData []byte) error {
+	choice, err := m.choose(name, m.merge != nil && currData != nil, func() error {
+		return m.preview.WriteFile(name, data, perm, currData)
+	})
+	if err != nil {
+		return err
+	}
+	switch choice {
+	case InteractiveOverwrite:
+		return m.m.WriteFile(name, data, perm, currData)
+	case InteractiveMerge:
+		mergedData, err := m.merge(name, data, currData)
+		if err != nil {
+			return err
+		}
+		return m.m.WriteFile(name, mergedData, perm, currData)
+	default:
+		return nil
+	}
+}
+
+// WriteSymlink implements Mutator.WriteSymlink.
+func (m *InteractiveMutator) WriteSymlink(oldname, newname string) error {
+	choice, err := m.choose(newname, false, func() error {
+		return m.preview.WriteSymlink(oldname, newname)
+	})
+	if err != nil || choice != InteractiveOverwrite {
+		return err
+	}
+	return m.m.WriteSymlink(oldname, newname)
+}
+
+// choose returns whether a change to name should be made, skipped, or merged,
+// calling preview and prompting if it has not already been chosen.
+func (m *InteractiveMutator) choose(name string, canMerge bool, preview func() error) (InteractiveChoice, error) {
+	if overwrite, ok := m.choices[name]; ok {
+		if overwrite {
+			return InteractiveOverwrite, nil
+		}
+		return InteractiveSkip, nil
+	}
+	for dir := filepath.Dir(name); ; dir = filepath.Dir(dir) {
+		if _, ok := m.skippedDirs[dir]; ok {
+			return InteractiveSkip, nil
+		}
+		if parentDir := filepath.Dir(dir); parentDir == dir {
+			break
+		}
+	}
+	switch {
+	case m.all:
+		return InteractiveOverwrite, nil
+	case m.none:
+		return InteractiveSkip, nil
+	}
+	if err := preview(); err != nil {
+		return InteractiveSkip, err
+	}
+	choice, err := m.prompt(name, canMerge)
+	if err != nil {
+		return InteractiveSkip, err
+	}
+	switch choice {
+	case InteractiveMerge:
+		if !canMerge {
+			choice = InteractiveSkip
+		}
+	case InteractiveAll:
+		m.all = true
+		choice = InteractiveOverwrite
+	case InteractiveNone:
+		m.none = true
+		choice = InteractiveSkip
+	}
+	m.choices[name] = choice != InteractiveSkip
+	return choice, nil
+}
@@ -0,0 +1,84 @@
+package chezmoi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestInteractiveMutator(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".config": &vfst.Dir{Perm: 0o700},
+			".file":   "# old contents of .file\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	choices := map[string]InteractiveChoice{
+		"/home/user/.config": InteractiveSkip,
+		"/home/user/.dir":    InteractiveSkip,
+		"/home/user/.file":   InteractiveMerge,
+		"/home/user/.new":    InteractiveOverwrite,
+		"/home/user/.other":  InteractiveNone,
+	}
+	var prompted []string
+	prompt := func(name string, canMerge bool) (InteractiveChoice, error) {
+		prompted = append(prompted, name)
+		return choices[name], nil
+	}
+	merge := func(name string, data, currData []byte) ([]byte, error) {
+		return append(append([]byte{}, currData...), data...), nil
+	}
+	mutator := NewInteractiveMutator(NewFSMutator(fs), NullMutator{}, prompt, merge)
+
+	require.NoError(t, mutator.Chmod("/home/user/.config", 0o755))
+	require.NoError(t, mutator.WriteFile("/home/user/.config/file", []byte("# contents of .config/file\n"), 0o644, nil))
+	require.NoError(t, mutator.Mkdir("/home/user/.dir", 0o755))
+	require.NoError(t, mutator.WriteFile("/home/user/.dir/file", []byte("# contents of .dir/file\n"), 0o644, nil))
+	require.NoError(t, mutator.WriteFile("/home/user/.file", []byte("# new contents of .file\n"), 0o644, []byte("# old contents of .file\n")))
+	require.NoError(t, mutator.Chmod("/home/user/.file", 0o600))
+	require.NoError(t, mutator.WriteFile("/home/user/.new", []byte("# contents of .new\n"), 0o644, nil))
+	require.NoError(t, mutator.WriteSymlink(".new", "/home/user/.other"))
+	require.NoError(t, mutator.WriteFile("/home/user/.last", []byte("# contents of .last\n"), 0o644, nil))
+
+	assert.Equal(t, []string{
+		"/home/user/.config",
+		"/home/user/.config/file",
+		"/home/user/.dir",
+		"/home/user/.file",
+		"/home/user/.new",
+		"/home/user/.other",
+	}, prompted)
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.config",
+			vfst.TestIsDir,
+			vfst.TestModePerm(0o700),
+		),
+		vfst.TestPath("/home/user/.config/file",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("# contents of .config/file\n"),
+		),
+		vfst.TestPath("/home/user/.dir",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/.file",
+			vfst.TestModeIsRegular,
+			vfst.TestModePerm(0o600),
+			vfst.TestContentsString("# old contents of .file\n# new contents of .file\n"),
+		),
+		vfst.TestPath("/home/user/.new",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("# contents of .new\n"),
+		),
+		vfst.TestPath("/home/user/.other",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/.last",
+			vfst.TestDoesNotExist,
+		),
+	)
+}
@@ -0,0 +1,177 @@
+package chezmoi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+var errReadOnly = errors.New("read-only")
+
+// A JSONFilePersistentState is a state persisted in a JSON file. Changes are
+// made in memory and written to the file when the state is closed. The file is
+// re-read and the changes are made again before it is written, so changes made
+// by other processes since it was first read are kept, and it is replaced
+// atomically, so it is never left partially written. The file is not locked,
+// however, so changes made by another process while the file is being written
+// can be lost.
+type JSONFilePersistentState struct {
+	fs       vfs.FS
+	path     string
+	readOnly bool
+	state    *MemoryPersistentState
+	changes  []func(*MemoryPersistentState) error
+}
+
+// NewJSONFilePersistentState returns a new JSONFilePersistentState. If readOnly
+// is true then all changes fail.
+func NewJSONFilePersistentState(fs vfs.FS, path string, readOnly bool) (*JSONFilePersistentState, error) {
+	s := &JSONFilePersistentState{
+		fs:       fs,
+		path:     path,
+		readOnly: readOnly,
+	}
+	if err := s.read(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Buckets returns the names of the buckets in s, in order.
+func (s *JSONFilePersistentState) Buckets() ([][]byte, error) {
+	return s.state.Buckets()
+}
+
+// Close writes any changes to s's file.
+func (s *JSONFilePersistentState) Close() error {
+	if len(s.changes) == 0 {
+		return nil
+	}
+	if err := s.write(); err != nil {
+		return err
+	}
+	s.changes = nil
+	return nil
+}
+
+// Delete deletes the value associated with key in bucket. If bucket or key
+// does not exist then Delete does nothing.
+func (s *JSONFilePersistentState) Delete(bucket, key []byte) error {
+	return s.change(func(state *MemoryPersistentState) error {
+		return state.Delete(bucket, key)
+	})
+}
+
+// DeleteBucket deletes bucket and all its keys. If bucket does not exist then
+// DeleteBucket does nothing.
+func (s *JSONFilePersistentState) DeleteBucket(bucket []byte) error {
+	return s.change(func(state *MemoryPersistentState) error {
+		return state.DeleteBucket(bucket)
+	})
+}
+
+// ForEach calls fn for each key and value in bucket, in key order. If bucket
+// does not exist then ForEach does nothing.
+func (s *JSONFilePersistentState) ForEach(bucket []byte, fn func(k, v []byte) error) error {
+	return s.state.ForEach(bucket, fn)
+}
+
+// Get returns the value associated with key in bucket.
+func (s *JSONFilePersistentState) Get(bucket, key []byte) ([]byte, error) {
+	return s.state.Get(bucket, key)
+}
+
+// Set sets the value associated with key in bucket. bucket will be created if
+// it does not already exist.
+func (s *JSONFilePersistentState) Set(bucket, key, value []byte) error {
+	return s.change(func(state *MemoryPersistentState) error {
+		return state.Set(bucket, key, value)
+	})
+}
+
+// change makes the change fn to s and records it so that it can be made again
+// when s's file is written.
+func (s *JSONFilePersistentState) change(fn func(*MemoryPersistentState) error) error {
+	if s.readOnly {
+		return fmt.Errorf("%s: %w", s.path, errReadOnly)
+	}
+	if err := fn(s.state); err != nil {
+		return err
+	}
+	s.changes = append(s.changes, fn)
+	return nil
+}
+
+// read reads s's file.
+func (s *JSONFilePersistentState) read() error {
+	state, err := s.readState()
+	if err != nil {
+		return err
+	}
+	s.state = state
+	return nil
+}
+
+// readState returns the state in s's file.
+func (s *JSONFilePersistentState) readState() (*MemoryPersistentState, error) {
+	state := NewMemoryPersistentState()
+	data, err := s.fs.ReadFile(s.path)
+	switch {
+	case os.IsNotExist(err):
+		return state, nil
+	case err != nil:
+		return nil, err
+	}
+	var stateData map[string]map[string]string
+	if err := json.Unmarshal(data, &stateData); err != nil {
+		return nil, fmt.Errorf("%s: %w", s.path, err)
+	}
+	if err := SetPersistentStateData(state, stateData); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// write re-reads s's file, makes s's changes to it again, and writes it.
+func (s *JSONFilePersistentState) write() error {
+	state, err := s.readState()
+	if err != nil {
+		return err
+	}
+	for _, change := range s.changes {
+		if err := change(state); err != nil {
+			return err
+		}
+	}
+	s.state = state
+	stateData := make(map[string]map[string]string)
+	for bucket, bucketMap := range s.state.data {
+		bucketData := make(map[string]string)
+		for key, value := range bucketMap {
+			bucketData[key] = string(value)
+		}
+		stateData[bucket] = bucketData
+	}
+	data, err := json.MarshalIndent(stateData, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := vfs.MkdirAll(s.fs, filepath.Dir(s.path), 0o777); err != nil {
+		return err
+	}
+	// Write a temporary file and rename it so that the file is replaced
+	// atomically.
+	tempPath := fmt.Sprintf("%s.%d.tmp", s.path, os.Getpid())
+	if err := s.fs.WriteFile(tempPath, append(data, '\n'), 0o666); err != nil {
+		return err
+	}
+	if err := s.fs.Rename(tempPath, s.path); err != nil {
+		_ = s.fs.RemoveAll(tempPath)
+		return err
+	}
+	return nil
+}
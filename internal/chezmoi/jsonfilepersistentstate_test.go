@@ -0,0 +1,67 @@
+package chezmoi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+var _ PersistentState = &JSONFilePersistentState{}
+
+func TestJSONFilePersistentState(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": &vfst.Dir{Perm: 0o755},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	path := "/home/user/.config/chezmoi/chezmoistate.json"
+	s, err := NewJSONFilePersistentState(fs, path, false)
+	require.NoError(t, err)
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath(path,
+			vfst.TestDoesNotExist,
+		),
+	)
+
+	testPersistentState(t, s)
+
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath(path,
+			vfst.TestModeIsRegular,
+		),
+	)
+	infos, err := fs.ReadDir("/home/user/.config/chezmoi")
+	require.NoError(t, err)
+	assert.Len(t, infos, 1)
+
+	// Changes are only written when the state is closed, and changes made by
+	// other instances are not lost.
+	a, err := NewJSONFilePersistentState(fs, path, false)
+	require.NoError(t, err)
+	b, err := NewJSONFilePersistentState(fs, path, false)
+	require.NoError(t, err)
+	require.NoError(t, a.Set([]byte("bucket"), []byte("a"), []byte("a")))
+	require.NoError(t, a.Set([]byte("bucket"), []byte("a2"), []byte("a2")))
+	require.NoError(t, b.Set([]byte("bucket"), []byte("b"), []byte("b")))
+	value, err := a.Get([]byte("bucket"), []byte("a"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("a"), value)
+	data, err := fs.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), `"a"`)
+	require.NoError(t, a.Close())
+	require.NoError(t, b.Close())
+	c, err := NewJSONFilePersistentState(fs, path, true)
+	require.NoError(t, err)
+	for _, key := range []string{"a", "a2", "b"} {
+		value, err := c.Get([]byte("bucket"), []byte(key))
+		require.NoError(t, err)
+		assert.Equal(t, []byte(key), value)
+	}
+
+	assert.Error(t, c.Set([]byte("bucket"), []byte("c"), []byte("c")))
+	assert.Error(t, c.Delete([]byte("bucket"), []byte("a")))
+}
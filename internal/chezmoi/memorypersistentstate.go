@@ -0,0 +1,102 @@
+package chezmoi
+
+import "sort"
+
+// A MemoryPersistentState is a state held in memory.
+type MemoryPersistentState struct {
+	data map[string]map[string][]byte
+}
+
+// NewMemoryPersistentState returns a new MemoryPersistentState.
+func NewMemoryPersistentState() *MemoryPersistentState {
+	return &MemoryPersistentState{
+		data: make(map[string]map[string][]byte),
+	}
+}
+
+// Buckets returns the names of the buckets in s, in order.
+func (s *MemoryPersistentState) Buckets() ([][]byte, error) {
+	names := make([]string, 0, len(s.data))
+	for name := range s.data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	buckets := make([][]byte, 0, len(names))
+	for _, name := range names {
+		buckets = append(buckets, []byte(name))
+	}
+	return buckets, nil
+}
+
+// Close closes s.
+func (s *MemoryPersistentState) Close() error {
+	return nil
+}
+
+// Delete deletes the value associated with key in bucket. If bucket or key
+// does not exist then Delete does nothing.
+func (s *MemoryPersistentState) Delete(bucket, key []byte) error {
+	bucketMap, ok := s.data[string(bucket)]
+	if !ok {
+		return nil
+	}
+	delete(bucketMap, string(key))
+	return nil
+}
+
+// DeleteBucket deletes bucket and all its keys. If bucket does not exist then
+// DeleteBucket does nothing.
+func (s *MemoryPersistentState) DeleteBucket(bucket []byte) error {
+	delete(s.data, string(bucket))
+	return nil
+}
+
+// ForEach calls fn for each key and value in bucket, in key order. If bucket
+// does not exist then ForEach does nothing.
+func (s *MemoryPersistentState) ForEach(bucket []byte, fn func(k, v []byte) error) error {
+	bucketMap, ok := s.data[string(bucket)]
+	if !ok {
+		return nil
+	}
+	for _, key := range sortedKeys(bucketMap) {
+		if err := fn([]byte(key), copyBytes(bucketMap[key])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get returns the value associated with key in bucket.
+func (s *MemoryPersistentState) Get(bucket, key []byte) ([]byte, error) {
+	bucketMap, ok := s.data[string(bucket)]
+	if !ok {
+		return nil, nil
+	}
+	value, ok := bucketMap[string(key)]
+	if !ok {
+		return nil, nil
+	}
+	return copyBytes(value), nil
+}
+
+// Set sets the value associated with key in bucket. bucket will be created if
+// it does not already exist.
+func (s *MemoryPersistentState) Set(bucket, key, value []byte) error {
+	bucketMap, ok := s.data[string(bucket)]
+	if !ok {
+		bucketMap = make(map[string][]byte)
+		s.data[string(bucket)] = bucketMap
+	}
+	bucketMap[string(key)] = copyBytes(value)
+	return nil
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string][]byte) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
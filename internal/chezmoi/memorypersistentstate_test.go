@@ -0,0 +1,73 @@
+package chezmoi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ PersistentState = &MemoryPersistentState{}
+
+func TestMemoryPersistentState(t *testing.T) {
+	testPersistentState(t, NewMemoryPersistentState())
+}
+
+// testPersistentState tests the common behavior of an empty PersistentState.
+func testPersistentState(t *testing.T, s PersistentState) {
+	t.Helper()
+
+	var (
+		bucket = []byte("bucket")
+		key    = []byte("key")
+		value  = []byte("value")
+	)
+
+	actualValue, err := s.Get(bucket, key)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(nil), actualValue)
+	require.NoError(t, s.Delete(bucket, key))
+	require.NoError(t, s.DeleteBucket(bucket))
+	buckets, err := s.Buckets()
+	require.NoError(t, err)
+	assert.Empty(t, buckets)
+
+	require.NoError(t, s.Set(bucket, key, value))
+	actualValue, err = s.Get(bucket, key)
+	require.NoError(t, err)
+	assert.Equal(t, value, actualValue)
+
+	require.NoError(t, s.Set(bucket, []byte("key2"), []byte("value2")))
+	require.NoError(t, s.Set([]byte("another"), key, value))
+	buckets, err = s.Buckets()
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("another"), bucket}, buckets)
+	stateData, err := PersistentStateData(s)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{
+		"another": {
+			"key": "value",
+		},
+		"bucket": {
+			"key":  "value",
+			"key2": "value2",
+		},
+	}, stateData)
+
+	require.NoError(t, s.Delete(bucket, key))
+	actualValue, err = s.Get(bucket, key)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(nil), actualValue)
+
+	require.NoError(t, s.DeleteBucket(bucket))
+	actualValue, err = s.Get(bucket, []byte("key2"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte(nil), actualValue)
+
+	require.NoError(t, SetPersistentStateData(s, stateData))
+	actualValue, err = s.Get(bucket, key)
+	require.NoError(t, err)
+	assert.Equal(t, value, actualValue)
+
+	require.NoError(t, s.Close())
+}
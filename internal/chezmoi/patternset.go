@@ -45,3 +45,16 @@ func (ps *PatternSet) Match(name string) bool {
 	}
 	return false
 }
+
+// merge merges the patterns in other into ps. Patterns in other replace the
+// same patterns in ps, whether they are included or excluded.
+func (ps *PatternSet) merge(other *PatternSet) {
+	for pattern := range other.includes {
+		delete(ps.excludes, pattern)
+		ps.includes[pattern] = struct{}{}
+	}
+	for pattern := range other.excludes {
+		delete(ps.includes, pattern)
+		ps.excludes[pattern] = struct{}{}
+	}
+}
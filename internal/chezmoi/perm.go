@@ -0,0 +1,71 @@
+package chezmoi
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// parseModePrefix parses an explicit mode prefix, for example mode_0640_, from
+// the start of name. It returns the rest of name, the permissions, and whether
+// name started with an explicit mode prefix.
+func parseModePrefix(name string) (string, os.FileMode, bool) {
+	if !strings.HasPrefix(name, modePrefix) {
+		return name, 0, false
+	}
+	rest := strings.TrimPrefix(name, modePrefix)
+	index := strings.IndexByte(rest, '_')
+	if index == -1 {
+		return name, 0, false
+	}
+	perm, err := strconv.ParseUint(rest[:index], 8, 32)
+	if err != nil || os.FileMode(perm) != os.FileMode(perm).Perm() {
+		return name, 0, false
+	}
+	return rest[index+1:], os.FileMode(perm), true
+}
+
+// modePrefixString returns the explicit mode prefix for perm.
+func modePrefixString(perm os.FileMode) string {
+	return fmt.Sprintf("%s%04o_", modePrefix, perm)
+}
+
+// explicitPermRequired returns true if perm, the permissions of a target,
+// cannot be expressed with the executable_, private_, and readonly_ attributes
+// and umask, and so must be given explicitly.
+func explicitPermRequired(perm, umask os.FileMode, dir bool) bool {
+	base := os.FileMode(0o666)
+	if dir {
+		base = 0o777
+	}
+	for _, executable := range []bool{false, true} {
+		for _, private := range []bool{false, true} {
+			for _, readOnly := range []bool{false, true} {
+				candidate := base
+				if executable {
+					candidate |= 0o111
+				}
+				if private {
+					candidate &= 0o700
+				}
+				if readOnly {
+					candidate &^= 0o222
+				}
+				if candidate&^umask == perm {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// targetPerm returns the permissions of a target with permissions perm. umask
+// is only applied if perm is not explicit.
+func targetPerm(perm os.FileMode, explicit bool, umask os.FileMode) os.FileMode {
+	if explicit {
+		return perm
+	}
+	return perm &^ umask
+}
@@ -0,0 +1,54 @@
+package chezmoi
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseModePrefix(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		expectedName string
+		expectedPerm os.FileMode
+		expectedOK   bool
+	}{
+		{name: "foo", expectedName: "foo"},
+		{name: "mode_0640_foo", expectedName: "foo", expectedPerm: 0o640, expectedOK: true},
+		{name: "mode_750_dot_foo", expectedName: "dot_foo", expectedPerm: 0o750, expectedOK: true},
+		{name: "mode_foo", expectedName: "mode_foo"},
+		{name: "mode_0800_foo", expectedName: "mode_0800_foo"},
+		{name: "mode_01777_foo", expectedName: "mode_01777_foo"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actualName, actualPerm, actualOK := parseModePrefix(tc.name)
+			assert.Equal(t, tc.expectedName, actualName)
+			assert.Equal(t, tc.expectedPerm, actualPerm)
+			assert.Equal(t, tc.expectedOK, actualOK)
+		})
+	}
+}
+
+func TestExplicitPermRequired(t *testing.T) {
+	for _, tc := range []struct {
+		perm     os.FileMode
+		umask    os.FileMode
+		dir      bool
+		expected bool
+	}{
+		{perm: 0o644, umask: 0o22},
+		{perm: 0o755, umask: 0o22},
+		{perm: 0o600, umask: 0o22},
+		{perm: 0o444, umask: 0o22},
+		{perm: 0o500, umask: 0o22},
+		{perm: 0o664, umask: 0o2},
+		{perm: 0o640, umask: 0o22, expected: true},
+		{perm: 0o664, umask: 0o22, expected: true},
+		{perm: 0o755, umask: 0o22, dir: true},
+		{perm: 0o555, umask: 0o22, dir: true},
+		{perm: 0o750, umask: 0o22, dir: true, expected: true},
+	} {
+		assert.Equal(t, tc.expected, explicitPermRequired(tc.perm, tc.umask, tc.dir), "perm %04o umask %03o dir %t", tc.perm, tc.umask, tc.dir)
+	}
+}
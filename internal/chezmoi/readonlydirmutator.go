@@ -0,0 +1,86 @@
+package chezmoi
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// A readOnlyDirMutator wraps another Mutator and makes a read-only directory
+// writable before the first change to the directory's entries.
+type readOnlyDirMutator struct {
+	Mutator
+	dir      string
+	perm     os.FileMode
+	writable bool
+}
+
+// newReadOnlyDirMutator returns a new readOnlyDirMutator for dir, which has
+// permissions perm.
+func newReadOnlyDirMutator(m Mutator, dir string, perm os.FileMode) *readOnlyDirMutator {
+	return &readOnlyDirMutator{
+		Mutator: m,
+		dir:     dir,
+		perm:    perm,
+	}
+}
+
+// Mkdir implements Mutator.Mkdir.
+func (m *readOnlyDirMutator) Mkdir(name string, perm os.FileMode) error {
+	if err := m.ensureWritable(name); err != nil {
+		return err
+	}
+	return m.Mutator.Mkdir(name, perm)
+}
+
+// RemoveAll implements Mutator.RemoveAll.
+func (m *readOnlyDirMutator) RemoveAll(name string) error {
+	if err := m.ensureWritable(name); err != nil {
+		return err
+	}
+	return m.Mutator.RemoveAll(name)
+}
+
+// Rename implements Mutator.Rename.
+func (m *readOnlyDirMutator) Rename(oldpath, newpath string) error {
+	if err := m.ensureWritable(oldpath); err != nil {
+		return err
+	}
+	if err := m.ensureWritable(newpath); err != nil {
+		return err
+	}
+	return m.Mutator.Rename(oldpath, newpath)
+}
+
+// WriteFile implements Mutator.WriteFile.
+func (m *readOnlyDirMutator) WriteFile(name string, data []byte, perm os.FileMode, currData []byte) error {
+	if err := m.ensureWritable(name); err != nil {
+		return err
+	}
+	return m.Mutator.WriteFile(name, data, perm, currData)
+}
+
+// WriteSymlink implements Mutator.WriteSymlink.
+func (m *readOnlyDirMutator) WriteSymlink(oldname, newname string) error {
+	if err := m.ensureWritable(newname); err != nil {
+		return err
+	}
+	return m.Mutator.WriteSymlink(oldname, newname)
+}
+
+// restore restores the permissions of m's directory if they were changed.
+func (m *readOnlyDirMutator) restore() error {
+	if !m.writable {
+		return nil
+	}
+	m.writable = false
+	return m.Mutator.Chmod(m.dir, m.perm)
+}
+
+// ensureWritable makes m's directory writable if name is in it.
+func (m *readOnlyDirMutator) ensureWritable(name string) error {
+	if m.writable || filepath.Dir(name) != m.dir {
+		return nil
+	}
+	m.writable = true
+	return m.Mutator.Chmod(m.dir, m.perm|0o200)
+}
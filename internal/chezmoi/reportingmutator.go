@@ -0,0 +1,168 @@
+package chezmoi
+
+import (
+	"os"
+	"os/exec"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// A Report describes an action on a target.
+type Report struct {
+	Path   string      `json:"path"`
+	Type   string      `json:"type"`
+	Action string      `json:"action"`
+	Old    *EntryState `json:"old,omitempty"`
+	New    *EntryState `json:"new,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// A ReportingMutator wraps a Mutator and reports each action that changes a
+// target, with the target's states before and after the action.
+type ReportingMutator struct {
+	m      Mutator
+	fs     vfs.FS
+	report func(*Report) error
+}
+
+// NewReportingMutator returns a new ReportingMutator that reads targets' old
+// states from fs and calls report for each action.
+func NewReportingMutator(m Mutator, fs vfs.FS, report func(*Report) error) *ReportingMutator {
+	return &ReportingMutator{
+		m:      m,
+		fs:     fs,
+		report: report,
+	}
+}
+
+// Chmod implements Mutator.Chmod.
+func (m *ReportingMutator) Chmod(name string, mode os.FileMode) error {
+	oldState, err := readEntryState(m.fs, name, false)
+	if err != nil {
+		return err
+	}
+	var newState *EntryState
+	if oldState != nil {
+		newState = &EntryState{
+			Mode:           oldState.Mode&^os.ModePerm | mode&os.ModePerm,
+			ContentsSHA256: oldState.ContentsSHA256,
+		}
+	}
+	return m.reportAction(name, entryStateType(oldState), "chmod", oldState, newState, m.m.Chmod(name, mode))
+}
+
+// IdempotentCmdOutput implements Mutator.IdempotentCmdOutput.
+func (m *ReportingMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
+	return m.m.IdempotentCmdOutput(cmd)
+}
+
+// Mkdir implements Mutator.Mkdir.
+func (m *ReportingMutator) Mkdir(name string, perm os.FileMode) error {
+	newState := &EntryState{
+		Mode: os.ModeDir | perm,
+	}
+	return m.reportAction(name, "dir", "mkdir", nil, newState, m.m.Mkdir(name, perm))
+}
+
+// RemoveAll implements Mutator.RemoveAll.
+func (m *ReportingMutator) RemoveAll(name string) error {
+	oldState, err := readEntryState(m.fs, name, false)
+	if err != nil {
+		return err
+	}
+	return m.reportAction(name, entryStateType(oldState), "remove", oldState, nil, m.m.RemoveAll(name))
+}
+
+// Rename implements Mutator.Rename.
+func (m *ReportingMutator) Rename(oldpath, newpath string) error {
+	oldState, err := readEntryState(m.fs, newpath, false)
+	if err != nil {
+		return err
+	}
+	newState, err := readEntryState(m.fs, oldpath, false)
+	if err != nil {
+		return err
+	}
+	return m.reportAction(newpath, entryStateType(newState), "rename", oldState, newState, m.m.Rename(oldpath, newpath))
+}
+
+// RunCmd implements Mutator.RunCmd.
+func (m *ReportingMutator) RunCmd(cmd *exec.Cmd) error {
+	return m.m.RunCmd(cmd)
+}
+
+// ReportScript reports that the script name with contents data would be run,
+// without running it. Scripts are not run in dry runs, so they can only be
+// reported with ReportScript.
+func (m *ReportingMutator) ReportScript(name string, data []byte) error {
+	return m.reportAction(name, "script", "run-script", nil, newScriptEntryState(data), nil)
+}
+
+// RunScript implements Mutator.RunScript.
+func (m *ReportingMutator) RunScript(name, dir string, data []byte) error {
+	return m.reportAction(name, "script", "run-script", nil, newScriptEntryState(data), m.m.RunScript(name, dir, data))
+}
+
+// Stat implements Mutator.Stat.
+func (m *ReportingMutator) Stat(name string) (os.FileInfo, error) {
+	return m.m.Stat(name)
+}
+
+// WriteFile implements Mutator.WriteFile.
+func (m *ReportingMutator) WriteFile(name string, data []byte, perm os.FileMode, currData []byte) error {
+	oldState, err := readEntryState(m.fs, name, false)
+	if err != nil {
+		return err
+	}
+	return m.reportAction(name, "file", "write", oldState, newFileEntryState(data, perm), m.m.WriteFile(name, data, perm, currData))
+}
+
+// WriteSymlink implements Mutator.WriteSymlink.
+func (m *ReportingMutator) WriteSymlink(oldname, newname string) error {
+	oldState, err := readEntryState(m.fs, newname, false)
+	if err != nil {
+		return err
+	}
+	return m.reportAction(newname, "symlink", "symlink", oldState, newSymlinkEntryState(oldname), m.m.WriteSymlink(oldname, newname))
+}
+
+// reportAction reports an action and returns err, the action's error.
+func (m *ReportingMutator) reportAction(name, entryType, action string, oldState, newState *EntryState, err error) error {
+	report := &Report{
+		Path:   name,
+		Type:   entryType,
+		Action: action,
+		Old:    oldState,
+		New:    newState,
+	}
+	if err != nil {
+		report.Error = err.Error()
+	}
+	if reportErr := m.report(report); err == nil {
+		err = reportErr
+	}
+	return err
+}
+
+// newScriptEntryState returns the state of a script with contents data.
+func newScriptEntryState(data []byte) *EntryState {
+	return &EntryState{
+		ContentsSHA256: sha256Sum(data),
+	}
+}
+
+// entryStateType returns the type of entry that has state s.
+func entryStateType(s *EntryState) string {
+	switch {
+	case s == nil:
+		return ""
+	case s.Mode.IsDir():
+		return "dir"
+	case s.Mode.IsRegular():
+		return "file"
+	case s.Mode&os.ModeType == os.ModeSymlink:
+		return "symlink"
+	default:
+		return "other"
+	}
+}
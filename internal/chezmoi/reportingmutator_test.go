@@ -0,0 +1,60 @@
+package chezmoi
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestReportingMutator(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".dir": map[string]interface{}{
+				"file": "# contents of .dir/file\n",
+			},
+			".file": "# contents of .file\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	var reports []*Report
+	mutator := NewReportingMutator(NewFSMutator(fs), fs, func(report *Report) error {
+		reports = append(reports, report)
+		return nil
+	})
+	require.NoError(t, mutator.RemoveAll("/home/user/.dir"))
+	require.NoError(t, mutator.WriteFile("/home/user/.file", []byte("# new contents of .file\n"), 0o600, []byte("# contents of .file\n")))
+	require.Error(t, mutator.Mkdir("/home/user/.file", 0o755))
+
+	assert.Equal(t, []*Report{
+		{
+			Path:   "/home/user/.dir",
+			Type:   "dir",
+			Action: "remove",
+			Old: &EntryState{
+				Mode: os.ModeDir | 0o755,
+			},
+		},
+		{
+			Path:   "/home/user/.file",
+			Type:   "file",
+			Action: "write",
+			Old:    newFileEntryState([]byte("# contents of .file\n"), 0o644),
+			New:    newFileEntryState([]byte("# new contents of .file\n"), 0o600),
+		},
+		{
+			Path:   "/home/user/.file",
+			Type:   "dir",
+			Action: "mkdir",
+			New: &EntryState{
+				Mode: os.ModeDir | 0o755,
+			},
+			Error: reports[2].Error,
+		},
+	}, reports)
+	assert.NotEmpty(t, reports[2].Error)
+}
@@ -8,7 +8,6 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
@@ -16,14 +15,23 @@ import (
 	vfs "github.com/twpayne/go-vfs"
 )
 
-// FIXME allow encrypted scripts
-// FIXME add pre- and post- attributes
+// A ScriptPhase is the phase of TargetState.Apply in which a script is run.
+type ScriptPhase int
+
+// Script phases.
+const (
+	ScriptPhaseDuring ScriptPhase = iota
+	ScriptPhaseBefore
+	ScriptPhaseAfter
+)
 
 // A ScriptAttributes holds attributes parsed from a source script name.
 type ScriptAttributes struct {
-	Name     string
-	Once     bool
-	Template bool
+	Name      string
+	Once      bool
+	Phase     ScriptPhase
+	Encrypted bool
+	Template  bool
 }
 
 // A ScriptState represents the state of a script.
@@ -37,6 +45,8 @@ type Script struct {
 	sourceName       string
 	targetName       string
 	Once             bool
+	Phase            ScriptPhase
+	Encrypted        bool
 	Template         bool
 	contents         []byte
 	contentsErr      error
@@ -48,6 +58,8 @@ type scriptConcreteValue struct {
 	SourcePath string `json:"sourcePath" yaml:"sourcePath"`
 	TargetPath string `json:"targetPath" yaml:"targetPath"`
 	Once       bool   `json:"once" yaml:"once"`
+	Phase      string `json:"phase" yaml:"phase"`
+	Encrypted  bool   `json:"encrypted" yaml:"encrypted"`
 	Template   bool   `json:"template" yaml:"template"`
 	Contents   string `json:"contents" yaml:"contents"`
 }
@@ -56,19 +68,35 @@ type scriptConcreteValue struct {
 func ParseScriptAttributes(sourceName string) ScriptAttributes {
 	name := strings.TrimPrefix(sourceName, runPrefix)
 	once := false
+	phase := ScriptPhaseDuring
+	encrypted := false
 	template := false
 	if strings.HasPrefix(name, oncePrefix) {
 		once = true
 		name = strings.TrimPrefix(name, oncePrefix)
 	}
+	switch {
+	case strings.HasPrefix(name, beforePrefix):
+		phase = ScriptPhaseBefore
+		name = strings.TrimPrefix(name, beforePrefix)
+	case strings.HasPrefix(name, afterPrefix):
+		phase = ScriptPhaseAfter
+		name = strings.TrimPrefix(name, afterPrefix)
+	}
+	if strings.HasPrefix(name, encryptedPrefix) {
+		encrypted = true
+		name = strings.TrimPrefix(name, encryptedPrefix)
+	}
 	if strings.HasSuffix(name, TemplateSuffix) {
 		template = true
 		name = strings.TrimSuffix(name, TemplateSuffix)
 	}
 	return ScriptAttributes{
-		Name:     name,
-		Once:     once,
-		Template: template,
+		Name:      name,
+		Once:      once,
+		Phase:     phase,
+		Encrypted: encrypted,
+		Template:  template,
 	}
 }
 
@@ -78,6 +106,15 @@ func (sa ScriptAttributes) SourceName() string {
 	if sa.Once {
 		sourceName += oncePrefix
 	}
+	switch sa.Phase {
+	case ScriptPhaseBefore:
+		sourceName += beforePrefix
+	case ScriptPhaseAfter:
+		sourceName += afterPrefix
+	}
+	if sa.Encrypted {
+		sourceName += encryptedPrefix
+	}
 	sourceName += sa.Name
 	if sa.Template {
 		sourceName += TemplateSuffix
@@ -90,9 +127,12 @@ func (s *Script) AppendAllEntries(allEntries []Entry) []Entry {
 	return allEntries
 }
 
-// Apply runs s.
+// Apply runs s if applyOptions is for s's phase.
 func (s *Script) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions) error {
-	if applyOptions.Ignore(s.targetName) {
+	if applyOptions.Ignore(s.targetName) || !applyOptions.Filter.IncludeEntry(s) {
+		return nil
+	}
+	if s.Phase != applyOptions.scriptPhase {
 		return nil
 	}
 	contents, err := s.Contents()
@@ -116,6 +156,12 @@ func (s *Script) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Ap
 		}
 	}
 
+	targetPath := filepath.Join(applyOptions.DestDir, s.targetName)
+	if applyOptions.ReportScript != nil {
+		if err := applyOptions.ReportScript(targetPath, contents); err != nil {
+			return err
+		}
+	}
 	if applyOptions.Verbose {
 		if _, err := applyOptions.Stdout.Write(contents); err != nil {
 			return err
@@ -125,34 +171,7 @@ func (s *Script) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Ap
 		return nil
 	}
 
-	// Write the temporary script file. Put the randomness on the front of the
-	// filename to preserve any file extension for Windows scripts.
-	f, err := ioutil.TempFile("", "*."+filepath.Base(s.targetName))
-	if err != nil {
-		return err
-	}
-
-	defer func() {
-		_ = os.RemoveAll(f.Name())
-	}()
-	if err := os.Chmod(f.Name(), 0o700); err != nil {
-		return err
-	}
-	if _, err := f.Write(contents); err != nil {
-		return err
-	}
-	if err := f.Close(); err != nil {
-		return err
-	}
-
-	// Run the temporary script file.
-	//nolint:gosec
-	c := exec.Command(f.Name())
-	c.Dir = filepath.Join(applyOptions.DestDir, filepath.Dir(s.targetName))
-	c.Stdout = os.Stdout
-	c.Stderr = os.Stderr
-	c.Stdin = os.Stdin
-	if err := c.Run(); err != nil {
+	if err := mutator.RunScript(targetPath, scriptDir(targetPath), contents); err != nil {
 		return err
 	}
 
@@ -174,8 +193,8 @@ func (s *Script) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Ap
 }
 
 // ConcreteValue implements Entry.ConcreteValue.
-func (s *Script) ConcreteValue(ignore func(string) bool, sourceDir string, umask os.FileMode, recursive bool) (interface{}, error) {
-	if ignore(s.targetName) {
+func (s *Script) ConcreteValue(ignore func(string) bool, filter *EntryTypeFilter, sourceDir func(Entry) string, umask os.FileMode, recursive bool) (interface{}, error) {
+	if ignore(s.targetName) || !filter.IncludeEntry(s) {
 		return nil, nil
 	}
 	contents, err := s.Contents()
@@ -184,9 +203,11 @@ func (s *Script) ConcreteValue(ignore func(string) bool, sourceDir string, umask
 	}
 	return &scriptConcreteValue{
 		Type:       "script",
-		SourcePath: filepath.Join(sourceDir, s.SourceName()),
+		SourcePath: filepath.Join(sourceDir(s), s.SourceName()),
 		TargetPath: s.TargetName(),
 		Once:       s.Once,
+		Phase:      s.Phase.String(),
+		Encrypted:  s.Encrypted,
 		Template:   s.Template,
 		Contents:   string(contents),
 	}, nil
@@ -220,9 +241,53 @@ func (s *Script) TargetName() string {
 	return s.targetName
 }
 
+// String returns p's string representation.
+func (p ScriptPhase) String() string {
+	switch p {
+	case ScriptPhaseBefore:
+		return "before"
+	case ScriptPhaseAfter:
+		return "after"
+	default:
+		return "during"
+	}
+}
+
+// scriptDir returns the directory in which to run a script for targetPath.
+// Scripts are run in targetPath's parent directory, but scripts with the before
+// attribute may be run before it is created, so scripts are run in the closest
+// directory that exists.
+func scriptDir(targetPath string) string {
+	dir := filepath.Dir(targetPath)
+	for {
+		if _, err := os.Stat(dir); err == nil || filepath.Dir(dir) == dir {
+			return dir
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// appendScripts appends all scripts in entries with phase to scripts, in the
+// order in which they would be applied.
+func appendScripts(scripts []*Script, entries map[string]Entry, ignore func(string) bool, phase ScriptPhase) []*Script {
+	for _, entryName := range sortedEntryNames(entries) {
+		switch entry := entries[entryName].(type) {
+		case *Dir:
+			if !ignore(entry.targetName) {
+				scripts = appendScripts(scripts, entry.Entries, ignore, phase)
+			}
+		case *Script:
+			if entry.Phase == phase {
+				scripts = append(scripts, entry)
+			}
+		}
+	}
+	return scripts
+}
+
 // archive writes s to w.
-func (s *Script) archive(w *tar.Writer, ignore func(string) bool, headerTemplate *tar.Header, umask os.FileMode) error {
-	if ignore(s.targetName) {
+func (s *Script) archive(w *tar.Writer, ignore func(string) bool, filter *EntryTypeFilter, headerTemplate *tar.Header, umask os.FileMode) error {
+	if ignore(s.targetName) || !filter.IncludeEntry(s) {
 		return nil
 	}
 	contents, err := s.Contents()
@@ -240,3 +305,30 @@ func (s *Script) archive(w *tar.Writer, ignore func(string) bool, headerTemplate
 	_, err = w.Write(contents)
 	return err
 }
+
+// writeTempScript writes contents to a new temporary executable file whose
+// name ends with name and returns its path.
+func writeTempScript(name string, contents []byte) (string, error) {
+	// Put the randomness on the front of the filename to preserve any file
+	// extension for Windows scripts.
+	f, err := ioutil.TempFile("", "*."+name)
+	if err != nil {
+		return "", err
+	}
+	if err := writeScriptFile(f, contents); err != nil {
+		_ = f.Close()
+		_ = os.RemoveAll(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+func writeScriptFile(f *os.File, contents []byte) error {
+	if err := os.Chmod(f.Name(), 0o700); err != nil {
+		return err
+	}
+	if _, err := f.Write(contents); err != nil {
+		return err
+	}
+	return f.Close()
+}
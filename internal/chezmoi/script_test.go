@@ -0,0 +1,73 @@
+package chezmoi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScriptAttributes(t *testing.T) {
+	for _, tc := range []struct {
+		sourceName string
+		sa         ScriptAttributes
+	}{
+		{
+			sourceName: "run_foo",
+			sa: ScriptAttributes{
+				Name: "foo",
+			},
+		},
+		{
+			sourceName: "run_once_foo",
+			sa: ScriptAttributes{
+				Name: "foo",
+				Once: true,
+			},
+		},
+		{
+			sourceName: "run_before_foo",
+			sa: ScriptAttributes{
+				Name:  "foo",
+				Phase: ScriptPhaseBefore,
+			},
+		},
+		{
+			sourceName: "run_after_foo.tmpl",
+			sa: ScriptAttributes{
+				Name:     "foo",
+				Phase:    ScriptPhaseAfter,
+				Template: true,
+			},
+		},
+		{
+			sourceName: "run_once_before_foo",
+			sa: ScriptAttributes{
+				Name:  "foo",
+				Once:  true,
+				Phase: ScriptPhaseBefore,
+			},
+		},
+		{
+			sourceName: "run_encrypted_foo",
+			sa: ScriptAttributes{
+				Name:      "foo",
+				Encrypted: true,
+			},
+		},
+		{
+			sourceName: "run_once_after_encrypted_foo.tmpl",
+			sa: ScriptAttributes{
+				Name:      "foo",
+				Once:      true,
+				Phase:     ScriptPhaseAfter,
+				Encrypted: true,
+				Template:  true,
+			},
+		},
+	} {
+		t.Run(tc.sourceName, func(t *testing.T) {
+			assert.Equal(t, tc.sa, ParseScriptAttributes(tc.sourceName))
+			assert.Equal(t, tc.sourceName, tc.sa.SourceName())
+		})
+	}
+}
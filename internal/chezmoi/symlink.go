@@ -13,6 +13,7 @@ import (
 type Symlink struct {
 	sourceName       string
 	targetName       string
+	External         bool
 	Template         bool
 	linkname         string
 	linknameErr      error
@@ -34,7 +35,7 @@ func (s *Symlink) AppendAllEntries(allEntries []Entry) []Entry {
 
 // Apply ensures that the state of s's target in fs matches s.
 func (s *Symlink) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions) error {
-	if applyOptions.Ignore(s.targetName) {
+	if applyOptions.Ignore(s.targetName) || !applyOptions.Filter.IncludeEntry(s) {
 		return nil
 	}
 	target, err := s.Linkname()
@@ -48,6 +49,8 @@ func (s *Symlink) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *A
 	} else {
 		info, err = fs.Lstat(targetPath)
 	}
+	targetState := newSymlinkEntryState(target)
+	var actualState *EntryState
 	switch {
 	case err == nil && target == "":
 		return mutator.RemoveAll(targetPath)
@@ -59,19 +62,27 @@ func (s *Symlink) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *A
 			return err
 		}
 		if currentTarget == target {
-			return nil
+			return applyOptions.recordEntryState(targetPath, targetState)
 		}
+		actualState = newSymlinkEntryState(currentTarget)
 	case err == nil:
+		actualState = &EntryState{Mode: info.Mode()}
 	case os.IsNotExist(err):
 	default:
 		return err
 	}
-	return mutator.WriteSymlink(target, targetPath)
+	if update, err := applyOptions.checkDrift(targetPath, actualState, targetState); err != nil || !update {
+		return err
+	}
+	if err := mutator.WriteSymlink(target, targetPath); err != nil {
+		return err
+	}
+	return applyOptions.recordAppliedEntryState(fs, targetPath, false, targetState)
 }
 
 // ConcreteValue implements Entry.ConcreteValue.
-func (s *Symlink) ConcreteValue(ignore func(string) bool, sourceDir string, umask os.FileMode, recursive bool) (interface{}, error) {
-	if ignore(s.targetName) {
+func (s *Symlink) ConcreteValue(ignore func(string) bool, filter *EntryTypeFilter, sourceDir func(Entry) string, umask os.FileMode, recursive bool) (interface{}, error) {
+	if ignore(s.targetName) || !filter.IncludeEntry(s) {
 		return nil, nil
 	}
 	linkname, err := s.Linkname()
@@ -80,7 +91,7 @@ func (s *Symlink) ConcreteValue(ignore func(string) bool, sourceDir string, umas
 	}
 	return &symlinkConcreteValue{
 		Type:       "symlink",
-		SourcePath: filepath.Join(sourceDir, s.SourceName()),
+		SourcePath: filepath.Join(sourceDir(s), s.SourceName()),
 		TargetPath: s.TargetName(),
 		Template:   s.Template,
 		Linkname:   linkname,
@@ -116,8 +127,8 @@ func (s *Symlink) TargetName() string {
 }
 
 // archive writes s to w.
-func (s *Symlink) archive(w *tar.Writer, ignore func(string) bool, headerTemplate *tar.Header, umask os.FileMode) error {
-	if ignore(s.targetName) {
+func (s *Symlink) archive(w *tar.Writer, ignore func(string) bool, filter *EntryTypeFilter, headerTemplate *tar.Header, umask os.FileMode) error {
+	if ignore(s.targetName) || !filter.IncludeEntry(s) {
 		return nil
 	}
 	linkname, err := s.Linkname()
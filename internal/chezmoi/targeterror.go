@@ -0,0 +1,86 @@
+package chezmoi
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// An AbortError stops applying targets, even if ApplyOptions.KeepGoing is
+// set.
+type AbortError struct {
+	Err error
+}
+
+// A TargetError is an error applying a target.
+type TargetError struct {
+	TargetPath string
+	Err        error
+}
+
+// TargetErrors are the errors applying targets when ApplyOptions.KeepGoing is
+// set.
+type TargetErrors []*TargetError
+
+func (e *AbortError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *AbortError) Unwrap() error {
+	return e.Err
+}
+
+func (e *TargetError) Error() string {
+	return e.TargetPath + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *TargetError) Unwrap() error {
+	return e.Err
+}
+
+func (e TargetErrors) Error() string {
+	sb := &strings.Builder{}
+	if len(e) == 1 {
+		sb.WriteString("1 target failed:")
+	} else {
+		fmt.Fprintf(sb, "%d targets failed:", len(e))
+	}
+	for _, targetErr := range e {
+		sb.WriteString("\n  ")
+		sb.WriteString(targetErr.Error())
+	}
+	return sb.String()
+}
+
+// errorOrNil returns e as an error, or nil if e is empty.
+func (e TargetErrors) errorOrNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// appendError handles err, an error applying targetPath. If o.KeepGoing is set
+// and err is not an *AbortError then err is appended to errs so that applying
+// can continue. Otherwise, err is returned.
+func (o *ApplyOptions) appendError(errs TargetErrors, targetPath string, err error) (TargetErrors, error) {
+	var abortErr *AbortError
+	if !o.KeepGoing || errors.As(err, &abortErr) {
+		return errs, err
+	}
+	var targetErrs TargetErrors
+	var targetErr *TargetError
+	switch {
+	case errors.As(err, &targetErrs):
+		return append(errs, targetErrs...), nil
+	case errors.As(err, &targetErr):
+		return append(errs, targetErr), nil
+	default:
+		return append(errs, &TargetError{
+			TargetPath: targetPath,
+			Err:        err,
+		}), nil
+	}
+}
@@ -0,0 +1,39 @@
+package chezmoi
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyOptionsAppendError(t *testing.T) {
+	errFoo := errors.New("foo")
+	errBar := errors.New("bar")
+	errAbort := &AbortError{Err: errors.New("abort")}
+
+	o := &ApplyOptions{}
+	errs, err := o.appendError(nil, "/home/user/.foo", errFoo)
+	assert.Nil(t, errs)
+	assert.Equal(t, errFoo, err)
+
+	o.KeepGoing = true
+	errs, err = o.appendError(nil, "/home/user/.foo", errFoo)
+	assert.NoError(t, err)
+	errs, err = o.appendError(errs, "/home/user/.dir", TargetErrors{
+		{TargetPath: "/home/user/.dir/bar", Err: errBar},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, TargetErrors{
+		{TargetPath: "/home/user/.foo", Err: errFoo},
+		{TargetPath: "/home/user/.dir/bar", Err: errBar},
+	}, errs)
+	assert.Equal(t, "2 targets failed:\n  /home/user/.foo: foo\n  /home/user/.dir/bar: bar", errs.Error())
+	assert.True(t, errors.Is(errs[1], errBar))
+
+	errs, err = o.appendError(errs, "/home/user/.baz", errAbort)
+	assert.Len(t, errs, 2)
+	assert.Equal(t, errAbort, err)
+
+	assert.NoError(t, TargetErrors(nil).errorOrNil())
+}
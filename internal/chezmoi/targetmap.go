@@ -0,0 +1,191 @@
+package chezmoi
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// TargetMapName is the name of the file that maps source paths to target
+// paths.
+const TargetMapName = ".chezmoimap"
+
+// RemoveTargetMapping returns the contents of the .chezmoimap file data with
+// the lines that map sourceName removed, and whether any lines were removed.
+// Each line is executed as a template on its own, so mappings that are
+// generated by templates that span several lines are not removed.
+func (ts *TargetState) RemoveTargetMapping(data []byte, sourceName string) ([]byte, bool) {
+	sourcePath := filepath.ToSlash(sourceName)
+	b := &bytes.Buffer{}
+	removed := false
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		line := s.Text()
+		executedLine := line
+		if data, err := ts.ExecuteTemplateData(TargetMapName, []byte(line)); err == nil {
+			executedLine = string(data)
+		}
+		if fields, err := splitTargetMapFields(executedLine); err == nil && len(fields) > 0 && path.Clean(fields[0]) == sourcePath {
+			removed = true
+			continue
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.Bytes(), removed
+}
+
+// addMappedEntry adds entry, which is mapped to its target name by a
+// .chezmoimap file, to ts. Any missing parent directories are created. If an
+// entry with the same target name already exists then entry overrides it if it
+// comes from an earlier source directory.
+func (ts *TargetState) addMappedEntry(entry Entry) error {
+	sourceDir := ts.EntrySourceDir(entry)
+	components := splitPathList(entry.TargetName())
+	dirNames, name := components[:len(components)-1], components[len(components)-1]
+	entries := ts.Entries
+	for i, dirName := range dirNames {
+		parentEntry, ok := entries[dirName]
+		if !ok {
+			dir := newDir(TargetMapName, filepath.Join(dirNames[:i+1]...), false, 0o777)
+			dir.External = true
+			ts.setEntrySourceDir(dir, sourceDir)
+			entries[dirName] = dir
+			parentEntry = dir
+		}
+		dir, ok := parentEntry.(*Dir)
+		if !ok {
+			return fmt.Errorf("%s: not a directory", filepath.Join(dirNames[:i+1]...))
+		}
+		entries = dir.Entries
+	}
+	if existingEntry, ok := entries[name]; ok {
+		existingSourceDir := ts.EntrySourceDir(existingEntry)
+		if existingSourceDir == sourceDir {
+			return fmt.Errorf("%s: target %s is also the target of %s", filepath.Join(sourceDir, entry.SourceName()), entry.TargetName(), filepath.Join(existingSourceDir, existingEntry.SourceName()))
+		}
+		for _, sd := range ts.sourceDirs() {
+			if sd == existingSourceDir {
+				break
+			}
+			if sd == sourceDir {
+				// The existing entry comes from a later source directory.
+				return nil
+			}
+		}
+	}
+	entries[name] = entry
+	return nil
+}
+
+// readTargetMap reads the .chezmoimap file in sourceDir, if it exists, and
+// returns a map of slash-separated source paths to target names.
+func (ts *TargetState) readTargetMap(fs vfs.FS, sourceDir string) (map[string]string, error) {
+	path := filepath.Join(sourceDir, TargetMapName)
+	data, err := ts.executeTemplate(fs, path)
+	switch {
+	case os.IsNotExist(err):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	targetMap, err := parseTargetMap(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return targetMap, nil
+}
+
+// parseTargetMap parses the contents of a .chezmoimap file, after its template
+// has been executed, and returns a map of slash-separated source paths to
+// target names.
+func parseTargetMap(data []byte) (map[string]string, error) {
+	targetMap := make(map[string]string)
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for lineNumber := 1; s.Scan(); lineNumber++ {
+		sourcePath, targetName, err := parseTargetMapLine(s.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		if sourcePath == "" {
+			continue
+		}
+		if _, ok := targetMap[sourcePath]; ok {
+			return nil, fmt.Errorf("line %d: %s: duplicate source path", lineNumber, sourcePath)
+		}
+		targetMap[sourcePath] = targetName
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return targetMap, nil
+}
+
+// parseTargetMapLine parses a single line of a .chezmoimap file. It returns
+// an empty source path if the line is empty or a comment.
+func parseTargetMapLine(line string) (string, string, error) {
+	fields, err := splitTargetMapFields(line)
+	switch {
+	case err != nil:
+		return "", "", err
+	case len(fields) == 0:
+		return "", "", nil
+	case len(fields) != 2:
+		return "", "", errors.New("expected a source path and a target path")
+	}
+	sourcePath := path.Clean(fields[0])
+	if path.IsAbs(sourcePath) || sourcePath == "." || sourcePath == ".." || strings.HasPrefix(sourcePath, "../") {
+		return "", "", fmt.Errorf("%s: invalid source path", fields[0])
+	}
+	targetName := filepath.Clean(filepath.FromSlash(fields[1]))
+	if filepath.IsAbs(targetName) || targetName == "." || targetName == ".." || strings.HasPrefix(targetName, ".."+string(filepath.Separator)) {
+		return "", "", fmt.Errorf("%s: invalid target path", fields[1])
+	}
+	return sourcePath, targetName, nil
+}
+
+// splitTargetMapFields splits line into whitespace-separated fields. Fields
+// may be double-quoted Go strings to include whitespace. An unquoted # starts a
+// comment.
+func splitTargetMapFields(line string) ([]string, error) {
+	var fields []string
+	for {
+		line = strings.TrimLeft(line, " \t")
+		switch {
+		case line == "" || line[0] == '#':
+			return fields, nil
+		case line[0] == '"':
+			end := 1
+			for end < len(line) && line[end] != '"' {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(line) {
+				return nil, fmt.Errorf("%s: unterminated string", line)
+			}
+			field, err := strconv.Unquote(line[:end+1])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", line[:end+1], err)
+			}
+			fields = append(fields, field)
+			line = line[end+1:]
+		default:
+			end := strings.IndexAny(line, " \t")
+			if end == -1 {
+				end = len(line)
+			}
+			fields = append(fields, line[:end])
+			line = line[end:]
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package chezmoi
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestParseTargetMap(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		data        string
+		expected    map[string]string
+		expectedErr bool
+	}{
+		{
+			name:     "empty",
+			expected: map[string]string{},
+		},
+		{
+			name: "mappings",
+			data: "# comment\n" +
+				"fedora/foo.conf .config/foo/foo.conf # comment\n" +
+				"\n" +
+				"\"code settings.json\" \".config/Code - OSS/User/settings.json\"\n",
+			expected: map[string]string{
+				"fedora/foo.conf":    filepath.FromSlash(".config/foo/foo.conf"),
+				"code settings.json": filepath.FromSlash(".config/Code - OSS/User/settings.json"),
+			},
+		},
+		{
+			name:        "duplicate_source_path",
+			data:        "foo bar\nfoo baz\n",
+			expectedErr: true,
+		},
+		{
+			name:        "missing_target_path",
+			data:        "foo\n",
+			expectedErr: true,
+		},
+		{
+			name:        "absolute_target_path",
+			data:        "foo /etc/foo\n",
+			expectedErr: true,
+		},
+		{
+			name:        "target_path_outside_destination",
+			data:        "foo ../foo\n",
+			expectedErr: true,
+		},
+		{
+			name:        "unterminated_string",
+			data:        "foo \"bar\n",
+			expectedErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := parseTargetMap([]byte(tc.data))
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestRemoveTargetMapping(t *testing.T) {
+	ts := NewTargetState(
+		WithTemplateData(map[string]interface{}{
+			"distro": "fedora",
+		}),
+	)
+	data := "# comment\n" +
+		"{{ .distro }}/foo.conf .config/{{ .distro }}/foo.conf\n" +
+		"bar .bar\n"
+	actual, removed := ts.RemoveTargetMapping([]byte(data), filepath.FromSlash("fedora/foo.conf"))
+	assert.True(t, removed)
+	assert.Equal(t, "# comment\nbar .bar\n", string(actual))
+
+	actual, removed = ts.RemoveTargetMapping([]byte(data), "baz")
+	assert.False(t, removed)
+	assert.Equal(t, data, string(actual))
+}
+
+func TestTargetStatePopulateTargetMap(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/base": map[string]interface{}{
+			".chezmoimap": "base.conf .config/app/app.conf\noverridden .overridden\n",
+			"base.conf":   "base",
+			"dot_config":  &vfst.Dir{Perm: 0o755},
+			"overridden":  "base",
+		},
+		"/home/user/personal": map[string]interface{}{
+			".chezmoimap":       "{{ .distro }}/foo.conf .foo/foo.conf\n",
+			"dot_overridden":    "personal",
+			"fedora/foo.conf":   "fedora",
+			"ubuntu/foo.conf":   "ubuntu",
+			"private_dot_netrc": "personal",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	ts := NewTargetState(
+		WithDestDir("/home/user"),
+		WithSourceDir("/home/user/personal"),
+		WithSourceDirs([]string{"/home/user/base", "/home/user/personal"}),
+		WithTemplateData(map[string]interface{}{
+			"distro": "fedora",
+		}),
+	)
+	require.NoError(t, ts.Populate(fs, nil))
+	require.NoError(t, ts.Evaluate())
+
+	for targetName, expected := range map[string]struct {
+		sourceDir  string
+		sourceName string
+		contents   string
+	}{
+		".config/app/app.conf": {
+			sourceDir:  "/home/user/base",
+			sourceName: "base.conf",
+			contents:   "base",
+		},
+		".foo/foo.conf": {
+			sourceDir:  "/home/user/personal",
+			sourceName: "fedora/foo.conf",
+			contents:   "fedora",
+		},
+		".overridden": {
+			sourceDir:  "/home/user/personal",
+			sourceName: "dot_overridden",
+			contents:   "personal",
+		},
+		"ubuntu/foo.conf": {
+			sourceDir:  "/home/user/personal",
+			sourceName: "ubuntu/foo.conf",
+			contents:   "ubuntu",
+		},
+	} {
+		entry, err := ts.Get(fs, filepath.Join("/home/user", targetName))
+		require.NoError(t, err)
+		assert.Equal(t, filepath.FromSlash(targetName), entry.TargetName())
+		assert.Equal(t, expected.sourceDir, ts.EntrySourceDir(entry))
+		assert.Equal(t, filepath.FromSlash(expected.sourceName), entry.SourceName())
+		contents, err := entry.(*File).Contents()
+		require.NoError(t, err)
+		assert.Equal(t, expected.contents, string(contents))
+	}
+
+	for _, targetName := range []string{"base.conf", "fedora/foo.conf", "overridden"} {
+		_, err := ts.Get(fs, filepath.Join("/home/user", targetName))
+		assert.Error(t, err, targetName)
+	}
+
+	foo, err := ts.Get(fs, "/home/user/.foo")
+	require.NoError(t, err)
+	assert.True(t, IsExternal(foo))
+	assert.Equal(t, TargetMapName, foo.SourceName())
+	assert.Equal(t, "/home/user/personal", ts.EntrySourceDir(foo))
+
+	config, err := ts.Get(fs, "/home/user/.config")
+	require.NoError(t, err)
+	assert.False(t, IsExternal(config))
+}
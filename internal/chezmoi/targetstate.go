@@ -23,6 +23,8 @@ import (
 var DefaultTemplateOptions = []string{"missingkey=error"}
 
 const (
+	dataName         = ".chezmoidata"
+	externalName     = ".chezmoiexternal"
 	ignoreName       = ".chezmoiignore"
 	removeName       = ".chezmoiremove"
 	templatesDirName = ".chezmoitemplates"
@@ -51,13 +53,38 @@ type PopulateOptions struct {
 	ExecuteTemplates bool
 }
 
+// A DuplicateTarget is a target name that several paths in a source directory
+// map to.
+type DuplicateTarget struct {
+	TargetName  string
+	SourcePaths []string
+}
+
+// A DuplicateTargetsError is returned by TargetState.Populate if a source
+// directory contains duplicate targets.
+type DuplicateTargetsError struct {
+	DuplicateTargets []DuplicateTarget
+}
+
+func (e *DuplicateTargetsError) Error() string {
+	ss := make([]string, 0, len(e.DuplicateTargets))
+	for _, dt := range e.DuplicateTargets {
+		ss = append(ss, fmt.Sprintf("%s: duplicate target from %s", dt.TargetName, strings.Join(dt.SourcePaths, " and ")))
+	}
+	return strings.Join(ss, ", ")
+}
+
 // A TargetState represents the root target state.
 type TargetState struct {
+	CacheDir        string
+	CacheFS         vfs.FS
 	DestDir         string
 	Entries         map[string]Entry
+	GitCommand      string
 	GPG             *GPG
 	MinVersion      *semver.Version
 	SourceDir       string
+	SourceDirs      []string
 	TargetIgnore    *PatternSet
 	TargetRemove    *PatternSet
 	TemplateData    map[string]interface{}
@@ -65,11 +92,28 @@ type TargetState struct {
 	TemplateOptions []string
 	Templates       map[string]*template.Template
 	Umask           os.FileMode
+
+	// declaredAttributes maps directories in source directories to the
+	// attributes declared in their .chezmoiattributes files.
+	declaredAttributes map[string]map[string]*DeclaredAttributes
+
+	// entrySourceDirs records the source directory of each entry that does
+	// not come from SourceDir.
+	entrySourceDirs map[Entry]string
 }
 
 // A TargetStateOption sets an option on a TargeState.
 type TargetStateOption func(*TargetState)
 
+// WithCache sets the cache directory and the filesystem in which it is
+// stored.
+func WithCache(fs vfs.FS, cacheDir string) TargetStateOption {
+	return func(ts *TargetState) {
+		ts.CacheFS = fs
+		ts.CacheDir = cacheDir
+	}
+}
+
 // WithDestDir sets DestDir.
 func WithDestDir(destDir string) TargetStateOption {
 	return func(ts *TargetState) {
@@ -84,6 +128,13 @@ func WithEntries(entries map[string]Entry) TargetStateOption {
 	}
 }
 
+// WithGitCommand sets the git command used by git-repo externals.
+func WithGitCommand(gitCommand string) TargetStateOption {
+	return func(ts *TargetState) {
+		ts.GitCommand = gitCommand
+	}
+}
+
 // WithGPG sets the GPG options.
 func WithGPG(gpg *GPG) TargetStateOption {
 	return func(ts *TargetState) {
@@ -105,6 +156,14 @@ func WithSourceDir(sourceDir string) TargetStateOption {
 	}
 }
 
+// WithSourceDirs sets the source directories, in order of increasing
+// precedence.
+func WithSourceDirs(sourceDirs []string) TargetStateOption {
+	return func(ts *TargetState) {
+		ts.SourceDirs = sourceDirs
+	}
+}
+
 // WithTargetIgnore sets the target patterns to ignore.
 func WithTargetIgnore(targetIgnore *PatternSet) TargetStateOption {
 	return func(ts *TargetState) {
@@ -158,6 +217,7 @@ func WithUmask(umask os.FileMode) TargetStateOption {
 func NewTargetState(options ...TargetStateOption) *TargetState {
 	ts := &TargetState{
 		Entries:         make(map[string]Entry),
+		GitCommand:      "git",
 		TargetIgnore:    NewPatternSet(),
 		TargetRemove:    NewPatternSet(),
 		TemplateOptions: DefaultTemplateOptions,
@@ -218,6 +278,12 @@ func (ts *TargetState) Add(fs vfs.FS, addOptions AddOptions, targetPath string,
 			return fmt.Errorf("%s: not a directory", parentDirName)
 		}
 		parentDir := parentEntry.(*Dir)
+		if _, ok := parentDir.Entries[filepath.Base(targetName)]; !ok && IsExternal(parentDir) {
+			return fmt.Errorf("%s: parent directory is external, edit %s instead", targetPath, filepath.Join(ts.EntrySourceDir(parentDir), parentDir.SourceName()))
+		}
+		if err := ts.ensureDirInSourceDir(parentDir, mutator); err != nil {
+			return err
+		}
 		parentDirSourceName = parentDir.sourceName
 		entries = parentDir.Entries
 	}
@@ -297,6 +363,7 @@ func (ts *TargetState) AllEntries() []Entry {
 
 // Apply ensures that ts.DestDir in fs matches ts.
 func (ts *TargetState) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions) error {
+	var errs TargetErrors
 	if applyOptions.Remove {
 		// Build a set of targets to remove.
 		targetsToRemove := make(map[string]struct{})
@@ -335,28 +402,80 @@ func (ts *TargetState) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptio
 		sort.Sort(sort.Reverse(sort.StringSlice(sortedTargetsToRemove)))
 		for _, target := range sortedTargetsToRemove {
 			if err := mutator.RemoveAll(target); err != nil {
-				return err
+				if errs, err = applyOptions.appendError(errs, target, err); err != nil {
+					return err
+				}
 			}
 		}
 	}
 
+	entries := make([]Entry, 0, len(ts.Entries))
 	for _, entryName := range sortedEntryNames(ts.Entries) {
-		if err := ts.Entries[entryName].Apply(fs, mutator, follow, applyOptions); err != nil {
+		entries = append(entries, ts.Entries[entryName])
+	}
+	if err := ts.ApplyEntries(fs, mutator, follow, applyOptions, entries); err != nil {
+		if errs, err = applyOptions.appendError(errs, ts.DestDir, err); err != nil {
 			return err
 		}
 	}
-	return nil
+	return errs.errorOrNil()
+}
+
+// ApplyEntries ensures that the targets of entries in fs match entries. All
+// scripts in entries with the before attribute are run first, then all
+// entries are applied, and finally all scripts with the after attribute are
+// run. If applyOptions.KeepGoing is set then entries are applied even if
+// applying earlier entries fails, and the errors are returned as TargetErrors.
+func (ts *TargetState) ApplyEntries(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions, entries []Entry) error {
+	entriesMap := make(map[string]Entry, len(entries))
+	for _, entry := range entries {
+		entriesMap[entry.TargetName()] = entry
+	}
+
+	var errs TargetErrors
+
+	beforeApplyOptions := *applyOptions
+	beforeApplyOptions.scriptPhase = ScriptPhaseBefore
+	for _, script := range appendScripts(nil, entriesMap, applyOptions.Ignore, ScriptPhaseBefore) {
+		if err := script.Apply(fs, mutator, follow, &beforeApplyOptions); err != nil {
+			if errs, err = applyOptions.appendError(errs, filepath.Join(ts.DestDir, script.TargetName()), err); err != nil {
+				return err
+			}
+		}
+	}
+
+	duringApplyOptions := *applyOptions
+	duringApplyOptions.scriptPhase = ScriptPhaseDuring
+	for _, entry := range entries {
+		if err := entry.Apply(fs, mutator, follow, &duringApplyOptions); err != nil {
+			if errs, err = applyOptions.appendError(errs, filepath.Join(ts.DestDir, entry.TargetName()), err); err != nil {
+				return err
+			}
+		}
+	}
+
+	afterApplyOptions := *applyOptions
+	afterApplyOptions.scriptPhase = ScriptPhaseAfter
+	for _, script := range appendScripts(nil, entriesMap, applyOptions.Ignore, ScriptPhaseAfter) {
+		if err := script.Apply(fs, mutator, follow, &afterApplyOptions); err != nil {
+			if errs, err = applyOptions.appendError(errs, filepath.Join(ts.DestDir, script.TargetName()), err); err != nil {
+				return err
+			}
+		}
+	}
+
+	return errs.errorOrNil()
 }
 
 // Archive writes ts to w.
-func (ts *TargetState) Archive(w *tar.Writer, umask os.FileMode) error {
+func (ts *TargetState) Archive(w *tar.Writer, filter *EntryTypeFilter, umask os.FileMode) error {
 	headerTemplate, err := ts.getTarHeaderTemplate()
 	if err != nil {
 		return err
 	}
 
 	for _, entryName := range sortedEntryNames(ts.Entries) {
-		if err := ts.Entries[entryName].archive(w, ts.TargetIgnore.Match, headerTemplate, umask); err != nil {
+		if err := ts.Entries[entryName].archive(w, ts.TargetIgnore.Match, filter, headerTemplate, umask); err != nil {
 			return err
 		}
 	}
@@ -364,10 +483,10 @@ func (ts *TargetState) Archive(w *tar.Writer, umask os.FileMode) error {
 }
 
 // ConcreteValue returns a value suitable for serialization.
-func (ts *TargetState) ConcreteValue(recursive bool) (interface{}, error) {
+func (ts *TargetState) ConcreteValue(filter *EntryTypeFilter, recursive bool) (interface{}, error) {
 	var entryConcreteValues []interface{}
 	for _, entryName := range sortedEntryNames(ts.Entries) {
-		entryConcreteValue, err := ts.Entries[entryName].ConcreteValue(ts.TargetIgnore.Match, ts.SourceDir, ts.Umask, recursive)
+		entryConcreteValue, err := ts.Entries[entryName].ConcreteValue(ts.TargetIgnore.Match, filter, ts.EntrySourceDir, ts.Umask, recursive)
 		if err != nil {
 			return nil, err
 		}
@@ -378,6 +497,20 @@ func (ts *TargetState) ConcreteValue(recursive bool) (interface{}, error) {
 	return entryConcreteValues, nil
 }
 
+// DeclaredAttributes returns the attributes declared for entry in a
+// .chezmoiattributes file, or nil if there are none.
+func (ts *TargetState) DeclaredAttributes(entry Entry) *DeclaredAttributes {
+	return ts.declaredAttributesFor(filepath.Join(ts.EntrySourceDir(entry), entry.SourceName()))
+}
+
+// EntrySourceDir returns the source directory that entry came from.
+func (ts *TargetState) EntrySourceDir(entry Entry) string {
+	if sourceDir, ok := ts.entrySourceDirs[entry]; ok {
+		return sourceDir
+	}
+	return ts.SourceDir
+}
+
 // Evaluate evaluates all of the entries in ts.
 func (ts *TargetState) Evaluate() error {
 	for _, entryName := range sortedEntryNames(ts.Entries) {
@@ -445,25 +578,122 @@ func (ts *TargetState) ImportTAR(r *tar.Reader, importTAROptions ImportTAROption
 	return nil
 }
 
-// Populate walks fs from ts.SourceDir to populate ts.
+// Populate walks fs from each of ts's source directories, in order, to
+// populate ts. Entries, ignore and remove patterns, and templates from later
+// source directories override those from earlier ones.
 func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
-	return vfs.Walk(fs, ts.SourceDir, func(path string, info os.FileInfo, _ error) error {
-		relPath, err := filepath.Rel(ts.SourceDir, path)
+	if err := ts.PopulateTemplateData(fs); err != nil {
+		return err
+	}
+
+	// External entries are added after all source directories are walked so
+	// that they are not overwritten by the directories that contain them.
+	type externalSource struct {
+		sourceDir string
+		relPath   string
+	}
+	var externalSources []externalSource
+	var allMappedEntries []Entry
+	for _, sourceDir := range ts.sourceDirs() {
+		externalRelPaths, mappedEntries, err := ts.populateSourceDir(fs, sourceDir, options)
+		if err != nil {
+			return err
+		}
+		for _, externalRelPath := range externalRelPaths {
+			externalSources = append(externalSources, externalSource{
+				sourceDir: sourceDir,
+				relPath:   externalRelPath,
+			})
+		}
+		allMappedEntries = append(allMappedEntries, mappedEntries...)
+	}
+
+	for _, entry := range allMappedEntries {
+		if err := ts.addMappedEntry(entry); err != nil {
+			return err
+		}
+	}
+	for _, es := range externalSources {
+		if err := ts.addExternals(fs, es.sourceDir, es.relPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// populateSourceDir walks fs from sourceDir to populate ts and returns the
+// paths of the .chezmoiexternal files that it finds, relative to sourceDir,
+// and the entries that are mapped by its .chezmoimap file, which are not added
+// to ts.
+func (ts *TargetState) populateSourceDir(fs vfs.FS, sourceDir string, options *PopulateOptions) ([]string, []Entry, error) {
+	targetIgnore := NewPatternSet()
+	targetRemove := NewPatternSet()
+	var externalRelPaths []string
+	var targetMap map[string]string
+	var mappedEntries []Entry
+	// targetRelPaths maps the target names in this source directory to the
+	// paths that they come from, so that duplicate targets can be reported.
+	// Entries in different source directories override each other and so
+	// are not duplicates.
+	targetRelPaths := make(map[string]string)
+	var duplicateTargets []DuplicateTarget
+	isDuplicateTarget := func(targetName, relPath string) bool {
+		otherRelPath, ok := targetRelPaths[targetName]
+		if !ok {
+			targetRelPaths[targetName] = relPath
+			return false
+		}
+		sourcePath := filepath.Join(sourceDir, relPath)
+		for i := range duplicateTargets {
+			if duplicateTargets[i].TargetName == targetName {
+				duplicateTargets[i].SourcePaths = append(duplicateTargets[i].SourcePaths, sourcePath)
+				return true
+			}
+		}
+		duplicateTargets = append(duplicateTargets, DuplicateTarget{
+			TargetName:  targetName,
+			SourcePaths: []string{filepath.Join(sourceDir, otherRelPath), sourcePath},
+		})
+		return true
+	}
+	if err := walk(fs, sourceDir, func(path string, info os.FileInfo, _ error) error {
+		relPath, err := filepath.Rel(sourceDir, path)
 		if err != nil {
 			return err
 		}
 		if relPath == "." {
-			return nil
+			if err := ts.readAttributesFile(fs, path); err != nil {
+				return err
+			}
+			targetMap, err = ts.readTargetMap(fs, path)
+			return err
 		}
 		// Treat all files and directories beginning with "." specially.
 		if _, name := filepath.Split(relPath); strings.HasPrefix(name, ".") {
 			switch {
 			case info.Name() == ignoreName:
-				dns := dirNames(parseDirNameComponents(splitPathList(relPath)))
-				return ts.addPatterns(fs, ts.TargetIgnore, path, filepath.Join(dns...))
+				dns, err := ts.dirNames(parseDirNameComponents(splitPathList(relPath)))
+				if err != nil {
+					return fmt.Errorf("%s: %w", path, err)
+				}
+				return ts.addPatterns(fs, targetIgnore, path, filepath.Join(dns...))
+			case isExternalName(info.Name()):
+				externalRelPaths = append(externalRelPaths, relPath)
+				return nil
 			case info.Name() == removeName:
-				dns := dirNames(parseDirNameComponents(splitPathList(relPath)))
-				return ts.addPatterns(fs, ts.TargetRemove, path, filepath.Join(dns...))
+				dns, err := ts.dirNames(parseDirNameComponents(splitPathList(relPath)))
+				if err != nil {
+					return fmt.Errorf("%s: %w", path, err)
+				}
+				return ts.addPatterns(fs, targetRemove, path, filepath.Join(dns...))
+			case info.Name() == AttributesName:
+				// .chezmoiattributes files are read when their directory is
+				// visited.
+				return nil
+			case info.Name() == TargetMapName:
+				// The .chezmoimap file is read when the source directory is
+				// visited.
+				return nil
 			case info.Name() == templatesDirName:
 				if err := ts.addTemplatesDir(fs, path); err != nil {
 					return err
@@ -492,21 +722,89 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 		switch {
 		case info.IsDir():
 			components := splitPathList(relPath)
-			das := parseDirNameComponents(components)
-			dns := dirNames(das)
+			dns, err := ts.dirNames(parseDirNameComponents(components))
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
 			targetName := filepath.Join(dns...)
+			if _, ok := targetMap[filepath.ToSlash(relPath)]; ok {
+				return fmt.Errorf("%s: directories cannot be mapped", path)
+			}
+			if isDuplicateTarget(targetName, relPath) {
+				return filepath.SkipDir
+			}
 			entries, err := ts.findEntries(dns[:len(dns)-1])
 			if err != nil {
 				return err
 			}
-			da := das[len(das)-1]
-			entries[da.Name] = newDir(relPath, targetName, da.Exact, da.Perm)
+			da, err := ts.declaredAttributesFor(path).ParseDirAttributes(info.Name())
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			name := dns[len(dns)-1]
+			// Directories in later source directories override the
+			// attributes of directories in earlier ones, but keep their
+			// entries.
+			dir, ok := entries[name].(*Dir)
+			if ok && !dir.External {
+				dir.sourceName = relPath
+				dir.Exact = da.Exact
+				dir.Perm = da.Perm
+			} else {
+				dir = newDir(relPath, targetName, da.Exact, da.Perm)
+				entries[name] = dir
+			}
+			dir.ExplicitPerm = da.ExplicitPerm
+			ts.setEntrySourceDir(dir, sourceDir)
+			return ts.readAttributesFile(fs, path)
 		case info.Mode().IsRegular():
-			psfp := parseSourceFilePath(relPath)
-			dns := dirNames(psfp.dirAttributes)
-			entries, err := ts.findEntries(dns)
+			psfp, err := parseSourceFilePath(relPath, ts.declaredAttributesFor(path))
 			if err != nil {
-				return err
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			dns, err := ts.dirNames(psfp.dirAttributes)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			var name string
+			switch {
+			case psfp.fileAttributes != nil:
+				psfp.fileAttributes.Name, err = ts.renderName(psfp.fileAttributes.Name)
+				name = psfp.fileAttributes.Name
+			case psfp.scriptAttributes != nil:
+				psfp.scriptAttributes.Name, err = ts.renderName(psfp.scriptAttributes.Name)
+				name = psfp.scriptAttributes.Name
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			targetName := filepath.Join(append(dns, name)...)
+			mappedTargetName, mapped := targetMap[filepath.ToSlash(relPath)]
+			if mapped {
+				if psfp.scriptAttributes != nil {
+					return fmt.Errorf("%s: scripts cannot be mapped", path)
+				}
+				targetName = mappedTargetName
+				psfp.fileAttributes.Name = filepath.Base(targetName)
+			}
+			if isDuplicateTarget(targetName, relPath) {
+				return nil
+			}
+			// Mapped entries are added after all source directories are
+			// walked, as the directories that contain them might not exist
+			// yet.
+			addEntry := func(entry Entry) error {
+				ts.setEntrySourceDir(entry, sourceDir)
+				if mapped {
+					mappedEntries = append(mappedEntries, entry)
+					return nil
+				}
+				entries, err := ts.findEntries(dns)
+				if err != nil {
+					return err
+				}
+				entries[name] = entry
+				return nil
 			}
 			switch {
 			case psfp.fileAttributes != nil && psfp.fileAttributes.Mode&os.ModeType == 0 || psfp.scriptAttributes != nil:
@@ -514,7 +812,7 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 					return fs.ReadFile(path)
 				}
 				evaluateContents := readFile
-				if psfp.fileAttributes != nil && psfp.fileAttributes.Encrypted {
+				if psfp.fileAttributes != nil && psfp.fileAttributes.Encrypted || psfp.scriptAttributes != nil && psfp.scriptAttributes.Encrypted {
 					prevEvaluateContents := evaluateContents
 					evaluateContents = func() ([]byte, error) {
 						ciphertext, err := prevEvaluateContents()
@@ -536,27 +834,99 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 						}
 					}
 				}
+				if psfp.fileAttributes != nil && psfp.fileAttributes.Modify {
+					// Modify scripts, like templates, are not executed when
+					// only the source contents are needed.
+					if options == nil || options.ExecuteTemplates {
+						targetPath := filepath.Join(ts.DestDir, targetName)
+						prevEvaluateContents := evaluateContents
+						evaluateContents = func() ([]byte, error) {
+							script, err := prevEvaluateContents()
+							if err != nil {
+								return nil, err
+							}
+							currentContents, err := fs.ReadFile(targetPath)
+							if err != nil && !os.IsNotExist(err) {
+								return nil, err
+							}
+							return modifyContents(targetPath, script, currentContents)
+						}
+					}
+				}
+				if psfp.fileAttributes != nil && psfp.fileAttributes.Block {
+					// Like modify scripts, managed blocks are only inserted
+					// when the target contents are needed.
+					if options == nil || options.ExecuteTemplates {
+						targetPath := filepath.Join(ts.DestDir, targetName)
+						prevEvaluateContents := evaluateContents
+						evaluateContents = func() ([]byte, error) {
+							block, err := prevEvaluateContents()
+							if err != nil {
+								return nil, err
+							}
+							currentContents, err := fs.ReadFile(targetPath)
+							if err != nil && !os.IsNotExist(err) {
+								return nil, err
+							}
+							contents, err := blockContents(block, currentContents)
+							if err != nil {
+								return nil, fmt.Errorf("%s: %w", targetPath, err)
+							}
+							return contents, nil
+						}
+					}
+				}
+				if psfp.fileAttributes != nil && psfp.fileAttributes.Merge {
+					// Partial documents are also only merged when the target
+					// contents are needed.
+					if options == nil || options.ExecuteTemplates {
+						targetPath := filepath.Join(ts.DestDir, targetName)
+						prevEvaluateContents := evaluateContents
+						evaluateContents = func() ([]byte, error) {
+							partial, err := prevEvaluateContents()
+							if err != nil {
+								return nil, err
+							}
+							currentContents, err := fs.ReadFile(targetPath)
+							if err != nil && !os.IsNotExist(err) {
+								return nil, err
+							}
+							contents, err := mergeDocumentContents(psfp.fileAttributes.Name, partial, currentContents)
+							if err != nil {
+								return nil, fmt.Errorf("%s: %w", targetPath, err)
+							}
+							return contents, nil
+						}
+					}
+				}
 				switch {
 				case psfp.fileAttributes != nil:
 					entry := &File{
 						sourceName:       relPath,
-						targetName:       filepath.Join(append(dns, psfp.fileAttributes.Name)...),
+						targetName:       targetName,
+						Block:            psfp.fileAttributes.Block,
+						Create:           psfp.fileAttributes.Create,
 						Empty:            psfp.fileAttributes.Empty,
 						Encrypted:        psfp.fileAttributes.Encrypted,
+						ExplicitPerm:     psfp.fileAttributes.ExplicitPerm,
+						Merge:            psfp.fileAttributes.Merge,
+						Modify:           psfp.fileAttributes.Modify,
 						Perm:             psfp.fileAttributes.Mode.Perm(),
 						Template:         psfp.fileAttributes.Template,
 						evaluateContents: evaluateContents,
 					}
-					entries[psfp.fileAttributes.Name] = entry
+					return addEntry(entry)
 				case psfp.scriptAttributes != nil:
 					entry := &Script{
 						sourceName:       relPath,
-						targetName:       filepath.Join(append(dns, psfp.scriptAttributes.Name)...),
+						targetName:       targetName,
 						Once:             psfp.scriptAttributes.Once,
+						Phase:            psfp.scriptAttributes.Phase,
+						Encrypted:        psfp.scriptAttributes.Encrypted,
 						Template:         psfp.scriptAttributes.Template,
 						evaluateContents: evaluateContents,
 					}
-					entries[psfp.scriptAttributes.Name] = entry
+					return addEntry(entry)
 				}
 			case psfp.fileAttributes != nil && psfp.fileAttributes.Mode&os.ModeType == os.ModeSymlink:
 				evaluateLinkname := func() (string, error) {
@@ -571,11 +941,11 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 				}
 				entry := &Symlink{
 					sourceName:       relPath,
-					targetName:       filepath.Join(append(dns, psfp.fileAttributes.Name)...),
+					targetName:       targetName,
 					Template:         psfp.fileAttributes.Template,
 					evaluateLinkname: evaluateLinkname,
 				}
-				entries[psfp.fileAttributes.Name] = entry
+				return addEntry(entry)
 			default:
 				return fmt.Errorf("%s: unsupported file type", path)
 			}
@@ -583,26 +953,42 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 			return fmt.Errorf("%s: unsupported file type", path)
 		}
 		return nil
-	})
+	}); err != nil {
+		return nil, nil, err
+	}
+	if len(duplicateTargets) != 0 {
+		return nil, nil, &DuplicateTargetsError{
+			DuplicateTargets: duplicateTargets,
+		}
+	}
+
+	ts.TargetIgnore.merge(targetIgnore)
+	ts.TargetRemove.merge(targetRemove)
+	return externalRelPaths, mappedEntries, nil
 }
 
 func (ts *TargetState) addDir(targetName string, entries map[string]Entry, parentDirSourceName string, exact bool, perm os.FileMode, createKeepFile bool, mutator Mutator) error {
+	// Preserve permissions that cannot be expressed with attributes.
+	explicitPerm := explicitPermRequired(perm, ts.Umask, true)
 	name := filepath.Base(targetName)
 	if entry, ok := entries[name]; ok {
-		if _, ok = entry.(*Dir); !ok {
+		dir, ok := entry.(*Dir)
+		if !ok {
 			return fmt.Errorf("%s: already added and not a directory", targetName)
 		}
-		return nil
+		return ts.ensureDirInSourceDir(dir, mutator)
 	}
 	sourceName := DirAttributes{
-		Name:  name,
-		Exact: exact,
-		Perm:  perm,
+		Name:         name,
+		Exact:        exact,
+		ExplicitPerm: explicitPerm,
+		Perm:         perm,
 	}.SourceName()
 	if parentDirSourceName != "" {
 		sourceName = filepath.Join(parentDirSourceName, sourceName)
 	}
 	dir := newDir(sourceName, targetName, exact, perm)
+	dir.ExplicitPerm = explicitPerm
 	if err := mutator.Mkdir(filepath.Join(ts.SourceDir, sourceName), 0o777&^ts.Umask); err != nil {
 		return err
 	}
@@ -624,6 +1010,12 @@ func (ts *TargetState) addFile(targetName string, entries map[string]Entry, pare
 		if !ok {
 			return fmt.Errorf("%s: already added and not a regular file", targetName)
 		}
+		if ts.EntrySourceDir(existingFile) != ts.SourceDir {
+			// Override the file from another source directory.
+			existingFile = nil
+		}
+	}
+	if existingFile != nil {
 		var err error
 		existingContents, err = existingFile.Contents()
 		if err != nil {
@@ -632,24 +1024,34 @@ func (ts *TargetState) addFile(targetName string, entries map[string]Entry, pare
 	}
 
 	empty := info.Size() == 0
+	// Preserve permissions that cannot be expressed with attributes.
+	explicitPerm := explicitPermRequired(perm, ts.Umask, false)
+	sourceNameComponent := name
+	if existingFile != nil {
+		// Keep the existing file's name, which might be a template, and its
+		// source directory, which is different if the file is mapped.
+		parentDirSourceName, sourceNameComponent = existingSourceName(existingFile)
+	}
 	sourceName := FileAttributes{
-		Name:      name,
-		Mode:      perm,
-		Empty:     empty,
-		Encrypted: encrypted,
-		Template:  template,
+		Name:         sourceNameComponent,
+		Mode:         perm,
+		Empty:        empty,
+		Encrypted:    encrypted,
+		ExplicitPerm: explicitPerm,
+		Template:     template,
 	}.SourceName()
 	if parentDirSourceName != "" {
 		sourceName = filepath.Join(parentDirSourceName, sourceName)
 	}
 	file := &File{
-		sourceName: sourceName,
-		targetName: targetName,
-		Empty:      empty,
-		Encrypted:  encrypted,
-		Perm:       perm,
-		Template:   template,
-		contents:   contents,
+		sourceName:   sourceName,
+		targetName:   targetName,
+		Empty:        empty,
+		Encrypted:    encrypted,
+		ExplicitPerm: explicitPerm,
+		Perm:         perm,
+		Template:     template,
+		contents:     contents,
 	}
 	if existingFile != nil {
 		if bytes.Equal(existingFile.contents, file.contents) {
@@ -707,14 +1109,26 @@ func (ts *TargetState) addSymlink(targetName string, entries map[string]Entry, p
 		if !ok {
 			return fmt.Errorf("%s: already added and not a symlink", targetName)
 		}
+		if ts.EntrySourceDir(existingSymlink) != ts.SourceDir {
+			// Override the symlink from another source directory.
+			existingSymlink = nil
+		}
+	}
+	if existingSymlink != nil {
 		var err error
 		existingLinkname, err = existingSymlink.Linkname()
 		if err != nil {
 			return err
 		}
 	}
+	sourceNameComponent := name
+	if existingSymlink != nil {
+		// Keep the existing symlink's name and source directory, as for
+		// files.
+		parentDirSourceName, sourceNameComponent = existingSourceName(existingSymlink)
+	}
 	sourceName := FileAttributes{
-		Name: name,
+		Name: sourceNameComponent,
 		Mode: os.ModeSymlink,
 	}.SourceName()
 	if parentDirSourceName != "" {
@@ -770,6 +1184,29 @@ func (ts *TargetState) addTemplatesDir(fs vfs.FS, path string) error {
 	})
 }
 
+// ensureDirInSourceDir ensures that dir, which may come from another source
+// directory, exists in ts.SourceDir so that entries can be added to it.
+func (ts *TargetState) ensureDirInSourceDir(dir *Dir, mutator Mutator) error {
+	if ts.EntrySourceDir(dir) == ts.SourceDir {
+		return nil
+	}
+	return vfs.MkdirAll(mutator, filepath.Join(ts.SourceDir, dir.sourceName), 0o777&^ts.Umask)
+}
+
+// dirNames returns the target names of the directories with dirAttributes,
+// executing any templates in their names.
+func (ts *TargetState) dirNames(dirAttributes []DirAttributes) ([]string, error) {
+	dns := make([]string, len(dirAttributes))
+	for i, da := range dirAttributes {
+		dn, err := ts.renderName(da.Name)
+		if err != nil {
+			return nil, err
+		}
+		dns[i] = dn
+	}
+	return dns, nil
+}
+
 func (ts *TargetState) executeTemplate(fs vfs.FS, path string) ([]byte, error) {
 	data, err := fs.ReadFile(path)
 	if err != nil {
@@ -784,6 +1221,9 @@ func (ts *TargetState) findEntries(dirNames []string) (map[string]Entry, error)
 		if entry, ok := entries[dirName]; !ok {
 			return nil, os.ErrNotExist
 		} else if dir, ok := entry.(*Dir); ok {
+			if err := dir.loadEntries(); err != nil {
+				return nil, err
+			}
 			entries = dir.Entries
 		} else {
 			return nil, fmt.Errorf("%s: not a directory", filepath.Join(dirNames[:i+1]...))
@@ -805,6 +1245,79 @@ func (ts *TargetState) findEntry(name string) (Entry, error) {
 	return entry, nil
 }
 
+// declaredAttributesFor returns the attributes declared for the source path
+// path, or nil if there are none.
+func (ts *TargetState) declaredAttributesFor(path string) *DeclaredAttributes {
+	dir, name := filepath.Split(path)
+	return ts.declaredAttributes[filepath.Clean(dir)][name]
+}
+
+// readAttributesFile reads the .chezmoiattributes file in dir, if it exists.
+func (ts *TargetState) readAttributesFile(fs vfs.FS, dir string) error {
+	path := filepath.Join(dir, AttributesName)
+	data, err := fs.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		return nil
+	case err != nil:
+		return err
+	}
+	declaredAttributes, err := ParseAttributesFile(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if ts.declaredAttributes == nil {
+		ts.declaredAttributes = make(map[string]map[string]*DeclaredAttributes)
+	}
+	ts.declaredAttributes[filepath.Clean(dir)] = declaredAttributes
+	return nil
+}
+
+// existingSourceName returns the source name of the directory that contains
+// entry and the name of entry with its attributes removed.
+func existingSourceName(entry Entry) (string, string) {
+	parentDirSourceName, base := filepath.Split(entry.SourceName())
+	return strings.TrimSuffix(parentDirSourceName, string(filepath.Separator)), ParseFileAttributes(base).Name
+}
+
+// renderName returns the target name of the name component name, executing it
+// as a template if it is one.
+func (ts *TargetState) renderName(name string) (string, error) {
+	if !isTemplateName(name) {
+		return name, nil
+	}
+	data, err := ts.ExecuteTemplateData(name, []byte(name))
+	if err != nil {
+		return "", err
+	}
+	renderedName := string(data)
+	if renderedName == "" || renderedName == "." || renderedName == ".." || strings.ContainsAny(renderedName, `/`+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s: invalid target name %q", name, renderedName)
+	}
+	return renderedName, nil
+}
+
+// setEntrySourceDir records that entry came from sourceDir.
+func (ts *TargetState) setEntrySourceDir(entry Entry, sourceDir string) {
+	if sourceDir == ts.SourceDir {
+		delete(ts.entrySourceDirs, entry)
+		return
+	}
+	if ts.entrySourceDirs == nil {
+		ts.entrySourceDirs = make(map[Entry]string)
+	}
+	ts.entrySourceDirs[entry] = sourceDir
+}
+
+// sourceDirs returns ts's source directories, in order of increasing
+// precedence.
+func (ts *TargetState) sourceDirs() []string {
+	if len(ts.SourceDirs) == 0 {
+		return []string{ts.SourceDir}
+	}
+	return ts.SourceDirs
+}
+
 func (ts *TargetState) importHeader(r io.Reader, importTAROptions ImportTAROptions, header *tar.Header, mutator Mutator) error {
 	targetPath := header.Name
 	if importTAROptions.StripComponents > 0 {
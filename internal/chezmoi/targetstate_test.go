@@ -1,6 +1,7 @@
 package chezmoi
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -488,3 +489,285 @@ func TestTargetStatePopulate(t *testing.T) {
 		})
 	}
 }
+
+func TestTargetStatePopulateSourceDirs(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/base": map[string]interface{}{
+			".chezmoidata.toml":       "email = \"base@example.com\"\nname = \"base\"\n",
+			".chezmoiignore":          "!.ignored\n.overridden\n",
+			".chezmoitemplates/greet": "hello from base",
+			"dot_bashrc":              "base",
+			"dot_greet.tmpl":          `{{ template "greet" }}`,
+			"dot_name.tmpl":           "{{ .name }} {{ .email }}",
+			"dot_ssh/config":          "base",
+			"dot_ssh/known_hosts":     "base",
+		},
+		"/home/user/personal": map[string]interface{}{
+			".chezmoidata.toml":       "name = \"personal\"\n",
+			".chezmoiignore":          ".ignored\n!.overridden\n",
+			".chezmoitemplates/greet": "hello from personal",
+			"dot_bashrc":              "personal",
+			"private_dot_ssh/config":  "personal",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	ts := NewTargetState(
+		WithDestDir("/home/user"),
+		WithSourceDir("/home/user/personal"),
+		WithSourceDirs([]string{"/home/user/base", "/home/user/personal"}),
+	)
+	require.NoError(t, ts.Populate(fs, nil))
+	require.NoError(t, ts.Evaluate())
+
+	for targetName, expected := range map[string]struct {
+		sourceDir  string
+		sourceName string
+		contents   string
+	}{
+		".bashrc": {
+			sourceDir:  "/home/user/personal",
+			sourceName: "dot_bashrc",
+			contents:   "personal",
+		},
+		".greet": {
+			sourceDir:  "/home/user/base",
+			sourceName: "dot_greet.tmpl",
+			contents:   "hello from personal",
+		},
+		".name": {
+			sourceDir:  "/home/user/base",
+			sourceName: "dot_name.tmpl",
+			contents:   "personal base@example.com",
+		},
+		".ssh/config": {
+			sourceDir:  "/home/user/personal",
+			sourceName: "private_dot_ssh/config",
+			contents:   "personal",
+		},
+		".ssh/known_hosts": {
+			sourceDir:  "/home/user/base",
+			sourceName: "dot_ssh/known_hosts",
+			contents:   "base",
+		},
+	} {
+		entry, err := ts.findEntry(targetName)
+		require.NoError(t, err)
+		assert.Equal(t, expected.sourceDir, ts.EntrySourceDir(entry))
+		assert.Equal(t, expected.sourceName, entry.SourceName())
+		contents, err := entry.(*File).Contents()
+		require.NoError(t, err)
+		assert.Equal(t, expected.contents, string(contents))
+	}
+
+	ssh, err := ts.findEntry(".ssh")
+	require.NoError(t, err)
+	assert.Equal(t, "/home/user/personal", ts.EntrySourceDir(ssh))
+	assert.Equal(t, os.FileMode(0o700), ssh.(*Dir).Perm)
+
+	assert.True(t, ts.TargetIgnore.Match(".ignored"))
+	assert.False(t, ts.TargetIgnore.Match(".overridden"))
+}
+
+func TestTargetStatePopulateTemplateNames(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		root        interface{}
+		wantEntries map[string]string
+		wantIgnore  []string
+		wantErr     bool
+	}{
+		{
+			name: "dir",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/dot_config/{{ .codeDir }}/User/settings.json": "{}",
+			},
+			wantEntries: map[string]string{
+				".config/Code - OSS":                    "dot_config/{{ .codeDir }}",
+				".config/Code - OSS/User/settings.json": "dot_config/{{ .codeDir }}/User/settings.json",
+			},
+		},
+		{
+			name: "file",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/dot_{{ .chezmoi.hostname }}.conf.tmpl": "contents",
+			},
+			wantEntries: map[string]string{
+				".myhost.conf": "dot_{{ .chezmoi.hostname }}.conf.tmpl",
+			},
+		},
+		{
+			name: "ignore",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/{{ .chezmoi.hostname }}/.chezmoiignore": "foo\n",
+			},
+			wantEntries: map[string]string{
+				"myhost": "{{ .chezmoi.hostname }}",
+			},
+			wantIgnore: []string{"myhost/foo"},
+		},
+		{
+			name: "collision",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"dot_{{ .chezmoi.hostname }}": "templated",
+					"dot_myhost":                  "literal",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "collision_dir",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"{{ .chezmoi.hostname }}": &vfst.Dir{Perm: 0o755},
+					"myhost":                  &vfst.Dir{Perm: 0o755},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "separator",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/{{ .path }}": "contents",
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/{{ if false }}foo{{ end }}": "contents",
+			},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			ts := NewTargetState(
+				WithDestDir("/home/user"),
+				WithSourceDir("/home/user/.local/share/chezmoi"),
+				WithTemplateData(map[string]interface{}{
+					"chezmoi": map[string]interface{}{
+						"hostname": "myhost",
+					},
+					"codeDir": "Code - OSS",
+					"path":    "foo/bar",
+				}),
+			)
+			err = ts.Populate(fs, nil)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			for targetName, sourceName := range tc.wantEntries {
+				entry, err := ts.Get(fs, filepath.Join("/home/user", targetName))
+				require.NoError(t, err)
+				assert.Equal(t, sourceName, entry.SourceName())
+				assert.Equal(t, targetName, entry.TargetName())
+			}
+			for _, pattern := range tc.wantIgnore {
+				assert.True(t, ts.TargetIgnore.Match(pattern))
+			}
+		})
+	}
+}
+
+func TestTargetStatePopulateDuplicateTargets(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		root     interface{}
+		expected []DuplicateTarget
+	}{
+		{
+			name: "attributes",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"dot_foo":         "foo",
+					"private_dot_foo": "foo",
+				},
+			},
+			expected: []DuplicateTarget{
+				{
+					TargetName: ".foo",
+					SourcePaths: []string{
+						"/home/user/.local/share/chezmoi/dot_foo",
+						"/home/user/.local/share/chezmoi/private_dot_foo",
+					},
+				},
+			},
+		},
+		{
+			name: "template",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"foo":      "foo",
+					"foo.tmpl": "foo",
+					"run_foo":  "#!/bin/sh\n",
+				},
+			},
+			expected: []DuplicateTarget{
+				{
+					TargetName: "foo",
+					SourcePaths: []string{
+						"/home/user/.local/share/chezmoi/foo",
+						"/home/user/.local/share/chezmoi/foo.tmpl",
+						"/home/user/.local/share/chezmoi/run_foo",
+					},
+				},
+			},
+		},
+		{
+			name: "dirs",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					"dot_dir/bar":       "bar",
+					"exact_dot_dir/bar": "bar",
+				},
+			},
+			expected: []DuplicateTarget{
+				{
+					TargetName: ".dir",
+					SourcePaths: []string{
+						"/home/user/.local/share/chezmoi/dot_dir",
+						"/home/user/.local/share/chezmoi/exact_dot_dir",
+					},
+				},
+			},
+		},
+		{
+			name: "source_dirs",
+			root: map[string]interface{}{
+				"/home/user/base/dot_foo":                 "base",
+				"/home/user/.local/share/chezmoi/dot_foo": "personal",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			ts := NewTargetState(
+				WithDestDir("/home/user"),
+				WithSourceDir("/home/user/.local/share/chezmoi"),
+				WithSourceDirs([]string{"/home/user/base", "/home/user/.local/share/chezmoi"}),
+			)
+			err = ts.Populate(fs, nil)
+			if tc.expected == nil {
+				assert.NoError(t, err)
+				return
+			}
+			for _, dt := range tc.expected {
+				for i, sourcePath := range dt.SourcePaths {
+					dt.SourcePaths[i] = filepath.FromSlash(sourcePath)
+				}
+			}
+			var duplicateTargetsErr *DuplicateTargetsError
+			require.True(t, errors.As(err, &duplicateTargetsErr))
+			assert.Equal(t, tc.expected, duplicateTargetsErr.DuplicateTargets)
+		})
+	}
+}
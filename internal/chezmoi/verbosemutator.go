@@ -102,6 +102,12 @@ func (m *VerboseMutator) RunCmd(cmd *exec.Cmd) error {
 	return err
 }
 
+// RunScript implements Mutator.RunScript. Scripts' contents are already
+// written when they are applied in verbose mode, so nothing is logged.
+func (m *VerboseMutator) RunScript(name, dir string, data []byte) error {
+	return m.m.RunScript(name, dir, data)
+}
+
 // Stat implements Mutator.Stat.
 func (m *VerboseMutator) Stat(name string) (os.FileInfo, error) {
 	return m.m.Stat(name)